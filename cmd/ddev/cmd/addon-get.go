@@ -23,7 +23,7 @@ import (
 var AddonGetCmd = &cobra.Command{
 	Use:               "get <addonOrURL>",
 	Aliases:           []string{"install"},
-	Args:              cobra.ExactArgs(1),
+	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: ddevapp.GetAddonNamesFunc(1),
 	Short:             "Get/Download a 3rd party add-on (service, provider, etc.)",
 	Long:              `Get/Download a 3rd party add-on (service, provider, etc.). This can be a GitHub repo, in which case the latest release will be used, or it can be a link to a .tar.gz in the correct format (like a particular release's .tar.gz) or it can be a local directory.`,
@@ -39,8 +39,17 @@ ddev add-on get https://github.com/ddev/ddev-drupal-contrib/tarball/main
 ddev add-on get https://github.com/ddev/ddev-opensearch/tarball/refs/pull/15/head
 ddev add-on get /path/to/package
 ddev add-on get /path/to/tarball.tar.gz
+ddev add-on install --from-lock
+ddev add-on get ddev/ddev-redis --dry-run
 `,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
+		fromLock, _ := cmd.Flags().GetBool("from-lock")
+		if fromLock && len(args) > 0 {
+			return fmt.Errorf("--from-lock installs the add-ons of %s and doesn't take an add-on argument", ddevapp.AddonLockFile)
+		}
+		if !fromLock && len(args) != 1 {
+			return fmt.Errorf("accepts 1 arg(s), received %d", len(args))
+		}
 		// Validate --version flag
 		if cmd.Flags().Changed("version") {
 			if v := cmd.Flag("version").Value.String(); v == "" {
@@ -84,6 +93,15 @@ ddev add-on get /path/to/tarball.tar.gz
 		}
 		_ = app.DockerEnv()
 
+		if fromLock, _ := cmd.Flags().GetBool("from-lock"); fromLock {
+			err = ddevapp.InstallAddonsFromLock(app, verbose)
+			if err != nil {
+				util.Failed("Unable to install the add-ons from %s: %v", ddevapp.AddonLockFile, err)
+			}
+			output.UserOut.Printf("Installed the add-ons of %s\nUse `ddev restart` to enable them", app.GetConfigPath(ddevapp.AddonLockFile))
+			return
+		}
+
 		sourceRepoArg := args[0]
 		extractedDir := ""
 		parts := strings.Split(sourceRepoArg, "/")
@@ -93,6 +111,7 @@ ddev add-on get /path/to/tarball.tar.gz
 		owner := ""
 		repo := ""
 		downloadedRelease := ""
+		checksum := ""
 		switch {
 		// If the provided sourceRepoArg is a directory, then we will use that as the source
 		case fileutil.IsDirectory(sourceRepoArg):
@@ -130,7 +149,7 @@ ddev add-on get /path/to/tarball.tar.gz
 				tarballURL = sourceRepoArg
 				argType = "tarball"
 			}
-			extractedDir, cleanup, err = archive.DownloadAndExtractTarball(tarballURL, true)
+			extractedDir, cleanup, checksum, err = archive.DownloadAndExtractTarballWithChecksum(tarballURL, true)
 			defer cleanup()
 			if err != nil {
 				util.Failed("Unable to download %v: %v", sourceRepoArg, err)
@@ -158,6 +177,31 @@ ddev add-on get /path/to/tarball.tar.gz
 			util.Failed("Unable to parse %v: %v", yamlFile, err)
 		}
 
+		repository := ""
+		switch argType {
+		case "github":
+			repository = fmt.Sprintf("%s/%s", owner, repo)
+		case "directory":
+			fallthrough
+		case "tarball":
+			repository = sourceRepoArg
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			plan, err := ddevapp.PlanAddonInstall(app, extractedDir, repository, downloadedRelease, skipDeps)
+			if err != nil {
+				util.Failed("Unable to plan the installation of %s: %v", sourceRepoArg, err)
+			}
+			output.UserOut.WithField("raw", plan).Print(renderAddonInstallPlan(plan, verbose))
+			return
+		}
+
+		// Check the actions before changing anything, including installing the dependencies
+		_, err = ddevapp.PlanAddonActions(app, extractedDir, s)
+		if err != nil {
+			util.Failed("Unable to install the '%s' add-on: %v", s.Name, err)
+		}
+
 		// Handle dependencies
 		if len(s.Dependencies) > 0 {
 			if !skipDeps {
@@ -252,6 +296,11 @@ ddev add-on get /path/to/tarball.tar.gz
 			util.Failed("Unable to chdir to %v: %v", app.GetConfigPath(""), err)
 		}
 
+		removals, err := ddevapp.RunAddonActions(app, extractedDir, s)
+		if err != nil {
+			util.Failed("%v", err)
+		}
+
 		if len(s.PostInstallActions) > 0 {
 			util.Success("\nExecuting post-install actions:")
 		}
@@ -275,19 +324,21 @@ ddev add-on get /path/to/tarball.tar.gz
 			}
 		}
 
-		repository := ""
-		switch argType {
-		case "github":
-			repository = fmt.Sprintf("%s/%s", owner, repo)
-		case "directory":
-			fallthrough
-		case "tarball":
-			repository = sourceRepoArg
-		}
-		manifest, err := createManifestFile(app, s.Name, repository, downloadedRelease, s)
+		manifest, err := createManifestFile(app, s.Name, repository, downloadedRelease, s, removals)
 		if err != nil {
 			util.Failed("Unable to create manifest file: %v", err)
 		}
+		err = ddevapp.RecordAddonInLock(app, ddevapp.AddonLockEntry{
+			Name:         s.Name,
+			Repository:   repository,
+			Version:      downloadedRelease,
+			TarballURL:   tarballURL,
+			Checksum:     checksum,
+			Dependencies: s.Dependencies,
+		})
+		if err != nil {
+			util.Failed("Unable to update %s: %v", ddevapp.AddonLockFile, err)
+		}
 
 		// Clean up temporary configuration files created for PHP actions
 		err = app.CleanupConfigurationFiles()
@@ -306,18 +357,108 @@ ddev add-on get /path/to/tarball.tar.gz
 	},
 }
 
+// renderAddonInstallPlan renders what installing an add-on would do, and what
+// installing its dependencies would do. Scripts are shown when verbose is true.
+func renderAddonInstallPlan(plan *ddevapp.AddonInstallPlan, verbose bool) string {
+	var out strings.Builder
+	id := plan.Repository
+	if plan.Version != "" {
+		id = fmt.Sprintf("%s:%s", id, plan.Version)
+	}
+	fmt.Fprintf(&out, "Installing the '%s' add-on from %s would:\n", plan.Name, id)
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(&out, "\nWarning: %s\n", w)
+	}
+
+	renderFiles := func(title string, files []ddevapp.AddonFilePlan) {
+		if len(files) == 0 {
+			return
+		}
+		fmt.Fprintf(&out, "\n%s:\n", title)
+		for _, f := range files {
+			fmt.Fprintf(&out, "  %-9s %s", f.Status, f.Path)
+			if f.Status == ddevapp.AddonPlanFileKeep {
+				out.WriteString(" (exists without #ddev-generated, NOT overwritten)")
+			}
+			out.WriteString("\n")
+		}
+	}
+	renderScripts := func(title string, scripts []ddevapp.AddonScriptPlan) {
+		if len(scripts) == 0 {
+			return
+		}
+		fmt.Fprintf(&out, "\n%s:\n", title)
+		for i, script := range scripts {
+			where := "bash on the host"
+			if script.Interpreter == "php" {
+				where = fmt.Sprintf("PHP in a %s container", script.Image)
+			}
+			desc := script.Description
+			if desc == "" {
+				desc = "(no #ddev-description)"
+			}
+			fmt.Fprintf(&out, "  %d. [%s] %s\n", i+1, where, desc)
+			if verbose {
+				for _, line := range strings.Split(strings.TrimRight(script.Script, "\n"), "\n") {
+					fmt.Fprintf(&out, "       %s\n", line)
+				}
+			}
+		}
+	}
+
+	renderFiles("Write project files", plan.ProjectFiles)
+	renderFiles(fmt.Sprintf("Write global files in %s", globalconfig.GetGlobalDdevDir()), plan.GlobalFiles)
+	renderScripts("Run pre-install actions", plan.PreInstallActions)
+	if len(plan.Actions) > 0 {
+		out.WriteString("\nRun actions:\n")
+		for _, a := range plan.Actions {
+			fmt.Fprintf(&out, "  - %s\n", a.Description)
+		}
+	}
+	renderScripts("Run post-install actions", plan.PostInstallActions)
+	renderScripts("Run removal actions when it is removed", plan.RemovalActions)
+	if len(plan.Images) > 0 {
+		out.WriteString("\nUse images:\n")
+		for _, image := range plan.Images {
+			fmt.Fprintf(&out, "  %s\n", image)
+		}
+	}
+	if len(plan.InstalledDeps) > 0 {
+		fmt.Fprintf(&out, "\nUse the already installed dependencies: %s\n", strings.Join(plan.InstalledDeps, ", "))
+	}
+	if len(plan.SkippedDeps) > 0 {
+		fmt.Fprintf(&out, "\nSkip the dependencies: %s\n", strings.Join(plan.SkippedDeps, ", "))
+	}
+	if len(plan.Dependencies) > 0 {
+		var deps []string
+		for _, dep := range plan.Dependencies {
+			deps = append(deps, dep.Repository)
+		}
+		fmt.Fprintf(&out, "\nInstall the dependencies first: %s\n", strings.Join(deps, ", "))
+	}
+	if !verbose && len(plan.PreInstallActions)+len(plan.PostInstallActions)+len(plan.RemovalActions) > 0 {
+		out.WriteString("\nBash and PHP actions can change other files too, use --verbose to see them.\n")
+	}
+	for _, dep := range plan.Dependencies {
+		out.WriteString("\n")
+		out.WriteString(renderAddonInstallPlan(&dep, verbose))
+	}
+	return out.String()
+}
+
 // createManifestFile creates a manifest file for the addon
-func createManifestFile(app *ddevapp.DdevApp, addonName string, repository string, downloadedRelease string, desc ddevapp.InstallDesc) (ddevapp.AddonManifest, error) {
+func createManifestFile(app *ddevapp.DdevApp, addonName string, repository string, downloadedRelease string, desc ddevapp.InstallDesc, generatedRemovalActions []ddevapp.AddonAction) (ddevapp.AddonManifest, error) {
 	// Create a manifest file
 	manifest := ddevapp.AddonManifest{
-		Name:           addonName,
-		Repository:     repository,
-		Version:        downloadedRelease,
-		Dependencies:   desc.Dependencies,
-		InstallDate:    time.Now().Format(time.RFC3339),
-		ProjectFiles:   desc.ProjectFiles,
-		GlobalFiles:    desc.GlobalFiles,
-		RemovalActions: desc.RemovalActions,
+		Name:                    addonName,
+		Repository:              repository,
+		Version:                 downloadedRelease,
+		Dependencies:            desc.Dependencies,
+		InstallDate:             time.Now().Format(time.RFC3339),
+		ProjectFiles:            desc.ProjectFiles,
+		GlobalFiles:             desc.GlobalFiles,
+		RemovalActions:          desc.RemovalActions,
+		GeneratedRemovalActions: generatedRemovalActions,
 	}
 	manifestFile := app.GetConfigPath(fmt.Sprintf("%s/%s/manifest.yaml", ddevapp.AddonMetadataDir, addonName))
 	if fileutil.FileExists(manifestFile) {
@@ -348,7 +489,10 @@ func init() {
 	AddonGetCmd.Flags().Bool("default-branch", false, "Install from the last commit in the default branch")
 	_ = AddonGetCmd.RegisterFlagCompletionFunc("default-branch", configCompletionFunc([]string{"true", "false"}))
 	AddonGetCmd.Flags().Int("pr", 0, "Install from a pull request number")
-	AddonGetCmd.MarkFlagsMutuallyExclusive("version", "default-branch", "pr")
+	AddonGetCmd.Flags().Bool("from-lock", false, "Install the add-ons recorded in .ddev/addons.lock.yaml at their recorded versions, verifying their checksums")
+	AddonGetCmd.Flags().Bool("dry-run", false, "Show the files, actions, images, and dependencies the add-on would install, without installing anything")
+	AddonGetCmd.MarkFlagsMutuallyExclusive("version", "default-branch", "pr", "from-lock")
+	AddonGetCmd.MarkFlagsMutuallyExclusive("dry-run", "from-lock")
 
 	AddonCmd.AddCommand(AddonGetCmd)
 }
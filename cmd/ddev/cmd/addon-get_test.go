@@ -699,3 +699,40 @@ func TestAddonGetWithDefaultBranchFlag(t *testing.T) {
 	// Should install from main branch (default_branch for ddev-redis)
 	require.Contains(t, out, "Installing ddev/ddev-redis:main")
 }
+
+// TestRenderAddonInstallPlan verifies that a dry run shows the files, actions
+// with their interpreter, images, and dependencies of an add-on.
+func TestRenderAddonInstallPlan(t *testing.T) {
+	plan := &ddevapp.AddonInstallPlan{
+		Name:       "test",
+		Repository: "owner/ddev-test",
+		Version:    "v1.0.0",
+		ProjectFiles: []ddevapp.AddonFilePlan{
+			{Path: ".ddev/docker-compose.test.yaml", Status: ddevapp.AddonPlanFileCreate},
+			{Path: ".ddev/test.conf", Status: ddevapp.AddonPlanFileKeep},
+		},
+		GlobalFiles:        []ddevapp.AddonFilePlan{{Path: "commands/host/test", Status: ddevapp.AddonPlanFileOverwrite}},
+		PreInstallActions:  []ddevapp.AddonScriptPlan{{Description: "Check requirements", Interpreter: "bash", Script: "true"}},
+		Actions:            []ddevapp.AddonActionPlan{{Description: "Set TEST_HOST in .ddev/.env"}},
+		PostInstallActions: []ddevapp.AddonScriptPlan{{Description: "Configure settings", Interpreter: "php", Image: "ddev/ddev-webserver", Script: "<?php\necho 'configured';"}},
+		Images:             []string{"busybox:stable"},
+		Dependencies:       []ddevapp.AddonInstallPlan{{Name: "dep", Repository: "owner/ddev-dep", Images: []string{"redis:7"}}},
+	}
+	out := renderAddonInstallPlan(plan, false)
+
+	require.Contains(t, out, "Installing the 'test' add-on from owner/ddev-test:v1.0.0 would:")
+	require.Contains(t, out, "  create    .ddev/docker-compose.test.yaml\n")
+	require.Contains(t, out, "  keep      .ddev/test.conf (exists without #ddev-generated, NOT overwritten)\n")
+	require.Contains(t, out, "  overwrite commands/host/test\n")
+	require.Contains(t, out, "  1. [bash on the host] Check requirements\n")
+	require.Contains(t, out, "  1. [PHP in a ddev/ddev-webserver container] Configure settings\n")
+	require.Contains(t, out, "  - Set TEST_HOST in .ddev/.env\n")
+	require.Contains(t, out, "  busybox:stable\n")
+	require.Contains(t, out, "Install the dependencies first: owner/ddev-dep\n")
+	require.Contains(t, out, "Installing the 'dep' add-on from owner/ddev-dep would:")
+	require.Contains(t, out, "  redis:7\n")
+	require.NotContains(t, out, "echo 'configured';")
+
+	out = renderAddonInstallPlan(plan, true)
+	require.Contains(t, out, "       echo 'configured';\n")
+}
@@ -27,11 +27,15 @@ var AddonListCmd = &cobra.Command{
 	Example: `ddev add-on list
 ddev add-on list --installed
 ddev add-on list --installed --project my-project
+ddev add-on list --installed --outdated
 `,
 	Run: func(cmd *cobra.Command, _ []string) {
 		if cmd.Flags().Changed("project") && !cmd.Flags().Changed("installed") {
 			util.Failed("--project flag can only be used with --installed flag")
 		}
+		if cmd.Flags().Changed("outdated") && !cmd.Flags().Changed("installed") {
+			util.Failed("--outdated flag can only be used with --installed flag")
+		}
 
 		// List installed add-ons
 		if cmd.Flags().Changed("installed") {
@@ -40,6 +44,10 @@ ddev add-on list --installed --project my-project
 				util.Failed("Unable to get project %v: %v", cmd.Flag("project").Value.String(), err)
 			}
 
+			if cmd.Flags().Changed("outdated") {
+				ListOutdatedAddons(app)
+				return
+			}
 			ListInstalledAddons(app)
 			return
 		}
@@ -109,6 +117,44 @@ func ListInstalledAddons(app *ddevapp.DdevApp) {
 	output.UserOut.WithField("raw", manifests).Println(out.String())
 }
 
+// ListOutdatedAddons shows the installed add-ons that have a newer release
+func ListOutdatedAddons(app *ddevapp.DdevApp) {
+	outdated := []ddevapp.AddonUpdate{}
+	for _, manifest := range ddevapp.GetInstalledAddons(app) {
+		if !ddevapp.IsGithubRef(manifest.Repository) {
+			util.Debug("Not checking %s for updates, it was not installed from a GitHub repository", manifest.Name)
+			continue
+		}
+		update, err := ddevapp.GetAddonUpdate(manifest, "")
+		if err != nil {
+			util.Warning("Unable to check %s for updates: %v", manifest.Name, err)
+			continue
+		}
+		if update.IsOutdated() {
+			outdated = append(outdated, update)
+		}
+	}
+	if len(outdated) == 0 {
+		output.UserOut.WithField("raw", outdated).Println("All installed add-ons are up to date.")
+		return
+	}
+	output.UserOut.WithField("raw", outdated).Println(renderOutdatedAddonList(outdated))
+}
+
+// renderOutdatedAddonList renders the installed add-ons that have a newer release
+func renderOutdatedAddonList(updates []ddevapp.AddonUpdate) string {
+	var out bytes.Buffer
+	t := table.NewWriter()
+	t.SetOutputMirror(&out)
+	styles.SetGlobalTableStyle(t, false)
+	t.AppendHeader(table.Row{"Add-on", "Installed", "Latest", "Repository"})
+	for _, u := range updates {
+		t.AppendRow(table.Row{u.Name, u.InstalledVersion, u.LatestVersion, output.Hyperlink("https://github.com/"+u.Repository, u.Repository)})
+	}
+	t.Render()
+	return out.String() + "Use `ddev add-on update <add-on>` or `ddev add-on update --all` to update them."
+}
+
 // renderRepositoryList renders the found list of addons from the registry
 func renderRepositoryList(addons []types.Addon, wrapTable bool) string {
 	var out bytes.Buffer
@@ -167,6 +213,7 @@ func init() {
 	AddonListCmd.Flags().Bool("all", false, `List unofficial DDEV add-ons for in addition to the official ones`)
 	_ = AddonListCmd.Flags().MarkDeprecated("all", "this flag no longer has any effect. All add-ons are shown by default.")
 	AddonListCmd.Flags().Bool("installed", false, `Show installed DDEV add-ons`)
+	AddonListCmd.Flags().Bool("outdated", false, "Show only the installed DDEV add-ons that have a newer release. Can only be used with `--installed`")
 	AddonListCmd.Flags().String("project", "", "Name of the project to list the add-ons for. Can only be used with `--installed`")
 	_ = AddonListCmd.RegisterFlagCompletionFunc("project", ddevapp.GetProjectNamesFunc("all", 0))
 	AddonListCmd.Flags().BoolP("wrap-table", "W", false, "Display table with wrapped text instead of truncating.")
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/ddev/ddev/pkg/config/remoteconfig/types"
+	"github.com/ddev/ddev/pkg/ddevapp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -149,3 +150,16 @@ func TestRenderSearchResultsWrapTable(t *testing.T) {
 	require.Contains(t, out, longTitle, "full title should appear when wrap-table is set")
 	require.NotContains(t, out, "…")
 }
+
+// TestRenderOutdatedAddonList verifies that the installed and latest versions
+// of outdated add-ons are rendered with a hint to update them.
+func TestRenderOutdatedAddonList(t *testing.T) {
+	out := renderOutdatedAddonList([]ddevapp.AddonUpdate{
+		{Name: "redis", Repository: "ddev/ddev-redis", InstalledVersion: "v1.0.0", LatestVersion: "v2.1.0"},
+	})
+
+	require.Contains(t, out, "redis")
+	require.Contains(t, out, "v1.0.0")
+	require.Contains(t, out, "v2.1.0")
+	require.Contains(t, out, "ddev add-on update --all")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// AddonMirrorCmd is the "ddev add-on mirror" command
+var AddonMirrorCmd = &cobra.Command{
+	Use:   "mirror <owner/repo[:version]>...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Download add-ons and their dependencies into a directory usable as an offline add-on registry",
+	Long: `Download the tarballs of add-ons and of their dependencies into a directory,
+with an addons.json index, so that the directory can be used as an add-on
+registry in the addon_registries of the global config, for instance on
+machines without internet access or to pin the add-ons a team can install.
+Running it again on the same directory adds add-ons and versions to it.`,
+	Example: `ddev add-on mirror ddev/ddev-redis ddev/ddev-solr --dir ~/ddev-addons
+ddev add-on mirror ddev/ddev-redis:v2.1.0 --dir /mnt/shared/ddev-addons
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		dir, err := util.ExpandHomedir(dir)
+		if err != nil {
+			util.Failed("Unable to expand %s: %v", dir, err)
+		}
+		dir, err = filepath.Abs(dir)
+		if err != nil {
+			util.Failed("Unable to get the absolute path of %s: %v", dir, err)
+		}
+
+		mirror, err := ddevapp.MirrorAddons(dir, args)
+		if err != nil {
+			util.Failed("Unable to mirror add-ons: %v", err)
+		}
+		util.Success("%d add-ons are mirrored in %s\nTo install add-ons from it, add it to the addon_registries of ~/.ddev/global_config.yaml:\naddon_registries:\n  - name: mirror\n    location: %s\n    priority: 10", mirror.TotalAddonsCount, dir, dir)
+	},
+}
+
+func init() {
+	AddonMirrorCmd.Flags().String("dir", "", "Directory to download the add-ons into")
+	_ = AddonMirrorCmd.MarkFlagRequired("dir")
+	_ = AddonMirrorCmd.MarkFlagDirname("dir")
+	AddonCmd.AddCommand(AddonMirrorCmd)
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ddev/ddev/pkg/archive"
+	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// AddonUpdateCmd is the "ddev add-on update" command
+var AddonUpdateCmd = &cobra.Command{
+	Use:   "update [addon]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Update installed DDEV add-ons to their latest release",
+	Long: `Update installed DDEV add-ons to their latest release, or to a particular version.
+The changes to the project files are shown before they are applied.
+Files where the #ddev-generated line was removed are not overwritten: your changes
+are merged with the update, and if they conflict the merge result is written
+next to the file with a '.ddev-merge' suffix for you to resolve.`,
+	Example: `ddev add-on update redis
+ddev add-on update ddev/ddev-redis --version v2.2.0
+ddev add-on update --all
+ddev add-on update --all --yes
+ddev add-on list --installed --outdated
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		yes, _ := cmd.Flags().GetBool("yes")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		requestedVersion, _ := cmd.Flags().GetString("version")
+		if all == (len(args) == 1) {
+			util.Failed("Specify an add-on to update or use --all")
+		}
+
+		app, err := ddevapp.GetActiveApp(cmd.Flag("project").Value.String())
+		if err != nil {
+			util.Failed("Unable to get project %v: %v", cmd.Flag("project").Value.String(), err)
+		}
+
+		var manifests []ddevapp.AddonManifest
+		if all {
+			for _, m := range ddevapp.GetInstalledAddons(app) {
+				if !ddevapp.IsGithubRef(m.Repository) {
+					util.Warning("Skipping '%s', which was not installed from a GitHub repository", m.Name)
+					continue
+				}
+				manifests = append(manifests, m)
+			}
+		} else {
+			allManifests, err := ddevapp.GatherAllManifests(app)
+			if err != nil {
+				util.Failed("Unable to gather all manifests: %v", err)
+			}
+			m, ok := allManifests[args[0]]
+			if !ok {
+				util.Failed("The add-on '%s' is not installed.\nUse `ddev add-on list --installed` to see installed add-ons.", args[0])
+			}
+			manifests = append(manifests, m)
+		}
+
+		origDir, _ := os.Getwd()
+		defer func() {
+			err = os.Chdir(origDir)
+			if err != nil {
+				util.Failed("Unable to chdir to %v: %v", origDir, err)
+			}
+		}()
+		err = os.Chdir(app.AppRoot)
+		if err != nil {
+			util.Failed("Unable to change directory to project root %s: %v", app.AppRoot, err)
+		}
+		_ = app.DockerEnv()
+
+		var updated []ddevapp.AddonUpdate
+		for _, m := range manifests {
+			update, err := updateAddon(app, m, requestedVersion, yes, verbose)
+			if err != nil {
+				util.Failed("Unable to update %s: %v", m.Name, err)
+			}
+			if update != nil {
+				updated = append(updated, *update)
+			}
+		}
+
+		if len(updated) == 0 {
+			output.UserOut.WithField("raw", updated).Print("No add-ons were updated.")
+			return
+		}
+		var names []string
+		for _, u := range updated {
+			names = append(names, fmt.Sprintf("%s:%s", u.Name, u.LatestVersion))
+		}
+		output.UserOut.WithField("raw", updated).Printf("Updated %s\nUse `ddev restart` to apply the changes", strings.Join(names, ", "))
+	},
+}
+
+// updateAddon shows the changes of the update of an add-on and applies them
+// once confirmed. It returns nil when the add-on was not updated.
+func updateAddon(app *ddevapp.DdevApp, manifest ddevapp.AddonManifest, requestedVersion string, yes bool, verbose bool) (*ddevapp.AddonUpdate, error) {
+	update, err := ddevapp.GetAddonUpdate(manifest, requestedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if requestedVersion == "" && !update.IsOutdated() {
+		util.Success("%s is up to date (%s)", manifest.Name, manifest.Version)
+		return nil, nil
+	}
+
+	newDir, cleanup, checksum, err := archive.DownloadAndExtractTarballWithChecksum(update.TarballURL, true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download %v: %v", update.TarballURL, err)
+	}
+	defer cleanup()
+	update.Checksum = checksum
+
+	changes, err := ddevapp.PlanAddonUpdate(app, manifest, newDir, "")
+	if err != nil {
+		return nil, err
+	}
+	// Merging the files the user took ownership of needs the installed version
+	if slices.ContainsFunc(changes, func(c ddevapp.AddonFileChange) bool {
+		return c.Status == ddevapp.AddonFilePreserved && c.Diff != ""
+	}) {
+		baseDir, baseCleanup, err := ddevapp.DownloadAddonBase(manifest)
+		defer baseCleanup()
+		if err != nil {
+			util.Warning("Unable to download %s %s to merge your changes, the files without #ddev-generated will be preserved: %v", manifest.Name, manifest.Version, err)
+		} else if changes, err = ddevapp.PlanAddonUpdate(app, manifest, newDir, baseDir); err != nil {
+			return nil, err
+		}
+	}
+
+	output.UserOut.Printf("Updating %s from %s to %s:\n%s", manifest.Name, manifest.Version, update.LatestVersion, renderAddonFileChanges(changes))
+	if !yes && !util.Confirm(fmt.Sprintf("Apply the update of %s?", manifest.Name)) {
+		util.Warning("Skipped the update of %s", manifest.Name)
+		return nil, nil
+	}
+
+	err = ddevapp.UpdateAddon(app, manifest, update, newDir, changes, verbose)
+	if err != nil {
+		return nil, err
+	}
+	return &update, nil
+}
+
+// renderAddonFileChanges describes the changes of the files in an add-on update with their diffs
+func renderAddonFileChanges(changes []ddevapp.AddonFileChange) string {
+	var out strings.Builder
+	for _, c := range changes {
+		switch c.Status {
+		case ddevapp.AddonFileUnchanged:
+			continue
+		case ddevapp.AddonFilePreserved:
+			fmt.Fprintf(&out, "%s: preserved, #ddev-generated was removed\n", c.File)
+		case ddevapp.AddonFileMerged:
+			fmt.Fprintf(&out, "%s: #ddev-generated was removed, the update will be merged with your changes\n", c.File)
+		case ddevapp.AddonFileConflict:
+			fmt.Fprintf(&out, "%s: #ddev-generated was removed and your changes conflict with the update, the merge will be written to %s%s\n", c.File, c.File, ddevapp.AddonMergeConflictSuffix)
+		default:
+			fmt.Fprintf(&out, "%s: %s\n", c.File, c.Status)
+		}
+		out.WriteString(c.Diff)
+	}
+	if out.Len() == 0 {
+		return "No project files change.\n"
+	}
+	return out.String()
+}
+
+func init() {
+	AddonUpdateCmd.Flags().BoolP("all", "a", false, "Update all the installed add-ons")
+	AddonUpdateCmd.Flags().String("version", "", "Specify a particular version of the add-on to update to")
+	AddonUpdateCmd.Flags().BoolP("yes", "y", false, "Apply the updates without asking for confirmation")
+	AddonUpdateCmd.Flags().BoolP("verbose", "v", false, "Extended/verbose output")
+	AddonUpdateCmd.Flags().String("project", "", "Name of the project to update the add-ons of")
+	_ = AddonUpdateCmd.RegisterFlagCompletionFunc("project", ddevapp.GetProjectNamesFunc("all", 0))
+	AddonUpdateCmd.MarkFlagsMutuallyExclusive("all", "version")
+
+	AddonCmd.AddCommand(AddonUpdateCmd)
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Valid types of positional args
+const (
+	AtBool   = "bool"
+	AtEnum   = "enum"
+	AtInt    = "int"
+	AtString = "string"
+)
+
+// validArgName is the pattern of arg names, which are also used in
+// DDEV_ARG_<NAME> environment variables
+var validArgName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Arg is a typed positional arg of a custom command, see CommandArgs.
+type Arg struct {
+	Name     string   // name shown in the usage and used in DDEV_ARG_<NAME>
+	Usage    string   // help message
+	Type     string   // bool, enum, int or string (default)
+	Required bool     // the command fails if the arg is missing
+	DefValue string   // default value of an optional arg
+	Values   []string // valid values of an enum arg, also used for completion
+}
+
+// CommandArgs is the list of typed positional args of a custom command,
+// defined with the `## Args:` directive.
+type CommandArgs struct {
+	CommandName string
+	Script      string
+	Definition  []Arg
+}
+
+// Init initializes the args structure.
+func (a *CommandArgs) Init(commandName, script string) {
+	a.CommandName = commandName
+	a.Script = script
+}
+
+// LoadFromJSON loads and validates the args definition from JSON.
+func (a *CommandArgs) LoadFromJSON(data string) error {
+	if data == "" {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &a.Definition); err != nil {
+		return err
+	}
+
+	return a.validateArgs()
+}
+
+// validateArgs checks the args definition, required args must come first.
+func (a *CommandArgs) validateArgs() error {
+	var errors []string
+	names := map[string]bool{}
+	optional := false
+
+	for i := range a.Definition {
+		arg := &a.Definition[i]
+		if arg.Type == "" {
+			arg.Type = AtString
+		}
+
+		switch {
+		case !validArgName.MatchString(arg.Name):
+			errors = append(errors, fmt.Sprintf("arg %d: invalid name '%s'", i+1, arg.Name))
+		case names[arg.Name]:
+			errors = append(errors, fmt.Sprintf("arg %d: duplicate name '%s'", i+1, arg.Name))
+		}
+		names[arg.Name] = true
+
+		if !slices.Contains([]string{AtBool, AtEnum, AtInt, AtString}, arg.Type) {
+			errors = append(errors, fmt.Sprintf("arg '%s': invalid type '%s'", arg.Name, arg.Type))
+			continue
+		}
+		if arg.Type == AtEnum && len(arg.Values) == 0 {
+			errors = append(errors, fmt.Sprintf("arg '%s': no values defined for type '%s'", arg.Name, AtEnum))
+		}
+		if arg.Type != AtEnum && len(arg.Values) > 0 {
+			errors = append(errors, fmt.Sprintf("arg '%s': values are only allowed for type '%s'", arg.Name, AtEnum))
+		}
+
+		if arg.Required {
+			if arg.DefValue != "" {
+				errors = append(errors, fmt.Sprintf("arg '%s': a required arg can't have a default value", arg.Name))
+			}
+			if optional {
+				errors = append(errors, fmt.Sprintf("arg '%s': required args must come before optional args", arg.Name))
+			}
+		} else {
+			optional = true
+			if arg.DefValue != "" {
+				if _, err := arg.parse(arg.DefValue); err != nil {
+					errors = append(errors, fmt.Sprintf("arg '%s': invalid default value: %v", arg.Name, err))
+				}
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("the following problems were found in the args definition of the command '%s' in '%s':\n%s", a.CommandName, a.Script, " * "+strings.Join(errors, "\n * "))
+	}
+
+	return nil
+}
+
+// parse checks a value has the type of the arg and returns it normalized.
+func (arg *Arg) parse(value string) (string, error) {
+	switch arg.Type {
+	case AtBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("'%s' is not a boolean", value)
+		}
+		return strconv.FormatBool(b), nil
+	case AtInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("'%s' is not an integer", value)
+		}
+	case AtEnum:
+		if !slices.Contains(arg.Values, value) {
+			return "", fmt.Errorf("'%s' must be one of %s", value, strings.Join(arg.Values, ", "))
+		}
+	}
+	return value, nil
+}
+
+// Usage returns the usage of the args, as in "<site> [<count>]".
+func (a *CommandArgs) Usage() string {
+	var usage []string
+	for _, arg := range a.Definition {
+		if arg.Required {
+			usage = append(usage, "<"+arg.Name+">")
+		} else {
+			usage = append(usage, "[<"+arg.Name+">]")
+		}
+	}
+	return strings.Join(usage, " ")
+}
+
+// Validate checks the positional args of the command line against the definition.
+func (a *CommandArgs) Validate(_ *cobra.Command, args []string) error {
+	if len(args) > len(a.Definition) {
+		return fmt.Errorf("accepts at most %d arg(s), received %d", len(a.Definition), len(args))
+	}
+	for i, arg := range a.Definition {
+		if i >= len(args) {
+			if arg.Required {
+				return fmt.Errorf("missing required arg '%s'", arg.Name)
+			}
+			continue
+		}
+		if _, err := arg.parse(args[i]); err != nil {
+			return fmt.Errorf("invalid arg '%s': %v", arg.Name, err)
+		}
+	}
+	return nil
+}
+
+// Complete completes the positional arg at the current position,
+// with the values of enums and bools, and files otherwise.
+func (a *CommandArgs) Complete(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= len(a.Definition) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	switch arg := a.Definition[len(args)]; arg.Type {
+	case AtEnum:
+		return arg.Values, cobra.ShellCompDirectiveNoFileComp
+	case AtBool:
+		return []string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// AssignToCommand sets the validation and completion of the args on the command.
+func (a *CommandArgs) AssignToCommand(command *cobra.Command) {
+	if len(a.Definition) == 0 {
+		return
+	}
+	command.Args = a.Validate
+	if len(command.ValidArgs) == 0 {
+		command.ValidArgsFunction = a.Complete
+	}
+}
+
+// Environment returns the DDEV_ARG_<NAME> environment variables with the
+// values of the positional args, or their defaults, for the script.
+func (a *CommandArgs) Environment(args []string) []string {
+	var env []string
+	values := a.Values(args)
+	for _, arg := range a.Definition {
+		env = append(env, envVarName("DDEV_ARG_", arg.Name)+"="+values[arg.Name])
+	}
+	return env
+}
+
+// Values returns the values of the positional args, or their defaults, by name.
+func (a *CommandArgs) Values(args []string) map[string]string {
+	values := map[string]string{}
+	for i, arg := range a.Definition {
+		value := arg.DefValue
+		if i < len(args) {
+			value = args[i]
+		}
+		if value != "" {
+			value, _ = arg.parse(value)
+		}
+		values[arg.Name] = value
+	}
+	return values
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	asrt "github.com/stretchr/testify/assert"
+)
+
+// getArgsSubject returns a new, initialized CommandArgs struct.
+func getArgsSubject() CommandArgs {
+	var subject CommandArgs
+	subject.Init(commandName, script)
+	return subject
+}
+
+// TestUnitCmdArgsLoadFromJSON checks LoadFromJSON works correctly and handles
+// user errors.
+func TestUnitCmdArgsLoadFromJSON(t *testing.T) {
+	assert := asrt.New(t)
+	subject := getArgsSubject()
+
+	// No data
+	assert.NoError(subject.LoadFromJSON(``))
+
+	// Invalid JSON
+	assert.Error(subject.LoadFromJSON(`this is no valid JSON`))
+
+	// Minimal
+	assert.NoError(subject.LoadFromJSON(`[{"Name":"site"}]`))
+	assert.Equal(AtString, subject.Definition[0].Type)
+	assert.False(subject.Definition[0].Required)
+
+	// Invalid definitions
+	subject = getArgsSubject()
+	assert.EqualError(subject.LoadFromJSON(`[{"Name":"count","Type":"int"},{"Name":"site","Required":true},{"Name":"site"},{"Name":"1st"},{"Name":"env","Type":"enum"},{"Name":"size","Type":"float"},{"Name":"n","Type":"int","DefValue":"many"}]`),
+		"the following problems were found in the args definition of the command 'command' in 'script':\n * arg 'site': required args must come before optional args\n * arg 3: duplicate name 'site'\n * arg 4: invalid name '1st'\n * arg 'env': no values defined for type 'enum'\n * arg 'size': invalid type 'float'\n * arg 'n': invalid default value: 'many' is not an integer")
+}
+
+// TestUnitCmdArgsValidate checks the validation, completion and environment
+// of the positional args of a command.
+func TestUnitCmdArgsValidate(t *testing.T) {
+	assert := asrt.New(t)
+	subject := getArgsSubject()
+	assert.NoError(subject.LoadFromJSON(`[{"Name":"env","Type":"enum","Values":["dev","prod"],"Required":true},{"Name":"count","Type":"int","DefValue":"1"},{"Name":"force","Type":"bool"}]`))
+	assert.Equal("<env> [<count>] [<force>]", subject.Usage())
+
+	c := &cobra.Command{}
+	subject.AssignToCommand(c)
+	assert.NotNil(c.Args)
+	assert.NotNil(c.ValidArgsFunction)
+
+	assert.EqualError(c.Args(c, []string{}), "missing required arg 'env'")
+	assert.EqualError(c.Args(c, []string{"staging"}), "invalid arg 'env': 'staging' must be one of dev, prod")
+	assert.EqualError(c.Args(c, []string{"dev", "two"}), "invalid arg 'count': 'two' is not an integer")
+	assert.EqualError(c.Args(c, []string{"dev", "2", "yes"}), "invalid arg 'force': 'yes' is not a boolean")
+	assert.EqualError(c.Args(c, []string{"dev", "2", "true", "extra"}), "accepts at most 3 arg(s), received 4")
+	assert.NoError(c.Args(c, []string{"prod", "2", "1"}))
+
+	values, directive := c.ValidArgsFunction(c, []string{}, "")
+	assert.Equal([]string{"dev", "prod"}, values)
+	assert.Equal(cobra.ShellCompDirectiveNoFileComp, directive)
+	_, directive = c.ValidArgsFunction(c, []string{"dev"}, "")
+	assert.Equal(cobra.ShellCompDirectiveDefault, directive)
+
+	assert.Equal([]string{"DDEV_ARG_ENV=prod", "DDEV_ARG_COUNT=1", "DDEV_ARG_FORCE="}, subject.Environment([]string{"prod"}))
+	assert.Equal([]string{"DDEV_ARG_ENV=dev", "DDEV_ARG_COUNT=3", "DDEV_ARG_FORCE=true"}, subject.Environment([]string{"dev", "3", "1"}))
+}
@@ -24,6 +24,7 @@ import (
 const (
 	CustomCommand        = "customCommand"
 	BundledCustomCommand = "customCommand:bundled"
+	CustomCommandGroup   = "customCommand:group"
 )
 
 func IsUserDefinedCustomCommand(cmd *cobra.Command) bool {
@@ -37,13 +38,11 @@ func IsUserDefinedCustomCommand(cmd *cobra.Command) bool {
 // ~/.ddev/commands/<servicename> etc. and
 // .ddev/commands/<servicename> and .ddev/commands/host
 // and if it finds them adds them to Cobra's commands.
+// Subdirectories like .ddev/commands/web/<group> are command groups.
 func addCustomCommands(rootCmd *cobra.Command) error {
 	// Custom commands are shell scripts - so we can't use them on Windows without bash.
-	if nodeps.IsWindows() {
-		if windowsBashPath := util.FindBashPath(); windowsBashPath == "" {
-			return nil
-		}
-	}
+	// YAML commands don't need bash, so they are still added.
+	scriptsSupported := !nodeps.IsWindows() || util.FindBashPath() != ""
 
 	// Keep a map so we don't add multiple commands with the same name.
 	commandsAdded := map[string]int{}
@@ -52,11 +51,7 @@ func addCustomCommands(rootCmd *cobra.Command) error {
 	// If we're not running ddev inside a project directory, we should still add any host commands that can run without one.
 	if err != nil {
 		globalHostCommandPath := filepath.Join(globalconfig.GetGlobalDdevDir(), "commands", "host")
-		commandFiles, err := fileutil.ListFilesInDir(globalHostCommandPath)
-		if err != nil {
-			return err
-		}
-		err = addCustomCommandsFromDir(rootCmd, nil, globalHostCommandPath, commandFiles, true, commandsAdded)
+		err = addCustomCommandsFromServiceDir(rootCmd, nil, globalHostCommandPath, true, scriptsSupported, commandsAdded)
 		if err != nil {
 			return err
 		}
@@ -93,11 +88,7 @@ func addCustomCommands(rootCmd *cobra.Command) error {
 			if strings.HasPrefix(filepath.Base(serviceDirOnHost), ".") {
 				continue
 			}
-			commandFiles, err := fileutil.ListFilesInDir(serviceDirOnHost)
-			if err != nil {
-				return err
-			}
-			err = addCustomCommandsFromDir(rootCmd, app, serviceDirOnHost, commandFiles, commandSet == globalCommandPath, commandsAdded)
+			err = addCustomCommandsFromServiceDir(rootCmd, app, serviceDirOnHost, commandSet == globalCommandPath, scriptsSupported, commandsAdded)
 			if err != nil {
 				return err
 			}
@@ -107,20 +98,108 @@ func addCustomCommands(rootCmd *cobra.Command) error {
 	return nil
 }
 
-// addCustomCommandsFromDir adds the custom commands from inside a given directory
-func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serviceDirOnHost string, commandFiles []string, isGlobalSet bool, commandsAdded map[string]int) error {
+// listCustomCommandFiles lists the files of a commands directory,
+// only the YAML commands if scripts aren't supported
+func listCustomCommandFiles(dir string, scriptsSupported bool) ([]string, error) {
+	files, err := fileutil.ListFilesInDir(dir)
+	if err != nil || scriptsSupported {
+		return files, err
+	}
+	return slices.DeleteFunc(files, func(f string) bool { return !isYAMLCommand(f) }), nil
+}
+
+// addCustomCommandsFromServiceDir adds the custom commands of a service directory,
+// and the commands of its subdirectories as command groups, so that
+// .ddev/commands/web/qa/lint is `ddev qa lint`
+func addCustomCommandsFromServiceDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serviceDirOnHost string, isGlobalSet bool, scriptsSupported bool, commandsAdded map[string]int) error {
+	if !fileutil.IsDirectory(serviceDirOnHost) {
+		return nil
+	}
+	commandFiles, err := listCustomCommandFiles(serviceDirOnHost, scriptsSupported)
+	if err != nil {
+		return err
+	}
+	if err = addCustomCommandsFromDir(rootCmd, app, serviceDirOnHost, "", commandFiles, isGlobalSet, commandsAdded); err != nil {
+		return err
+	}
+
+	for _, group := range commandFiles {
+		groupDirOnHost := filepath.Join(serviceDirOnHost, group)
+		// The autocomplete directory has the completion scripts of the commands
+		if !fileutil.IsDirectory(groupDirOnHost) || strings.HasPrefix(group, ".") || group == "autocomplete" {
+			continue
+		}
+		groupFiles, err := listCustomCommandFiles(groupDirOnHost, scriptsSupported)
+		if err != nil {
+			return err
+		}
+		groupCmd := addCustomCommandGroup(rootCmd, group, groupDirOnHost)
+		if groupCmd == nil {
+			continue
+		}
+		if err = addCustomCommandsFromDir(groupCmd, app, serviceDirOnHost, group, groupFiles, isGlobalSet, commandsAdded); err != nil {
+			return err
+		}
+		// Don't keep groups without any usable command
+		if !groupCmd.HasSubCommands() {
+			rootCmd.RemoveCommand(groupCmd)
+		}
+	}
+	return nil
+}
+
+// addCustomCommandGroup returns the command of a custom command group, adding it
+// to rootCmd unless another service directory already did. It returns nil if
+// the group conflicts with a built-in or custom command of the same name.
+func addCustomCommandGroup(rootCmd *cobra.Command, group string, groupDirOnHost string) *cobra.Command {
+	if foundCmd, _, err := rootCmd.Find([]string{group}); err == nil && foundCmd != rootCmd {
+		if _, ok := foundCmd.Annotations[CustomCommandGroup]; ok {
+			return foundCmd
+		}
+		kind := "built-in"
+		if _, ok := foundCmd.Annotations[CustomCommand]; ok {
+			kind = "custom"
+		}
+		util.Warning("Command group '%s' conflicts with the %s command '%s', rename the directory to use its commands, skipping %s", group, kind, foundCmd.Name(), groupDirOnHost)
+		return nil
+	}
+
+	groupCmd := &cobra.Command{
+		Use:   group,
+		Short: fmt.Sprintf("Custom commands of the %s group", group),
+		Annotations: map[string]string{
+			CustomCommand:      "true",
+			CustomCommandGroup: "true",
+		},
+	}
+	rootCmd.AddCommand(groupCmd)
+	return groupCmd
+}
+
+// addCustomCommandsFromDir adds the custom commands from inside a given directory,
+// the service directory itself or the directory of a command group in it,
+// as subcommands of parentCmd
+func addCustomCommandsFromDir(parentCmd *cobra.Command, app *ddevapp.DdevApp, serviceDirOnHost string, group string, commandFiles []string, isGlobalSet bool, commandsAdded map[string]int) error {
 	service := filepath.Base(serviceDirOnHost)
+	commandDirOnHost := filepath.Join(serviceDirOnHost, group)
 	var err error
 
 	for _, commandName := range commandFiles {
-		onHostFullPath := filepath.Join(serviceDirOnHost, commandName)
+		onHostFullPath := filepath.Join(commandDirOnHost, commandName)
+		// The name of the command on the command line, as in "qa lint"
+		fullCommandName := strings.TrimSpace(group + " " + commandName)
 
 		if strings.HasSuffix(commandName, ".example") || strings.HasPrefix(commandName, "README") || strings.HasPrefix(commandName, ".") || fileutil.IsDirectory(onHostFullPath) {
 			continue
 		}
 
+		if isYAMLCommand(commandName) {
+			addYAMLCommand(parentCmd, app, serviceDirOnHost, group, commandName, isGlobalSet, commandsAdded)
+			continue
+		}
+
 		// If command has already been added, we won't work with it again.
-		if _, ok := commandsAdded[commandName]; ok {
+		if _, ok := commandsAdded[fullCommandName]; ok {
 			continue
 		}
 
@@ -139,7 +218,7 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 		// Skip host commands that need a project if we aren't in a project directory.
 		if service == "host" && app == nil {
 			if val, ok := directives["CanRunGlobally"]; !ok || val != "true" {
-				if isCustomCommandInArgs(commandName) {
+				if isCustomCommandInArgs(fullCommandName) {
 					util.Warning("Command '%s' cannot be used outside the project directory, skipping %s", commandName, onHostFullPath)
 				}
 				continue
@@ -151,12 +230,25 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 			description = val
 		}
 
+		// Init and import typed positional args
+		var commandArgs CommandArgs
+		commandArgs.Init(commandName, onHostFullPath)
+		if val, ok := directives["Args"]; ok {
+			if err = commandArgs.LoadFromJSON(val); err != nil {
+				util.Warning("Error '%s', command '%s' contains an invalid args definition '%s', skipping %s", err, commandName, val, onHostFullPath)
+				continue
+			}
+		}
+
 		usage = commandName + " [flags] [args]"
+		if len(commandArgs.Definition) > 0 {
+			usage = commandName + " [flags] " + commandArgs.Usage()
+		}
 		if val, ok := directives["Usage"]; ok {
 			usage = val
 		}
 		// Validate usage is not already in use
-		if foundCmd, _, err := rootCmd.Find(strings.Split(usage, " ")); err == nil && foundCmd != nil {
+		if foundCmd, _, err := parentCmd.Find(strings.Split(usage, " ")); err == nil && foundCmd != nil && foundCmd != parentCmd {
 			util.Warning("Command '%s' cannot have usage '%s' because it is already in use by command '%s', skipping %s", commandName, usage, foundCmd.Name(), onHostFullPath)
 			continue
 		}
@@ -169,7 +261,7 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 		if val, ok := directives["Aliases"]; ok {
 			for alias := range strings.SplitSeq(val, ",") {
 				alias = strings.TrimSpace(alias)
-				if foundCmd, _, err := rootCmd.Find([]string{alias}); err != nil {
+				if foundCmd, _, err := parentCmd.Find([]string{alias}); err != nil || foundCmd == parentCmd {
 					aliases = append(aliases, alias)
 				} else {
 					util.Warning("Command '%s' cannot have alias '%s' that is already in use by command '%s', skipping alias for %s", commandName, alias, foundCmd.Name(), onHostFullPath)
@@ -188,7 +280,7 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 		var flags Flags
 		flags.Init(commandName, onHostFullPath)
 
-		disableFlags := true
+		disableFlags := len(commandArgs.Definition) == 0
 		if val, ok := directives["Flags"]; ok {
 			disableFlags = false
 			if err = flags.LoadFromJSON(val); err != nil {
@@ -219,7 +311,7 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 
 		// If ProjectTypes is specified and we aren't of that type, skip
 		if projectTypes != "" && (app == nil || !strings.Contains(projectTypes, app.Type)) {
-			if app != nil && isCustomCommandInArgs(commandName) {
+			if app != nil && isCustomCommandInArgs(fullCommandName) {
 				suggestedCommands := strings.Split(projectTypes, ",")
 				for i, projectType := range suggestedCommands {
 					suggestedCommands[i] = fmt.Sprintf("ddev config --project-type=%s", projectType)
@@ -238,7 +330,7 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 		// If OSTypes is specified and we aren't on one of the specified OSes, skip
 		if osTypes != "" {
 			if !strings.Contains(osTypes, runtime.GOOS) && !(strings.Contains(osTypes, "wsl2") && nodeps.IsWSL2()) {
-				if isCustomCommandInArgs(commandName) {
+				if isCustomCommandInArgs(fullCommandName) {
 					util.Warning("Command '%s' cannot be used with your OS, skipping %s", commandName, onHostFullPath)
 				}
 				continue
@@ -261,7 +353,7 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 				return fileutil.FileExists(expanded)
 			})
 			if !binExists {
-				if isCustomCommandInArgs(commandName) {
+				if isCustomCommandInArgs(fullCommandName) {
 					suggestedBinaries, _ := util.ArrayToReadableOutput(bins)
 					util.Warning("Command '%s' cannot be used, skipping %s\nThe binary is not found at: %s", commandName, onHostFullPath, suggestedBinaries)
 				}
@@ -277,7 +369,7 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 		// If DBTypes is specified and we aren't using that DBTypes
 		if dbTypes != "" && app != nil {
 			if !strings.Contains(dbTypes, app.Database.Type) {
-				if isCustomCommandInArgs(commandName) {
+				if isCustomCommandInArgs(fullCommandName) {
 					util.Warning("Command '%s' is not available for the '%s' database type, skipping %s", commandName, app.Database.Type, onHostFullPath)
 				}
 				continue
@@ -308,6 +400,15 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 			util.Warning("Error '%s' in the flags definition for command '%s', skipping %s", err, commandName, onHostFullPath)
 			continue
 		}
+		commandArgs.AssignToCommand(commandToAdd)
+
+		// The parsed values of typed flags and args are passed to the script
+		typedEnv := func(command *cobra.Command, args []string) []string {
+			if disableFlags {
+				return nil
+			}
+			return append(flags.Environment(command), commandArgs.Environment(args)...)
+		}
 
 		// Execute the command matching the host working directory relative
 		// to the app root.
@@ -318,9 +419,9 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 			}
 		}
 
-		autocompletePathOnHost := filepath.Join(serviceDirOnHost, "autocomplete", commandName)
+		autocompletePathOnHost := filepath.Join(commandDirOnHost, "autocomplete", commandName)
 		if service == "host" {
-			commandToAdd.Run = makeHostCmd(app, onHostFullPath, commandName, mutagenSync)
+			commandToAdd.Run = makeHostCmd(app, onHostFullPath, fullCommandName, mutagenSync, typedEnv)
 			if fileutil.FileExists(autocompletePathOnHost) {
 				// Make sure autocomplete script can be executed
 				_ = util.Chmod(autocompletePathOnHost, 0755)
@@ -338,8 +439,8 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 			if strings.HasPrefix(serviceDirOnHost, globalconfig.GetGlobalDdevDir()) {
 				containerBasePath = path.Join("/mnt/ddev-global-cache/global-commands/", service)
 			}
-			inContainerFullPath := path.Join(containerBasePath, commandName)
-			commandToAdd.Run = makeContainerCmd(app, inContainerFullPath, commandName, service, execRaw, relative, mutagenSync)
+			inContainerFullPath := path.Join(containerBasePath, group, commandName)
+			commandToAdd.Run = makeContainerCmd(app, inContainerFullPath, fullCommandName, service, execRaw, relative, mutagenSync, typedEnv)
 			if fileutil.FileExists(autocompletePathOnHost) {
 				// Make sure autocomplete script can be executed
 				_ = util.Chmod(autocompletePathOnHost, 0755)
@@ -348,7 +449,7 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 					continue
 				}
 				// Add autocomplete script
-				autocompletePathInContainer := path.Join(containerBasePath, "autocomplete", commandName)
+				autocompletePathInContainer := path.Join(containerBasePath, group, "autocomplete", commandName)
 				commandToAdd.ValidArgsFunction = makeContainerCompletionFunc(autocompletePathInContainer, service, app, commandToAdd)
 			}
 		}
@@ -377,20 +478,22 @@ func addCustomCommandsFromDir(rootCmd *cobra.Command, app *ddevapp.DdevApp, serv
 		}
 
 		commandToAdd.Annotations[CustomCommand] = "true"
-		if ddevapp.IsBundledCustomCommand(isGlobalSet, service, commandName) {
+		if group == "" && ddevapp.IsBundledCustomCommand(isGlobalSet, service, commandName) {
 			commandToAdd.Annotations[BundledCustomCommand] = "true"
 		}
 
 		// Add the command and mark as added
-		rootCmd.AddCommand(commandToAdd)
-		commandsAdded[commandName] = 1
+		parentCmd.AddCommand(commandToAdd)
+		commandsAdded[fullCommandName] = 1
 	}
 	return nil
 }
 
-// isCustomCommandInArgs checks if the command is the first arg passed to the "ddev" command.
+// isCustomCommandInArgs checks if the command is the first arg passed to the "ddev" command,
+// or the first args for a command of a group, as in "qa lint".
 func isCustomCommandInArgs(commandName string) bool {
-	return len(os.Args) > 1 && os.Args[1] == commandName
+	words := strings.Fields(commandName)
+	return len(os.Args) > len(words) && slices.Equal(os.Args[1:len(words)+1], words)
 }
 
 func makeHostCompletionFunc(autocompletePathOnHost string, commandToAdd *cobra.Command) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
@@ -443,14 +546,28 @@ func makeContainerCompletionFunc(autocompletePathInContainer string, service str
 	}
 }
 
+// customCommandArgs returns the arguments on the command line after the name
+// of the custom command, which has more than one word when it's in a group, like "qa lint"
+func customCommandArgs(name string) []string {
+	commandWords := len(strings.Fields(name))
+	if len(os.Args) > commandWords+1 {
+		return os.Args[commandWords+1:]
+	}
+	return []string{}
+}
+
 // makeHostCmd creates a command which will run on the host
-func makeHostCmd(app *ddevapp.DdevApp, fullPath, name string, mutagenSync bool) func(*cobra.Command, []string) {
+func makeHostCmd(app *ddevapp.DdevApp, fullPath, name string, mutagenSync bool, typedEnv func(*cobra.Command, []string) []string) func(*cobra.Command, []string) {
 	var windowsBashPath = ""
 	if nodeps.IsWindows() {
 		windowsBashPath = util.FindBashPath()
 	}
 
-	return func(_ *cobra.Command, _ []string) {
+	return func(cmd *cobra.Command, args []string) {
+		for _, e := range typedEnv(cmd, args) {
+			k, v, _ := strings.Cut(e, "=")
+			_ = os.Setenv(k, v)
+		}
 		if app != nil {
 			status, _ := app.SiteStatus()
 			_ = app.DockerEnv()
@@ -459,10 +576,7 @@ func makeHostCmd(app *ddevapp.DdevApp, fullPath, name string, mutagenSync bool)
 			_ = os.Setenv("DDEV_PROJECT_STATUS", "")
 		}
 
-		osArgs := []string{}
-		if len(os.Args) > 2 {
-			osArgs = os.Args[2:]
-		}
+		osArgs := customCommandArgs(name)
 		var err error
 		// Load environment variables that may be useful for script.
 		if app != nil {
@@ -488,12 +602,12 @@ func makeHostCmd(app *ddevapp.DdevApp, fullPath, name string, mutagenSync bool)
 }
 
 // makeContainerCmd creates the command which will app.Exec to a container command
-func makeContainerCmd(app *ddevapp.DdevApp, fullPath, name, service string, execRaw bool, relative bool, mutagenSync bool) func(*cobra.Command, []string) {
+func makeContainerCmd(app *ddevapp.DdevApp, fullPath, name, service string, execRaw bool, relative bool, mutagenSync bool, typedEnv func(*cobra.Command, []string) []string) func(*cobra.Command, []string) {
 	s := service
 	if s[0:1] == "." {
 		s = s[1:]
 	}
-	return func(_ *cobra.Command, _ []string) {
+	return func(cmd *cobra.Command, args []string) {
 		status, _ := app.SiteStatus()
 		if status != ddevapp.SiteRunning {
 			err := app.Start()
@@ -507,10 +621,7 @@ func makeContainerCmd(app *ddevapp.DdevApp, fullPath, name, service string, exec
 			runMutagenSync(app, mutagenSync)
 		}
 
-		osArgs := []string{}
-		if len(os.Args) > 2 {
-			osArgs = os.Args[2:]
-		}
+		osArgs := customCommandArgs(name)
 
 		opts := &ddevapp.ExecOpts{
 			Cmd:       fullPath + " " + strings.Join(osArgs, " "),
@@ -518,6 +629,7 @@ func makeContainerCmd(app *ddevapp.DdevApp, fullPath, name, service string, exec
 			Dir:       app.GetWorkingDir(s, ""),
 			Tty:       isatty.IsTerminal(os.Stdin.Fd()),
 			NoCapture: true,
+			Env:       typedEnv(cmd, args),
 		}
 		if relative {
 			opts.Dir = path.Join(app.GetAbsAppRoot(true), app.GetRelativeWorkingDirectory())
@@ -17,6 +17,7 @@ import (
 	"github.com/ddev/ddev/pkg/nodeps"
 	"github.com/ddev/ddev/pkg/testcommon"
 	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
 	asrt "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -592,3 +593,74 @@ func TestNpmNpxYarnCommands(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+// TestUnitCmdCustomCommandGroups checks subdirectories of command directories
+// become command groups, and conflicting groups are skipped.
+func TestUnitCmdCustomCommandGroups(t *testing.T) {
+	assert := asrt.New(t)
+	hostDir := filepath.Join(t.TempDir(), "commands", "host")
+
+	script := "#!/usr/bin/env bash\n## Description: %s\n## CanRunGlobally: true\necho hi\n"
+	for _, f := range []string{"qa/lint", "qa/unit", "config/show", "top", "autocomplete/top", "empty/README.txt"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(hostDir, f)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(hostDir, f), []byte(script), 0755))
+	}
+
+	rootCmd := &cobra.Command{Use: "ddev"}
+	rootCmd.AddCommand(&cobra.Command{Use: "config", Run: func(*cobra.Command, []string) {}})
+
+	require.NoError(t, addCustomCommandsFromServiceDir(rootCmd, nil, hostDir, true, true, map[string]int{}))
+
+	names := func(c *cobra.Command) []string {
+		var n []string
+		for _, sub := range c.Commands() {
+			n = append(n, sub.Name())
+		}
+		return n
+	}
+	// The built-in config isn't replaced, autocomplete isn't a group
+	// and groups without commands are removed
+	assert.Equal([]string{"config", "qa", "top"}, names(rootCmd))
+
+	qa, _, err := rootCmd.Find([]string{"qa"})
+	require.NoError(t, err)
+	assert.Equal("true", qa.Annotations[CustomCommandGroup])
+	assert.Equal([]string{"lint", "unit"}, names(qa))
+
+	config, _, err := rootCmd.Find([]string{"config"})
+	require.NoError(t, err)
+	assert.False(config.HasSubCommands())
+}
+
+// TestUnitCmdCustomCommandGroupArgs checks that a script in a command group
+// gets only the arguments after the group and command names
+func TestUnitCmdCustomCommandGroupArgs(t *testing.T) {
+	if nodeps.IsWindows() {
+		t.Skip("Skipping on Windows, the script is run with bash")
+	}
+	tmpDir := t.TempDir()
+	hostDir := filepath.Join(tmpDir, "commands", "host")
+	argsFile := filepath.Join(tmpDir, "args.txt")
+
+	script := fmt.Sprintf("#!/usr/bin/env bash\n## Description: Lint\n## CanRunGlobally: true\necho \"$# $*\" > %s\n", argsFile)
+	require.NoError(t, os.MkdirAll(filepath.Join(hostDir, "qa"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hostDir, "qa", "lint"), []byte(script), 0755))
+
+	rootCmd := &cobra.Command{Use: "ddev"}
+	require.NoError(t, addCustomCommandsFromServiceDir(rootCmd, nil, hostDir, true, true, map[string]int{}))
+
+	origArgs := os.Args
+	t.Cleanup(func() {
+		os.Args = origArgs
+	})
+	os.Args = []string{"ddev", "qa", "lint", "foo", "bar"}
+
+	lint, args, err := rootCmd.Find(os.Args[1:])
+	require.NoError(t, err)
+	require.Equal(t, "lint", lint.Name())
+	lint.Run(lint, args)
+
+	out, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	require.Equal(t, "2 foo bar\n", string(out))
+}
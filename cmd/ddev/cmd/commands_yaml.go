@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/nodeps"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v4"
+)
+
+// yamlCommand is a custom command defined in a .yaml file of a commands directory.
+// Instead of a script, it runs a list of steps, which are hook tasks
+// (exec, exec-host, composer, ...) whose strings are Go templates.
+type yamlCommand struct {
+	Description string             `yaml:"description"`
+	Usage       string             `yaml:"usage"`
+	Example     string             `yaml:"example"`
+	Aliases     []string           `yaml:"aliases"`
+	Service     string             `yaml:"service"`
+	Flags       []map[string]any   `yaml:"flags"`
+	Args        []map[string]any   `yaml:"args"`
+	Steps       []ddevapp.YAMLTask `yaml:"steps"`
+
+	flags Flags
+	args  CommandArgs
+	// commandsDir is the directory of the command in .ddev/commands, host or a service
+	commandsDir string
+}
+
+// yamlCommandData is what the templates of the steps of a YAML command get
+type yamlCommandData struct {
+	// Flags are the values of the flags, by name
+	Flags map[string]string
+	// Args are the values of the typed positional args, by name
+	Args map[string]string
+	// RawArgs are all the positional args
+	RawArgs []string
+}
+
+// yamlCommandFuncs are the functions available in the templates of the steps
+var yamlCommandFuncs = template.FuncMap{
+	"quote": shellQuote,
+}
+
+// isYAMLCommand reports whether a file of a commands directory is a YAML command
+func isYAMLCommand(fileName string) bool {
+	ext := filepath.Ext(fileName)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// shellQuote quotes s for bash, so that it's a single word whatever it contains
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// loadYAMLCommand reads and validates the YAML command in fullPath.
+// Steps that exec without a service run in the service of the commands directory,
+// or in the web container for host commands, unless the command sets `service`.
+func loadYAMLCommand(commandName, fullPath, service string) (*yamlCommand, error) {
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c := &yamlCommand{commandsDir: service}
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err = dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if c.Service == "" {
+		c.Service = service
+		if service == "host" {
+			c.Service = nodeps.WebContainer
+		}
+	}
+
+	c.flags.Init(commandName, fullPath)
+	if len(c.Flags) > 0 {
+		data, err := json.Marshal(c.Flags)
+		if err != nil {
+			return nil, err
+		}
+		if err = c.flags.LoadFromJSON(string(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	c.args.Init(commandName, fullPath)
+	if len(c.Args) > 0 {
+		data, err := json.Marshal(c.Args)
+		if err != nil {
+			return nil, err
+		}
+		if err = c.args.LoadFromJSON(string(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.Steps) == 0 {
+		return nil, fmt.Errorf("no steps defined")
+	}
+	for i, step := range c.Steps {
+		if _, ok := step["exec"]; ok {
+			if _, ok := step["service"]; !ok {
+				step["service"] = c.Service
+			}
+		}
+		if ddevapp.NewTask(nil, step) == nil {
+			return nil, fmt.Errorf("invalid step %d '%v', valid steps are exec, exec-host, composer, wait-for and http", i+1, step)
+		}
+		if _, err = renderYAMLCommandStep(step, nil); err != nil {
+			return nil, fmt.Errorf("invalid step %d: %v", i+1, err)
+		}
+	}
+
+	return c, nil
+}
+
+// needsProject reports whether any step runs in a container
+func (c *yamlCommand) needsProject() bool {
+	for _, step := range c.Steps {
+		if _, ok := step["exec-host"]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// renderYAMLCommandStep executes the templates of the strings of a step with data.
+// With nil data, it only parses them.
+func renderYAMLCommandStep(step ddevapp.YAMLTask, data *yamlCommandData) (ddevapp.YAMLTask, error) {
+	rendered := ddevapp.YAMLTask{}
+	for k, v := range step {
+		r, err := renderYAMLCommandValue(v, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", k, err)
+		}
+		rendered[k] = r
+	}
+	return rendered, nil
+}
+
+// renderYAMLCommandValue executes the templates of the strings in value with data
+func renderYAMLCommandValue(value any, data *yamlCommandData) (any, error) {
+	switch v := value.(type) {
+	case string:
+		t, err := template.New("step").Funcs(yamlCommandFuncs).Option("missingkey=error").Parse(v)
+		if err != nil || data == nil {
+			return v, err
+		}
+		var b strings.Builder
+		if err = t.Execute(&b, data); err != nil {
+			return nil, err
+		}
+		return b.String(), nil
+	case []any:
+		rendered := make([]any, len(v))
+		for i, item := range v {
+			r, err := renderYAMLCommandValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	case map[string]any:
+		rendered := make(map[string]any, len(v))
+		for k, item := range v {
+			r, err := renderYAMLCommandValue(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+			rendered[k] = r
+		}
+		return rendered, nil
+	}
+	return value, nil
+}
+
+// addYAMLCommand adds the YAML command in fileName of the commands directory serviceDirOnHost,
+// or of its group subdirectory, as a subcommand of parentCmd
+func addYAMLCommand(parentCmd *cobra.Command, app *ddevapp.DdevApp, serviceDirOnHost string, group string, fileName string, isGlobalSet bool, commandsAdded map[string]int) {
+	onHostFullPath := filepath.Join(serviceDirOnHost, group, fileName)
+	commandName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	fullCommandName := strings.TrimSpace(group + " " + commandName)
+	if _, ok := commandsAdded[fullCommandName]; ok {
+		return
+	}
+
+	// The steps run with the project, there is no YAML command outside of one
+	if app == nil {
+		if isCustomCommandInArgs(fullCommandName) {
+			util.Warning("Command '%s' cannot be used outside the project directory, skipping %s", commandName, onHostFullPath)
+		}
+		return
+	}
+
+	c, err := loadYAMLCommand(commandName, onHostFullPath, filepath.Base(serviceDirOnHost))
+	if err != nil {
+		util.Warning("Error '%s' in YAML command '%s', skipping %s", err, commandName, onHostFullPath)
+		return
+	}
+
+	commandToAdd, err := newYAMLCommand(app, c, commandName, isGlobalSet)
+	if err != nil {
+		util.Warning("Error '%s' in the flags definition for command '%s', skipping %s", err, commandName, onHostFullPath)
+		return
+	}
+	// Validate usage is not already in use
+	if foundCmd, _, err := parentCmd.Find(strings.Split(commandToAdd.Use, " ")); err == nil && foundCmd != nil && foundCmd != parentCmd {
+		util.Warning("Command '%s' cannot have usage '%s' because it is already in use by command '%s', skipping %s", commandName, commandToAdd.Use, foundCmd.Name(), onHostFullPath)
+		return
+	}
+	var aliases []string
+	for _, alias := range commandToAdd.Aliases {
+		if foundCmd, _, err := parentCmd.Find([]string{alias}); err != nil || foundCmd == parentCmd {
+			aliases = append(aliases, alias)
+		} else {
+			util.Warning("Command '%s' cannot have alias '%s' that is already in use by command '%s', skipping alias for %s", commandName, alias, foundCmd.Name(), onHostFullPath)
+		}
+	}
+	commandToAdd.Aliases = aliases
+
+	commandToAdd.Annotations = map[string]string{CustomCommand: "true"}
+	parentCmd.AddCommand(commandToAdd)
+	commandsAdded[fullCommandName] = 1
+}
+
+// newYAMLCommand creates the cobra command of a YAML command
+func newYAMLCommand(app *ddevapp.DdevApp, c *yamlCommand, commandName string, isGlobalSet bool) (*cobra.Command, error) {
+	usage := commandName + " [flags]"
+	if len(c.args.Definition) > 0 {
+		usage += " " + c.args.Usage()
+	} else {
+		usage += " [args]"
+	}
+	if c.Usage != "" {
+		usage = c.Usage
+	}
+	description := commandName
+	if c.Description != "" {
+		description = c.Description
+	}
+	example := ""
+	if c.Example != "" {
+		example = "  " + strings.ReplaceAll(strings.TrimSpace(c.Example), "\n", "\n  ")
+	}
+
+	// Like for script commands, the suffix tells where the command is, not where its steps run
+	descSuffix := " (yaml " + c.commandsDir + " container command)"
+	if isGlobalSet {
+		descSuffix = " (global yaml " + c.commandsDir + " container command)"
+	}
+
+	commandToAdd := &cobra.Command{
+		Use:     usage,
+		Short:   description + descSuffix,
+		Example: example,
+		Aliases: c.Aliases,
+		Run:     makeYAMLCmd(app, c, commandName),
+	}
+	if err := c.flags.AssignToCommand(commandToAdd); err != nil {
+		return nil, err
+	}
+	c.args.AssignToCommand(commandToAdd)
+
+	return commandToAdd, nil
+}
+
+// makeYAMLCmd creates the command which runs the steps of a YAML command
+func makeYAMLCmd(app *ddevapp.DdevApp, c *yamlCommand, name string) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		// exec-host steps get the values as environment variables too
+		for _, e := range append(c.flags.Environment(cmd), c.args.Environment(args)...) {
+			k, v, _ := strings.Cut(e, "=")
+			_ = os.Setenv(k, v)
+		}
+
+		if c.needsProject() {
+			status, _ := app.SiteStatus()
+			if status != ddevapp.SiteRunning {
+				if err := app.Start(); err != nil {
+					util.Failed("Failed to start project for custom command: %v", err)
+				}
+			}
+		}
+		_ = app.DockerEnv()
+
+		data := &yamlCommandData{
+			Flags:   c.flags.Values(cmd),
+			Args:    c.args.Values(args),
+			RawArgs: args,
+		}
+		for i, step := range c.Steps {
+			rendered, err := renderYAMLCommandStep(step, data)
+			if err != nil {
+				util.Failed("Failed to run %s, step %d: %v", name, i+1, err)
+			}
+			task := ddevapp.NewTask(app, rendered)
+			if task == nil {
+				util.Failed("Failed to run %s, step %d is invalid: %v", name, i+1, rendered)
+			}
+			output.UserOut.Debugf("=== Running step %d of %s: %s", i+1, name, task.GetDescription())
+			if err = task.Execute(); err != nil {
+				util.Failed("Failed to run %s, step %d (%s): %v", name, i+1, task.GetDescription(), err)
+			}
+		}
+	}
+}
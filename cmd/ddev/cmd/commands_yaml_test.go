@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ddev/ddev/pkg/ddevapp"
+	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUnitCmdYAMLCommand checks loading, validating and rendering YAML custom commands.
+func TestUnitCmdYAMLCommand(t *testing.T) {
+	assert := asrt.New(t)
+	dir := t.TempDir()
+
+	write := func(content string) string {
+		f := filepath.Join(dir, "deploy.yaml")
+		require.NoError(t, os.WriteFile(f, []byte(content), 0644))
+		return f
+	}
+
+	f := write(`
+description: Deploy the site
+flags:
+  - name: env
+    usage: target environment
+    type: enum
+    values: [dev, prod]
+    required: true
+  - name: dry-run
+    usage: only show what would be done
+args:
+  - name: site
+    defvalue: main
+steps:
+  - exec: drush --uri={{ quote .Args.site }} deploy --env={{ .Flags.env }}
+  - exec-host: echo {{ index .Flags "dry-run" }} {{ range .RawArgs }}{{ quote . }} {{ end }}
+  - composer: install
+`)
+	c, err := loadYAMLCommand("deploy", f, "host")
+	require.NoError(t, err)
+	assert.Equal("Deploy the site", c.Description)
+	// exec steps run in web for host commands unless the service is set
+	assert.Equal("web", c.Service)
+	assert.Equal("web", c.Steps[0]["service"])
+	assert.True(c.needsProject())
+
+	cmd, err := newYAMLCommand(nil, c, "deploy", false)
+	require.NoError(t, err)
+	assert.Equal("deploy [flags] [<site>]", cmd.Use)
+	assert.Equal("Deploy the site (yaml host container command)", cmd.Short)
+	require.NoError(t, cmd.ParseFlags([]string{"--env", "prod"}))
+	webCommand, err := loadYAMLCommand("deploy", f, "web")
+	require.NoError(t, err)
+	webCmd, err := newYAMLCommand(nil, webCommand, "deploy", true)
+	require.NoError(t, err)
+	assert.Equal("Deploy the site (global yaml web container command)", webCmd.Short)
+
+	data := &yamlCommandData{Flags: c.flags.Values(cmd), Args: c.args.Values([]string{"it's"}), RawArgs: []string{"it's"}}
+	step, err := renderYAMLCommandStep(c.Steps[0], data)
+	require.NoError(t, err)
+	assert.Equal(`drush --uri='it'\''s' deploy --env=prod`, step["exec"])
+	step, err = renderYAMLCommandStep(c.Steps[1], data)
+	require.NoError(t, err)
+	assert.Equal(`echo false 'it'\''s' `, step["exec-host"])
+	assert.NotNil(ddevapp.NewTask(nil, step))
+
+	// Templates in the options of a step
+	step, err = renderYAMLCommandStep(ddevapp.YAMLTask{"wait-for": map[string]any{"url": "https://{{ .Args.site }}.ddev.site", "timeout": 10}}, data)
+	require.NoError(t, err)
+	assert.Equal(map[string]any{"url": "https://it's.ddev.site", "timeout": 10}, step["wait-for"])
+	_, err = renderYAMLCommandStep(ddevapp.YAMLTask{"wait-for": map[string]any{"url": "{{ .Args.site"}}, nil)
+	assert.ErrorContains(err, "wait-for: url:")
+
+	// Unknown flag in a template
+	_, err = renderYAMLCommandStep(ddevapp.YAMLTask{"exec": "echo {{ .Flags.nope }}"}, data)
+	assert.Error(err)
+
+	// Invalid commands
+	for _, content := range []string{
+		"description: no steps",
+		"steps:\n  - unknown: ls",
+		"steps:\n  - exec: echo {{ .Flags.env",
+		"unknown_key: true\nsteps:\n  - exec: ls",
+		"flags:\n  - name: env\n    type: enum\nsteps:\n  - exec: ls",
+	} {
+		_, err = loadYAMLCommand("deploy", write(content), "web")
+		assert.Error(err, content)
+	}
+}
@@ -143,6 +143,11 @@ func handleGlobalConfig(cmd *cobra.Command, _ []string) {
 		dirty = true
 	}
 
+	if cmd.Flag("router-tcp-routing").Changed {
+		globalconfig.DdevGlobalConfig.RouterTCPRouting, _ = cmd.Flags().GetBool("router-tcp-routing")
+		dirty = true
+	}
+
 	if cmd.Flag("simple-formatting").Changed {
 		globalconfig.DdevGlobalConfig.SimpleFormatting, _ = cmd.Flags().GetBool("simple-formatting")
 		dirty = true
@@ -314,6 +319,8 @@ func init() {
 	_ = configGlobalCommand.RegisterFlagCompletionFunc("instrumentation-opt-in", configCompletionFunc([]string{"true", "false"}))
 	configGlobalCommand.Flags().Bool("router-bind-all-interfaces", false, "Bind host router ports on all interfaces, not only on the localhost network interface")
 	_ = configGlobalCommand.RegisterFlagCompletionFunc("router-bind-all-interfaces", configCompletionFunc([]string{"true", "false"}))
+	configGlobalCommand.Flags().Bool("router-tcp-routing", false, "Route TCP connections to databases and services defining TCP_EXPOSE through ddev-router, by TLS SNI")
+	_ = configGlobalCommand.RegisterFlagCompletionFunc("router-tcp-routing", configCompletionFunc([]string{"true", "false"}))
 	configGlobalCommand.Flags().Int("internet-detection-timeout-ms", nodeps.InternetDetectionTimeoutDefault, "Increase timeout when checking internet timeout, in milliseconds")
 	_ = configGlobalCommand.RegisterFlagCompletionFunc("internet-detection-timeout-ms", configCompletionFunc([]string{strconv.Itoa(nodeps.InternetDetectionTimeoutDefault)}))
 	configGlobalCommand.Flags().Bool("use-letsencrypt", false, "Enables experimental Let's Encrypt integration, 'ddev config global --use-letsencrypt' or 'ddev config global --use-letsencrypt=false'")
@@ -209,6 +209,11 @@ func renderAppDescribe(app *ddevapp.DdevApp, desc map[string]any) (string, error
 			}
 		}
 
+		// Stable connection string through the router, see router_tcp_routing
+		if tcpURL, ok := v["router_tcp_url"].(string); ok && tcpURL != "" {
+			urlPortParts = append(urlPortParts, "Router: "+tcpURL)
+		}
+
 		// Get extra info for web container
 		if k == "web" {
 			projectType := fmt.Sprintf("%s PHP %s", desc["type"], desc["php_version"])
@@ -278,6 +283,15 @@ func renderAppDescribe(app *ddevapp.DdevApp, desc map[string]any) (string, error
 			t.AppendRow(table.Row{"Project URLs", "", strings.Join(linkedURLs, "\n")})
 		}
 	}
+	// Background shares of `ddev share --detach`
+	if shares, ok := desc["shares"].([]map[string]any); ok && len(shares) > 0 {
+		shareInfo := make([]string, 0, len(shares))
+		for _, share := range shares {
+			shareURL := share["url"].(string)
+			shareInfo = append(shareInfo, fmt.Sprintf("%s: %s", share["provider"], output.Hyperlink(shareURL, shareURL)))
+		}
+		t.AppendRow(table.Row{"Shares", "", strings.Join(shareInfo, "\n") + "\nStop: ddev share stop"})
+	}
 	bindInfo := []string{}
 	if app.BindAllInterfaces || dockerutil.IsRemoteDockerHost() {
 		bindInfo = append(bindInfo, "bind-all-interfaces ENABLED")
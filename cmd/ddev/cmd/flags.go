@@ -3,6 +3,7 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -17,6 +18,7 @@ type typeValue string
 type defValueValue string
 type noOptDefValValue string
 type annotationsValue map[string][]string
+type valuesValue []string
 
 // Flag is the structure for the flags, the json from the annotation is
 // unmarshaled into this structure. For more information see also
@@ -29,6 +31,8 @@ type Flag struct {
 	DefValue    defValueValue    // default value (as text); for usage message
 	NoOptDefVal noOptDefValValue // default value (as text); if the flag is on the command line without any options
 	Annotations annotationsValue // used by cobra.Command Bash autocomplete code
+	Required    bool             // the command fails if the flag isn't on the command line
+	Values      valuesValue      // valid values of an enum flag, also used for completion
 }
 
 // FlagsDefinition is an array of Flag holding all defined flags of a command.
@@ -47,6 +51,7 @@ const (
 	FtCount          = "count"
 	FtDuration       = "duration"
 	FtDurationSlice  = "durationSlice"
+	FtEnum           = "enum"
 	FtFloat32        = "float32"
 	FtFloat32Slice   = "float32Slice"
 	FtFloat64        = "float64"
@@ -93,6 +98,7 @@ var ValidTypes = map[typeValue]bool{
 	FtCount:          false,
 	FtDuration:       false,
 	FtDurationSlice:  false,
+	FtEnum:           true,
 	FtFloat32:        false,
 	FtFloat32Slice:   false,
 	FtFloat64:        false,
@@ -223,7 +229,7 @@ func (v *defValueValue) validate(aType typeValue) error {
 		*v = defValueValue(strconv.FormatBool(false))
 	case FtCount, FtDuration, FtFloat32, FtFloat64, FtInt, FtInt8, FtInt16, FtInt32, FtUint, FtUint8, FtUint16, FtUint32:
 		*v = defValueValue(strconv.FormatInt(0, 10))
-	case FtString:
+	case FtString, FtEnum:
 		*v = ""
 	case ftTest3: // used for testing only
 		*v = ""
@@ -257,6 +263,18 @@ func (v *annotationsValue) validate() error {
 	return nil
 }
 
+// validate checks a valuesValue is defined for enums only.
+func (v *valuesValue) validate(aType typeValue) error {
+	if aType == FtEnum && len(*v) == 0 {
+		return formatErrorItem(2, "-", "no values defined for type '%s'", aType)
+	}
+	if aType != FtEnum && len(*v) > 0 {
+		return formatErrorItem(2, "-", "values are only allowed for type '%s'", FtEnum)
+	}
+
+	return nil
+}
+
 // validateFlag checks all fields by calling the corresponding validate method.
 func (f *Flag) validateFlag(longOptions *map[nameValue]bool, shortOptions *map[shorthandValue]nameValue) error {
 	errors := ""
@@ -269,6 +287,7 @@ func (f *Flag) validateFlag(longOptions *map[nameValue]bool, shortOptions *map[s
 	errors += extractError(f.DefValue.validate(f.Type))
 	errors += extractError(f.NoOptDefVal.validate(f.Type))
 	errors += extractError(f.Annotations.validate())
+	errors += extractError(f.Values.validate(f.Type))
 
 	if errors != "" {
 		return fmt.Errorf("%s", errors)
@@ -352,6 +371,9 @@ func (f *Flags) AssignToCommand(command *cobra.Command) error {
 			command.Flags().StringP(string(flag.Name), string(flag.Shorthand), "", string(flag.Usage))
 		case FtUint:
 			command.Flags().UintP(string(flag.Name), string(flag.Shorthand), 0, string(flag.Usage))
+		case FtEnum:
+			command.Flags().VarP(&enumValue{values: flag.Values}, string(flag.Name), string(flag.Shorthand), string(flag.Usage)+" ("+strings.Join(flag.Values, ", ")+")")
+			_ = command.RegisterFlagCompletionFunc(string(flag.Name), cobra.FixedCompletions(flag.Values, cobra.ShellCompDirectiveNoFileComp))
 		default:
 			if implemented := ValidTypes[flag.Type]; implemented {
 				// Mandatory implementation missing -> panic
@@ -364,7 +386,10 @@ func (f *Flags) AssignToCommand(command *cobra.Command) error {
 		// Update default values and annotations
 		newFlag := command.Flags().Lookup(string(flag.Name))
 
-		if err := newFlag.Value.Set(string(flag.DefValue)); err != nil {
+		// An enum without a default value is empty, which isn't one of its values
+		if flag.Type == FtEnum && flag.DefValue == "" {
+			// Nothing to set
+		} else if err := newFlag.Value.Set(string(flag.DefValue)); err != nil {
 			// Invalid default value was defined by the user, hide the flag
 			// and save the error
 			newFlag.Hidden = true
@@ -374,6 +399,10 @@ func (f *Flags) AssignToCommand(command *cobra.Command) error {
 		newFlag.DefValue = string(flag.DefValue)
 		newFlag.NoOptDefVal = string(flag.NoOptDefVal)
 		newFlag.Annotations = flag.Annotations
+
+		if flag.Required {
+			_ = command.MarkFlagRequired(string(flag.Name))
+		}
 	}
 
 	if errors.String() != "" {
@@ -382,3 +411,58 @@ func (f *Flags) AssignToCommand(command *cobra.Command) error {
 
 	return nil
 }
+
+// Environment returns the DDEV_FLAG_<NAME> environment variables with the
+// values of the defined flags of the command, parsed or default, for the script.
+func (f *Flags) Environment(command *cobra.Command) []string {
+	var env []string
+	values := f.Values(command)
+	for _, flag := range f.Definition {
+		if value, ok := values[string(flag.Name)]; ok {
+			env = append(env, envVarName("DDEV_FLAG_", string(flag.Name))+"="+value)
+		}
+	}
+	return env
+}
+
+// Values returns the values of the defined flags of the command, parsed or default, by name.
+func (f *Flags) Values(command *cobra.Command) map[string]string {
+	values := map[string]string{}
+	for _, flag := range f.Definition {
+		if newFlag := command.Flags().Lookup(string(flag.Name)); newFlag != nil && ValidTypes[flag.Type] {
+			values[string(flag.Name)] = newFlag.Value.String()
+		}
+	}
+	return values
+}
+
+// envVarName returns the name of the environment variable of a flag or arg,
+// the uppercased name with dashes replaced by underscores, prefixed
+func envVarName(prefix string, name string) string {
+	return prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// enumValue is the pflag.Value of an enum flag, which only accepts one of its values.
+type enumValue struct {
+	value  string
+	values []string
+}
+
+// String returns the value of the flag.
+func (e *enumValue) String() string {
+	return e.value
+}
+
+// Set sets the value of the flag if it's one of the valid values.
+func (e *enumValue) Set(value string) error {
+	if !slices.Contains(e.values, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(e.values, ", "))
+	}
+	e.value = value
+	return nil
+}
+
+// Type returns the type of the flag.
+func (e *enumValue) Type() string {
+	return FtEnum
+}
@@ -193,3 +193,37 @@ func TestUnitCmdFlagsAssignToCommand(t *testing.T) {
 	assert.EqualError(subject.AssignToCommand(&c),
 		"the following problems were found while assigning the flags to the command 'command' in 'script':\n - error 'strconv.ParseBool: parsing \"no-bool-value\": invalid syntax' while set value of flag 'test-1'\n - error 'strconv.ParseInt: parsing \"no-int-value\": invalid syntax' while set value of flag 'test-2'")
 }
+
+// TestUnitCmdFlagsEnum checks enum and required flags and the environment
+// variables of the parsed values.
+func TestUnitCmdFlagsEnum(t *testing.T) {
+	assert := asrt.New(t)
+
+	// Enum without values
+	subject := getSubject()
+	assert.Error(subject.LoadFromJSON(`[{"Name":"env","Usage":"Usage of env","Type":"enum"}]`))
+
+	// Values on a non-enum
+	subject = getSubject()
+	assert.Error(subject.LoadFromJSON(`[{"Name":"env","Usage":"Usage of env","Type":"string","Values":["dev"]}]`))
+
+	subject = getSubject()
+	c := getCommand()
+	assert.NoError(subject.LoadFromJSON(`[{"Name":"env","Usage":"Usage of env","Type":"enum","Values":["dev","prod"],"Required":true},{"Name":"dry-run","Usage":"Usage of dry-run","Type":"bool"}]`))
+	assert.NoError(subject.AssignToCommand(&c))
+
+	f := c.Flags().Lookup("env")
+	assert.EqualValues(FtEnum, f.Value.Type())
+	assert.EqualValues("", f.DefValue)
+	assert.Equal([]string{"true"}, f.Annotations[cobra.BashCompOneRequiredFlag])
+	assert.Error(f.Value.Set("staging"))
+	assert.NoError(f.Value.Set("prod"))
+
+	assert.Equal([]string{"DDEV_FLAG_ENV=prod", "DDEV_FLAG_DRY_RUN=false"}, subject.Environment(&c))
+
+	// Invalid enum DefValue
+	subject = getSubject()
+	c = getCommand()
+	assert.NoError(subject.LoadFromJSON(`[{"Name":"env","Usage":"Usage of env","Type":"enum","Values":["dev","prod"],"DefValue":"staging"}]`))
+	assert.Error(subject.AssignToCommand(&c))
+}
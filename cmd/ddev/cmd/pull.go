@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/ddev/ddev/pkg/ddevapp"
 	"github.com/ddev/ddev/pkg/dockerutil"
 	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/output"
 	"github.com/ddev/ddev/pkg/util"
 	"github.com/spf13/cobra"
 )
@@ -17,12 +19,19 @@ var PullCmd = &cobra.Command{
 	Short: "Pull files and database using a configured provider plugin.",
 	Long: `Pull files and database using a configured provider plugin.
 	Running pull will connect to the configured provider and download + import the
-	database and files.`,
+	database and files. The database and files are downloaded concurrently.
+	If a pull is interrupted, the downloads that completed are verified with their
+	checksums and reused by the next pull, unless --force-redownload is used.`,
 	Example: `ddev pull upsun
 ddev pull pantheon -y
 ddev pull upsun --skip-files -y
 ddev pull acquia --skip-db -y
 ddev pull upsun --skip-import
+ddev pull upsun --force-redownload
+ddev pull upsun --skip-sanitize
+ddev pull upsun --dry-run
+ddev pull acquia --env stage
+ddev pull upsun --events=jsonl -y
 ddev pull upsun --environment="PLATFORM_ENVIRONMENT=main,UPSUN_CLI_TOKEN=abcdeyourtoken"
 ddev pull pantheon --environment="DDEV_PANTHEON_ENVIRONMENT=dev,TERMINUS_MACHINE_TOKEN=abcdeyourtoken"
 ddev pull lagoon --environment="LAGOON_PROJECT=your-project,LAGOON_ENVIRONMENT=main"
@@ -35,11 +44,16 @@ ddev pull lagoon --environment="LAGOON_PROJECT=your-project,LAGOON_ENVIRONMENT=m
 }
 
 // appPull() does the work of pull
-func appPull(providerType string, app *ddevapp.DdevApp, skipConfirmation bool, skipImportArg bool, skipDBArg bool, skipFilesArg bool, env string) {
+func appPull(providerType string, app *ddevapp.DdevApp, skipConfirmation bool, skipImportArg bool, skipDBArg bool, skipFilesArg bool, forceRedownload bool, skipSanitize bool, dryRun bool, envName string, env string) {
 	provider, err := app.GetProvider(providerType)
 	if err != nil {
 		util.Failed("Failed to get provider: %v", err)
 	}
+	provider.ForceRedownload = forceRedownload
+	provider.SkipSanitize = skipSanitize
+	if err = provider.SelectEnvironment(envName); err != nil {
+		util.Failed("Failed to select provider environment: %v", err)
+	}
 
 	// Add or override the command-line provided environment variables
 	if env != "" {
@@ -53,6 +67,15 @@ func appPull(providerType string, app *ddevapp.DdevApp, skipConfirmation bool, s
 		}
 	}
 
+	if dryRun {
+		plan, err := app.GetPullPlan(provider, skipDBArg, skipFilesArg, skipImportArg)
+		if err != nil {
+			util.Failed("Failed to resolve pull plan: %v", err)
+		}
+		output.UserOut.WithField("raw", plan).Print(renderPullPlan(plan))
+		return
+	}
+
 	// If we're not performing the import step, we won't be deleting the existing db or files.
 	if !skipConfirmation && !skipImportArg && globalconfig.IsInteractive() {
 		// Only warn the user about relevant risks.
@@ -109,12 +132,24 @@ ddev pull %s -y
 ddev pull %s --skip-files -y`, subCommandName, subCommandName, subCommandName),
 			Args: cobra.ExactArgs(0),
 			Run: func(cmd *cobra.Command, _ []string) {
+				checkEventsFlag(cmd)
 				app, err := ddevapp.GetActiveApp("")
 				if err != nil {
 					util.Failed("Pull failed: %v", err)
 				}
-				if err = app.StartAppIfNotRunning(); err != nil {
-					util.Failed("Failed to start app %s: %v", app.Name, err)
+				flags := map[string]bool{"skip-confirmation": false, "skip-db": false, "skip-files": false, "skip-import": false, "force-redownload": false, "skip-sanitize": false, "dry-run": false}
+				for f := range flags {
+					flags[f], err = cmd.Flags().GetBool(f)
+					if err != nil {
+						util.Failed("Failed to get flag %s: %v", f, err)
+					}
+				}
+
+				// A dry run doesn't start anything
+				if !flags["dry-run"] {
+					if err = app.StartAppIfNotRunning(); err != nil {
+						util.Failed("Failed to start app %s: %v", app.Name, err)
+					}
 				}
 				providerName := subCommandName
 				p, err := app.GetProvider(subCommandName)
@@ -123,16 +158,9 @@ ddev pull %s --skip-files -y`, subCommandName, subCommandName, subCommandName),
 				}
 				app.ProviderInstance = p
 
-				flags := map[string]bool{"skip-confirmation": false, "skip-db": false, "skip-files": false, "skip-import": false}
-				for f := range flags {
-					flags[f], err = cmd.Flags().GetBool(f)
-					if err != nil {
-						util.Failed("Failed to get flag %s: %v", f, err)
-					}
-				}
-
+				envName, _ := cmd.Flags().GetString("env")
 				environment, _ := cmd.Flags().GetString("environment")
-				appPull(providerName, app, flags["skip-confirmation"], flags["skip-import"], flags["skip-db"], flags["skip-files"], environment)
+				appPull(providerName, app, flags["skip-confirmation"], flags["skip-import"], flags["skip-db"], flags["skip-files"], flags["force-redownload"], flags["skip-sanitize"], flags["dry-run"], envName, environment)
 			},
 		}
 		// Mark custom command
@@ -147,6 +175,78 @@ ddev pull %s --skip-files -y`, subCommandName, subCommandName, subCommandName),
 		subCommand.Flags().Bool("skip-db", false, "Skip pulling database archive")
 		subCommand.Flags().Bool("skip-files", false, "Skip pulling file archive")
 		subCommand.Flags().Bool("skip-import", false, "Downloads file and/or database archives, but does not import them")
+		subCommand.Flags().Bool("skip-sanitize", false, "Skip the db_sanitize steps of the provider after importing the database")
+		subCommand.Flags().Bool("dry-run", false, "Show the commands, download directory, databases and upload directories of the pull without running anything")
+		subCommand.Flags().Bool("force-redownload", false, "Discard downloads kept from an earlier, interrupted pull and download everything again")
+		addEventsFlag(subCommand)
+		subCommand.Flags().String("env", "", "Pull from the named environment of the provider")
+		_ = subCommand.RegisterFlagCompletionFunc("env", providerEnvironmentCompletion(subCommandName))
 		subCommand.Flags().String("environment", "", "Add/override environment variables during pull. Commas and equals are not allowed in the names or values.")
 	}
 }
+
+// renderPullPlan formats a pull plan for the terminal
+func renderPullPlan(plan *ddevapp.PullPlan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pull plan for provider %s (nothing was run)\n", plan.Provider)
+	if plan.ProviderEnvironment != "" {
+		fmt.Fprintf(&b, "Provider environment: %s\n", plan.ProviderEnvironment)
+	}
+	fmt.Fprintf(&b, "Download directory: %s\n", plan.DownloadDir)
+	if len(plan.Environment) > 0 {
+		keys := make([]string, 0, len(plan.Environment))
+		for k := range plan.Environment {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("Environment:\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "  %s=%s\n", k, plan.Environment[k])
+		}
+	}
+	b.WriteString("Steps:\n")
+	if len(plan.Steps) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for i, step := range plan.Steps {
+		where := ""
+		if step.Service != "" {
+			where = fmt.Sprintf(" [%s]", step.Service)
+		}
+		fmt.Fprintf(&b, "  %d. %s%s\n", i+1, step.Stage, where)
+		if step.Command != "" {
+			fmt.Fprintf(&b, "     %s\n", step.Command)
+		}
+		if step.Description != "" {
+			fmt.Fprintf(&b, "     %s\n", step.Description)
+		}
+	}
+	switch {
+	case len(plan.Databases) > 0:
+		fmt.Fprintf(&b, "Databases that will be overwritten: %s\n", strings.Join(plan.Databases, ", "))
+	case plan.DatabasesNote != "":
+		fmt.Fprintf(&b, "Databases that will be overwritten: %s\n", plan.DatabasesNote)
+	}
+	switch {
+	case len(plan.UploadDirs) > 0:
+		fmt.Fprintf(&b, "Upload directories that will be overwritten: %s\n", strings.Join(plan.UploadDirs, ", "))
+	case plan.UploadDirsNote != "":
+		fmt.Fprintf(&b, "Upload directories that will be overwritten: %s\n", plan.UploadDirsNote)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// providerEnvironmentCompletion completes the --env flag with the environments of the provider
+func providerEnvironmentCompletion(providerName string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		app, err := ddevapp.GetActiveApp("")
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		provider, err := app.GetProvider(providerName)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return provider.GetEnvironmentNames(), cobra.ShellCompDirectiveNoFileComp
+	}
+}
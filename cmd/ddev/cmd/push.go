@@ -23,6 +23,7 @@ var PushCmd = &cobra.Command{
 ddev push pantheon -y
 ddev push upsun --skip-files -y
 ddev push acquia --skip-db -y
+ddev push acquia --env stage
 ddev push upsun --environment="PLATFORM_ENVIRONMENT=main,UPSUN_CLI_TOKEN=abcdeyourtoken"
 ddev push pantheon --environment="DDEV_PANTHEON_ENVIRONMENT=dev,TERMINUS_MACHINE_TOKEN=abcdeyourtoken"
 ddev push lagoon --environment="LAGOON_PROJECT=your-project,LAGOON_ENVIRONMENT=main"
@@ -34,11 +35,19 @@ ddev push lagoon --environment="LAGOON_PROJECT=your-project,LAGOON_ENVIRONMENT=m
 }
 
 // appPush does the work of push
-func appPush(providerType string, app *ddevapp.DdevApp, skipConfirmation bool, skipImportArg bool, skipDBArg bool, skipFilesArg bool, env string) {
+func appPush(providerType string, app *ddevapp.DdevApp, skipConfirmation bool, skipImportArg bool, skipDBArg bool, skipFilesArg bool, allowProtected bool, envName string, env string) {
 	provider, err := app.GetProvider(providerType)
 	if err != nil {
 		util.Failed("Failed to get provider: %v", err)
 	}
+	if err = provider.SelectEnvironment(envName); err != nil {
+		util.Failed("Failed to select provider environment: %v", err)
+	}
+	provider.AllowProtected = allowProtected
+	// Refuse before asking for confirmation
+	if err = provider.CheckPushAllowed(); err != nil {
+		util.Failed("Push refused: %v", err)
+	}
 
 	if env != "" {
 		// Add or override the command-line provided environment variables
@@ -122,16 +131,17 @@ ddev push %s --skip-files -y`, subCommandName, subCommandName, subCommandName),
 				}
 				app.ProviderInstance = p
 
-				flags := map[string]bool{"skip-confirmation": false, "skip-db": false, "skip-files": false, "skip-import": false}
+				flags := map[string]bool{"skip-confirmation": false, "skip-db": false, "skip-files": false, "skip-import": false, "allow-protected": false}
 				for f := range flags {
 					flags[f], err = cmd.Flags().GetBool(f)
 					if err != nil {
 						util.Failed("Failed to get flag %s: %v", f, err)
 					}
 				}
+				envName, _ := cmd.Flags().GetString("env")
 				environment, _ := cmd.Flags().GetString("environment")
 
-				appPush(providerName, app, flags["skip-confirmation"], flags["skip-import"], flags["skip-db"], flags["skip-files"], environment)
+				appPush(providerName, app, flags["skip-confirmation"], flags["skip-import"], flags["skip-db"], flags["skip-files"], flags["allow-protected"], envName, environment)
 			},
 		}
 		// Mark custom command
@@ -148,6 +158,9 @@ ddev push %s --skip-files -y`, subCommandName, subCommandName, subCommandName),
 		subCommand.Flags().Bool("skip-import", false, "Downloads file and/or database archives, but does not import them")
 		// This flag is irrelevant for push
 		_ = subCommand.Flags().MarkHidden("skip-import")
+		subCommand.Flags().String("env", "", "Push to the named environment of the provider")
+		_ = subCommand.RegisterFlagCompletionFunc("env", providerEnvironmentCompletion(subCommandName))
+		subCommand.Flags().Bool("allow-protected", false, "Push even if the environment is protected")
 		subCommand.Flags().String("environment", "", "Add/override environment variables during pull. Commas and equals are not allowed in the names or values.")
 	}
 }
@@ -18,8 +18,10 @@ var RestartCmd = &cobra.Command{
 	Long:              `Stops named projects and then starts them back up again.`,
 	Example: `ddev restart
 ddev restart <project1> <project2>
-ddev restart --all`,
-	PreRun: func(_ *cobra.Command, _ []string) {
+ddev restart --all
+ddev restart --events=jsonl`,
+	PreRun: func(cmd *cobra.Command, _ []string) {
+		checkEventsFlag(cmd)
 		dockerutil.EnsureDdevNetwork()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -37,9 +39,11 @@ ddev restart --all`,
 		ddevapp.RunUpgradeCheck()
 
 		noCache, _ := cmd.Flags().GetBool("no-cache")
+		rerunHooks, _ := cmd.Flags().GetBool("rerun-hooks")
 
 		for _, app := range projects {
 			app.NoCache = noCache
+			app.RerunHooks = rerunHooks
 			output.UserOut.Printf("Restarting project %s...", app.GetName())
 			err = app.Restart()
 			if err != nil {
@@ -55,6 +59,8 @@ ddev restart --all`,
 func init() {
 	RestartCmd.Flags().BoolP("skip-confirmation", "y", false, "Skip any confirmation steps")
 	RestartCmd.Flags().BoolP("no-cache", "", false, "Rebuild custom Docker image layers without cache")
+	RestartCmd.Flags().Bool("rerun-hooks", false, "Run hook tasks even if their inputs or the project configuration didn't change")
 	RestartCmd.Flags().BoolVarP(&restartAll, "all", "a", false, "Restart all projects")
+	addEventsFlag(RestartCmd)
 	RootCmd.AddCommand(RestartCmd)
 }
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// DdevShareStatusCommand contains the "ddev share status" command
+var DdevShareStatusCommand = &cobra.Command{
+	ValidArgsFunction: ddevapp.GetProjectNamesFunc("all", 0),
+	Use:               "status [project]",
+	Short:             "List the shares running in the background, started with 'ddev share --detach'",
+	Example: `ddev share status
+ddev share status myproject
+ddev share status --all`,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		apps, err := getRequestedProjects(args, all)
+		if err != nil {
+			util.Failed("Failed to get project(s): %v", err)
+		}
+
+		var out strings.Builder
+		shares := map[string][]ddevapp.ShareSession{}
+		for _, app := range apps {
+			sessions, err := app.GetShareSessions()
+			if err != nil {
+				util.Failed("Failed to get share sessions of %s: %v", app.Name, err)
+			}
+			if len(sessions) == 0 {
+				continue
+			}
+			shares[app.Name] = sessions
+			for _, s := range sessions {
+				_, _ = fmt.Fprintf(&out, "%s: %s share %s (pid %d, started %s ago)\n", app.Name, s.Provider, s.URL, s.PID, time.Since(s.Started).Round(time.Second))
+			}
+		}
+		if len(shares) == 0 {
+			out.WriteString("No shares running in the background\n")
+		}
+		output.UserOut.WithField("raw", shares).Print(out.String())
+	},
+}
+
+func init() {
+	DdevShareStatusCommand.Flags().BoolP("all", "a", false, "List the shares of all projects")
+	DdevShareCommand.AddCommand(DdevShareStatusCommand)
+}
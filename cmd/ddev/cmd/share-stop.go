@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// DdevShareStopCommand contains the "ddev share stop" command
+var DdevShareStopCommand = &cobra.Command{
+	ValidArgsFunction: ddevapp.GetProjectNamesFunc("all", 0),
+	Use:               "stop [project]",
+	Short:             "Stop the shares running in the background, started with 'ddev share --detach'",
+	Example: `ddev share stop
+ddev share stop --provider=cloudflared
+ddev share stop myproject
+ddev share stop --all`,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		provider, _ := cmd.Flags().GetString("provider")
+		apps, err := getRequestedProjects(args, all)
+		if err != nil {
+			util.Failed("Failed to get project(s): %v", err)
+		}
+
+		stoppedAny := false
+		for _, app := range apps {
+			stopped, err := app.StopShareSessions(provider)
+			for _, s := range stopped {
+				util.Success("Stopped %s share %s of %s", s.Provider, s.URL, app.Name)
+			}
+			if err != nil {
+				util.Failed("Failed to stop shares of %s: %v", app.Name, err)
+			}
+			if len(stopped) == 0 {
+				continue
+			}
+			stoppedAny = true
+			if status, _ := app.SiteStatus(); status == ddevapp.SiteRunning {
+				if err = app.ProcessHooks("post-share"); err != nil {
+					util.Warning("Failed to process post-share hooks: %v", err)
+				}
+			}
+		}
+		if !stoppedAny {
+			output.UserOut.Println("No shares running in the background")
+		}
+	},
+}
+
+func init() {
+	DdevShareStopCommand.Flags().BoolP("all", "a", false, "Stop the shares of all projects")
+	DdevShareStopCommand.Flags().String("provider", "", "Stop only the share of this provider")
+	DdevShareCommand.AddCommand(DdevShareStopCommand)
+}
@@ -8,12 +8,14 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ddev/ddev/pkg/ddevapp"
 	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/output"
 	"github.com/ddev/ddev/pkg/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -26,12 +28,18 @@ var DdevShareCommand = &cobra.Command{
 	Short:             "Share project on the internet via tunnel provider (ngrok, cloudflared, or custom).",
 	Long: `Share your project on the internet using a tunnel provider.
 Built-in providers: ngrok (default), cloudflared.
-Custom providers can be added to .ddev/share-providers/`,
+Custom providers can be added to .ddev/share-providers/
+
+With --detach, the share runs in the background until 'ddev share stop'
+or 'ddev stop', see 'ddev share status'.`,
 	Example: `ddev share
 ddev share --provider=cloudflared
 ddev share --provider-args "--basic-auth username:pass1234"
 ddev share --provider=cloudflared --provider-args="--tunnel my-tunnel --hostname mysite.example.com"
-ddev share myproject`,
+ddev share myproject
+ddev share --detach
+ddev share status
+ddev share stop`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) > 1 {
 			util.Failed("Too many arguments provided. Please use 'ddev share' or 'ddev share [projectname]'")
@@ -46,42 +54,13 @@ ddev share myproject`,
 			util.Failed("Failed to start app %s: %v", app.Name, err)
 		}
 
-		// Determine which provider to use: flag > project config > global config > default
-		providerName := "ngrok" // default
-		if globalconfig.DdevGlobalConfig.ShareDefaultProvider != "" {
-			providerName = globalconfig.DdevGlobalConfig.ShareDefaultProvider
-		}
-		if app.ShareDefaultProvider != "" {
-			providerName = app.ShareDefaultProvider
-		}
-		if cmd.Flags().Changed("provider") {
-			providerName, err = cmd.Flags().GetString("provider")
-			if err != nil {
-				util.Failed("Unable to get --provider flag: %v", err)
-			}
-		}
-
-		// Get provider script path
-		scriptPath, err := app.GetShareProviderScript(providerName)
-		if err != nil {
-			util.Error("Failed to find share provider '%s': %v\n\nAvailable providers:", providerName, err)
-			if providers, listErr := app.ListShareProviders(); listErr == nil && len(providers) > 0 {
-				for _, p := range providers {
-					util.Error("  - %s", p)
-				}
-			}
-			os.Exit(1)
-		}
+		providerName, scriptPath, env := getShareProvider(cmd, app)
 
-		// Get provider args override from command line
-		var providerArgsOverride string
-		if cmd.Flags().Changed("provider-args") {
-			providerArgsOverride, _ = cmd.Flags().GetString("provider-args")
+		if detach, _ := cmd.Flags().GetBool("detach"); detach {
+			startDetachedShare(app, providerName, scriptPath, env)
+			return
 		}
 
-		// Get environment for provider
-		env := app.GetShareProviderEnvironment(providerName, providerArgsOverride)
-
 		// Create pipe to capture stdout (for URL)
 		stdoutReader, stdoutWriter, err := os.Pipe()
 		if err != nil {
@@ -163,8 +142,7 @@ ddev share myproject`,
 
 		// Validate URL
 		shareURL = strings.TrimSpace(shareURL)
-		parsedURL, err := url.Parse(shareURL)
-		if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		if !isValidShareURL(shareURL) {
 			killProcessTree(providerCmd)
 			util.Failed("Provider '%s' output invalid URL: %s", providerName, shareURL)
 		}
@@ -222,6 +200,7 @@ func init() {
 	DdevShareCommand.Flags().String("provider", "", "share provider to use (ngrok, cloudflared, or custom)")
 	_ = DdevShareCommand.RegisterFlagCompletionFunc("provider", configCompletionFunc([]string{"ngrok", "cloudflared"}))
 	DdevShareCommand.Flags().String("provider-args", "", "arguments to pass to the share provider")
+	DdevShareCommand.Flags().Bool("detach", false, "run the share in the background, see 'ddev share status' and 'ddev share stop'")
 	DdevShareCommand.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
 		if name == "ngrok-args" {
 			newName := "provider-args"
@@ -231,3 +210,152 @@ func init() {
 		return pflag.NormalizedName(name)
 	})
 }
+
+// getShareProvider returns the share provider to use for the project, its script,
+// and the environment to run it with, exiting if the provider doesn't exist
+func getShareProvider(cmd *cobra.Command, app *ddevapp.DdevApp) (string, string, []string) {
+	var err error
+	// Determine which provider to use: flag > project config > global config > default
+	providerName := "ngrok" // default
+	if globalconfig.DdevGlobalConfig.ShareDefaultProvider != "" {
+		providerName = globalconfig.DdevGlobalConfig.ShareDefaultProvider
+	}
+	if app.ShareDefaultProvider != "" {
+		providerName = app.ShareDefaultProvider
+	}
+	if cmd.Flags().Changed("provider") {
+		providerName, err = cmd.Flags().GetString("provider")
+		if err != nil {
+			util.Failed("Unable to get --provider flag: %v", err)
+		}
+	}
+
+	// Get provider script path
+	scriptPath, err := app.GetShareProviderScript(providerName)
+	if err != nil {
+		util.Error("Failed to find share provider '%s': %v\n\nAvailable providers:", providerName, err)
+		if providers, listErr := app.ListShareProviders(); listErr == nil && len(providers) > 0 {
+			for _, p := range providers {
+				util.Error("  - %s", p)
+			}
+		}
+		os.Exit(1)
+	}
+
+	// Get provider args override from command line
+	var providerArgsOverride string
+	if cmd.Flags().Changed("provider-args") {
+		providerArgsOverride, _ = cmd.Flags().GetString("provider-args")
+	}
+
+	// Get environment for provider
+	env := app.GetShareProviderEnvironment(providerName, providerArgsOverride)
+	return providerName, scriptPath, env
+}
+
+// isValidShareURL reports whether the output of a share provider is an http(s) URL
+func isValidShareURL(shareURL string) bool {
+	parsedURL, err := url.Parse(shareURL)
+	return err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https")
+}
+
+// startDetachedShare runs the share provider in the background and records the
+// share session once the provider outputs the URL. The output of the provider
+// goes to files in .ddev/.share-sessions instead of the terminal.
+func startDetachedShare(app *ddevapp.DdevApp, providerName string, scriptPath string, env []string) {
+	sessions, err := app.GetShareSessions()
+	if err != nil {
+		util.Failed("Failed to get share sessions: %v", err)
+	}
+	for _, s := range sessions {
+		if s.Provider == providerName {
+			util.Failed("Project %s is already shared with %s at %s, use 'ddev share stop' first", app.Name, providerName, s.URL)
+		}
+	}
+
+	outPath := app.GetShareSessionPath(providerName, ".out")
+	logPath := app.GetShareSessionPath(providerName, ".log")
+	if err = os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		util.Failed("Failed to create share sessions directory: %v", err)
+	}
+	outFile, err := os.Create(outPath)
+	if err != nil {
+		util.Failed("Failed to create share output file: %v", err)
+	}
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		util.Failed("Failed to create share log file: %v", err)
+	}
+
+	util.Success("Using share provider script: %s", scriptPath)
+	bashPath := util.FindBashPath()
+	if bashPath == "" {
+		util.Failed("Unable to find bash to run share provider script. Please install bash.")
+	}
+	providerCmd := exec.Command(bashPath, scriptPath)
+	providerCmd.Env = env
+	providerCmd.Stdout = outFile
+	providerCmd.Stderr = logFile
+	setDetachedAttr(providerCmd)
+
+	err = providerCmd.Start()
+	_ = outFile.Close()
+	_ = logFile.Close()
+	if err != nil {
+		util.Failed("Failed to start share provider '%s': %v", providerName, err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- providerCmd.Wait()
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	// The URL is the first line of the output of the provider
+	var shareURL string
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(60 * time.Second)
+	for waiting := true; waiting; {
+		select {
+		case <-ticker.C:
+			out, err := os.ReadFile(outPath)
+			if line, _, found := strings.Cut(string(out), "\n"); err == nil && found {
+				shareURL, waiting = strings.TrimSpace(line), false
+			}
+		case <-done:
+			util.Failed("Provider '%s' exited without outputting a URL, see %s", providerName, logPath)
+		case <-sigChan:
+			killProcessTree(providerCmd)
+			util.Failed("Interrupted before tunnel URL was established")
+		case <-timeout:
+			killProcessTree(providerCmd)
+			util.Failed("Provider '%s' did not output a URL within 60 seconds, see %s", providerName, logPath)
+		}
+	}
+	if !isValidShareURL(shareURL) {
+		killProcessTree(providerCmd)
+		util.Failed("Provider '%s' output invalid URL: %s", providerName, shareURL)
+	}
+
+	session := ddevapp.ShareSession{
+		Provider: providerName,
+		PID:      providerCmd.Process.Pid,
+		URL:      shareURL,
+		Started:  time.Now(),
+	}
+	if err = app.SaveShareSession(session); err != nil {
+		killProcessTree(providerCmd)
+		util.Failed("Failed to record share session: %v", err)
+	}
+
+	// Set DDEV_SHARE_URL environment variable for hooks
+	_ = os.Setenv("DDEV_SHARE_URL", shareURL)
+	if err = app.ProcessHooks("pre-share"); err != nil {
+		util.Warning("Failed to process pre-share hooks: %v", err)
+	}
+
+	output.UserOut.WithField("raw", session).Printf("Shared %s in the background with %s at %s\nStop it with 'ddev share stop'", app.Name, providerName, shareURL)
+}
@@ -21,3 +21,10 @@ func killProcessTree(cmd *exec.Cmd) {
 		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 	}
 }
+
+// setDetachedAttr starts the child process in its own session, so that it keeps
+// running after ddev exits and its terminal closes. Its PID is also its process
+// group ID, which lets `ddev share stop` address the entire group.
+func setDetachedAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -5,6 +5,9 @@ package cmd
 import (
 	"os/exec"
 	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/windows"
 )
 
 // setProcessGroupAttr is a no-op on Windows. taskkill with /T traverses the
@@ -23,3 +26,9 @@ func killProcessTree(cmd *exec.Cmd) {
 		}
 	}
 }
+
+// setDetachedAttr starts the child process without a console and in a new
+// process group, so that it keeps running after ddev exits.
+func setDetachedAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS}
+}
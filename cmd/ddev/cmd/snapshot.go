@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/ddev/ddev/pkg/ddevapp"
 	"github.com/ddev/ddev/pkg/globalconfig"
@@ -17,9 +18,13 @@ import (
 
 var snapshotAll bool
 var snapshotCleanup bool
+var snapshotIncremental bool
+var snapshotIncrementalBase string
 var snapshotList bool
+var snapshotMessage string
 var snapshotName string
 var snapshotRestoreLatest bool
+var snapshotRestoreMigrate bool
 
 // noConfirm: If true, --yes, we won't stop and prompt before each deletion
 var snapshotCleanupNoConfirm bool
@@ -32,10 +37,14 @@ var DdevSnapshotCommand = &cobra.Command{
 	Long:              `Uses mariabackup or xtrabackup command to create a database snapshot in the .ddev/db_snapshots folder. These are compatible with server backups using the same tools and can be restored with "ddev snapshot restore".`,
 	Example: `ddev snapshot
 ddev snapshot --name some_descriptive_name
+ddev snapshot --name before_migration --message "Before running the migrations"
+ddev snapshot --incremental
+ddev snapshot --incremental-base some_descriptive_name
 ddev snapshot --cleanup
 ddev snapshot --cleanup --name my_snapshot_name
 ddev snapshot --cleanup -y
 ddev snapshot --list
+ddev snapshot --list --json-output
 ddev snapshot --all`,
 	Run: func(_ *cobra.Command, args []string) {
 		apps, err := getRequestedProjects(args, snapshotAll)
@@ -72,7 +81,7 @@ func listSnapshots(apps []*ddevapp.DdevApp) {
 	if len(apps) > 1 {
 		columns = append(columns, "Project")
 	}
-	columns = append(columns, "Snapshot", "Created", "Size", "DB Version")
+	columns = append(columns, "Snapshot", "Created", "Size", "DB Version", "Base", "Git", "Databases", "Description")
 
 	if !globalconfig.DdevGlobalConfig.SimpleFormatting {
 		var colConfig []table.ColumnConfig
@@ -93,18 +102,18 @@ func listSnapshots(apps []*ddevapp.DdevApp) {
 			allSnapshots[app.GetName()] = snapshots
 			if len(snapshots) > 0 {
 				for _, snapshot := range snapshots {
+					row := table.Row{snapshot.Name, snapshot.Created.Format("2006-01-02 15:04"), util.FormatBytes(snapshot.Size), snapshot.DBVersion, snapshot.Base, formatSnapshotGit(snapshot), formatSnapshotDatabases(snapshot), snapshot.Description}
 					if len(apps) > 1 {
-						t.AppendRow(table.Row{app.GetName(), snapshot.Name, snapshot.Created.Format("2006-01-02"), util.FormatBytes(snapshot.Size), snapshot.DBVersion})
-					} else {
-						t.AppendRow(table.Row{snapshot.Name, snapshot.Created.Format("2006-01-02"), util.FormatBytes(snapshot.Size), snapshot.DBVersion})
+						row = append(table.Row{app.GetName()}, row...)
 					}
+					t.AppendRow(row)
 				}
 			} else {
+				row := table.Row{text.Italic.Sprint("No snapshots"), "", "", "", "", "", "", ""}
 				if len(apps) > 1 {
-					t.AppendRow(table.Row{app.GetName(), text.Italic.Sprint("No snapshots"), "", "", ""})
-				} else {
-					t.AppendRow(table.Row{text.Italic.Sprint("No snapshots"), "", "", ""})
+					row = append(table.Row{app.GetName()}, row...)
 				}
+				t.AppendRow(row)
 			}
 		}
 	}
@@ -113,6 +122,27 @@ func listSnapshots(apps []*ddevapp.DdevApp) {
 	output.UserOut.WithField("raw", allSnapshots).Println(out.String())
 }
 
+// formatSnapshotGit returns the git branch and short commit a snapshot was taken on
+func formatSnapshotGit(snapshot ddevapp.Snapshot) string {
+	commit := snapshot.GitCommit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	if snapshot.GitBranch != "" && commit != "" {
+		return snapshot.GitBranch + "@" + commit
+	}
+	return commit
+}
+
+// formatSnapshotDatabases returns the databases in a snapshot with their table counts
+func formatSnapshotDatabases(snapshot ddevapp.Snapshot) string {
+	var databases []string
+	for _, db := range snapshot.Databases {
+		databases = append(databases, fmt.Sprintf("%s (%d tables)", db.Name, db.Tables))
+	}
+	return strings.Join(databases, "\n")
+}
+
 func createAppSnapshot(app *ddevapp.DdevApp) {
 	// If the database is omitted, do not snapshot
 	omittedContainers := app.GetOmittedContainers()
@@ -131,7 +161,13 @@ func createAppSnapshot(app *ddevapp.DdevApp) {
 	}
 	// If there is an error from Snapshot, show a warning message
 	// allow the command to continue, there may be other snapshots needed
-	if snapshotNameOutput, err := app.Snapshot(snapshotName); err != nil {
+	opts := ddevapp.SnapshotOpts{
+		Name:        snapshotName,
+		Description: snapshotMessage,
+		Incremental: snapshotIncremental,
+		Base:        snapshotIncrementalBase,
+	}
+	if snapshotNameOutput, err := app.CreateSnapshot(opts); err != nil {
 		errorMsg := util.ColorizeText("Failed to snapshot %s: %v", "red")
 		util.Warning(errorMsg, app.GetName(), err)
 	} else {
@@ -189,5 +225,8 @@ func init() {
 	DdevSnapshotCommand.Flags().BoolVarP(&snapshotCleanup, "cleanup", "C", false, "Cleanup snapshots")
 	DdevSnapshotCommand.Flags().BoolVarP(&snapshotCleanupNoConfirm, "yes", "y", false, "Yes - skip confirmation prompt")
 	DdevSnapshotCommand.Flags().StringVarP(&snapshotName, "name", "n", "", "provide a name for the snapshot")
+	DdevSnapshotCommand.Flags().StringVarP(&snapshotMessage, "message", "m", "", "provide a description stored with the snapshot")
+	DdevSnapshotCommand.Flags().BoolVarP(&snapshotIncremental, "incremental", "i", false, "Create an incremental snapshot based on the latest snapshot (MariaDB and MySQL only)")
+	DdevSnapshotCommand.Flags().StringVar(&snapshotIncrementalBase, "incremental-base", "", "Create an incremental snapshot based on the named snapshot (MariaDB and MySQL only)")
 	RootCmd.AddCommand(DdevSnapshotCommand)
 }
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/nodeps"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var snapshotExportIncludeFiles bool
+
+// DdevSnapshotExportCommand handles ddev snapshot export
+var DdevSnapshotExportCommand = &cobra.Command{
+	Use:   "export [snapshot_name] [archive]",
+	Short: "Export a database snapshot into a portable archive.",
+	Long: `Exports a snapshot from the .ddev/db_snapshots folder, the snapshots it's based on, and optionally the project's upload_dirs into a single checksummed archive that can be imported into the same project on another machine with "ddev snapshot import".
+The archive is gzipped if its name ends with .gz or .tgz, other names get a plain tar archive, and .xz and .bz2 are not supported. It defaults to <snapshot_name>.tar.gz in the current directory.`,
+	Example: `ddev snapshot export my_snapshot_name
+ddev snapshot export my_snapshot_name /tmp/my_snapshot_name.tar.gz
+ddev snapshot export my_snapshot_name --include-files`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(_ *cobra.Command, args []string) {
+		app, err := ddevapp.GetActiveApp("")
+		if err != nil {
+			util.Failed("Failed to find active project: %v", err)
+		}
+		if nodeps.ArrayContainsString(app.OmitContainers, "db") {
+			util.Failed("Snapshots are not available when database container is omitted")
+		}
+
+		snapshotName := args[0]
+		archivePath := snapshotName + ".tar.gz"
+		if len(args) == 2 {
+			archivePath = args[1]
+		}
+
+		if err = app.ExportSnapshot(snapshotName, archivePath, snapshotExportIncludeFiles); err != nil {
+			util.Failed("Failed to export snapshot %s of project %s: %v", snapshotName, app.GetName(), err)
+		}
+		util.Success("Exported snapshot %s to %s", snapshotName, archivePath)
+	},
+}
+
+func init() {
+	DdevSnapshotExportCommand.Flags().BoolVar(&snapshotExportIncludeFiles, "include-files", false, "Include the contents of the project's upload_dirs")
+	DdevSnapshotCommand.AddCommand(DdevSnapshotExportCommand)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/nodeps"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var snapshotImportIncludeFiles bool
+var snapshotImportRestore bool
+
+// DdevSnapshotImportCommand handles ddev snapshot import
+var DdevSnapshotImportCommand = &cobra.Command{
+	Use:   "import [archive]",
+	Short: "Import a database snapshot from an archive created by ddev snapshot export.",
+	Long: `Verifies the checksums of an archive created by "ddev snapshot export" and imports its snapshots into the .ddev/db_snapshots folder, where they can be restored with "ddev snapshot restore".
+The snapshot must have been created with the same database type and version as the project uses.`,
+	Example: `ddev snapshot import my_snapshot_name.tar.gz
+ddev snapshot import my_snapshot_name.tar.gz --restore
+ddev snapshot import my_snapshot_name.tar.gz --include-files --yes`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := ddevapp.GetActiveApp("")
+		if err != nil {
+			util.Failed("Failed to find active project: %v", err)
+		}
+		if nodeps.ArrayContainsString(app.OmitContainers, "db") {
+			util.Failed("Snapshots are not available when database container is omitted")
+		}
+
+		archivePath := args[0]
+		if snapshotImportIncludeFiles {
+			yes, _ := cmd.Flags().GetBool("yes")
+			if !yes && !util.ConfirmTo("Files in the project's upload_dirs will be overwritten by the files in the archive. Are you sure you want to continue?", false) {
+				if globalconfig.IsInteractive() {
+					util.Failed("User cancelled operation. Terminating without importing the snapshot.")
+				} else {
+					util.Failed("Unable to continue because DDEV_NONINTERACTIVE=true, use `--yes` flag to proceed.")
+				}
+			}
+		}
+
+		snapshotName, err := app.ImportSnapshot(archivePath, snapshotImportIncludeFiles)
+		if err != nil {
+			util.Failed("Failed to import snapshot archive %s into project %s: %v", archivePath, app.GetName(), err)
+		}
+		util.Success("Imported snapshot %s into project %s", snapshotName, app.GetName())
+
+		if snapshotImportRestore {
+			if err = app.StartAppIfNotRunning(); err != nil {
+				util.Failed("Failed to start app %s: %v", app.GetName(), err)
+			}
+			if err = app.RestoreSnapshot(snapshotName); err != nil {
+				util.Failed("Failed to restore snapshot %s for project %s: %v", snapshotName, app.GetName(), err)
+			}
+		}
+	},
+}
+
+func init() {
+	DdevSnapshotImportCommand.Flags().BoolVar(&snapshotImportIncludeFiles, "include-files", false, "Also import the archive's files into the project's upload_dirs")
+	DdevSnapshotImportCommand.Flags().BoolVar(&snapshotImportRestore, "restore", false, "Restore the snapshot after importing it")
+	DdevSnapshotImportCommand.Flags().BoolP("yes", "y", false, "Yes - skip confirmation prompt")
+	DdevSnapshotCommand.AddCommand(DdevSnapshotImportCommand)
+}
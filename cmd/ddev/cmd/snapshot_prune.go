@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var snapshotPruneDryRun bool
+
+// DdevSnapshotPruneCommand handles ddev snapshot prune
+var DdevSnapshotPruneCommand = &cobra.Command{
+	ValidArgsFunction: ddevapp.GetProjectNamesFunc("all", 0),
+	Use:               "prune [projectname projectname...]",
+	Short:             "Delete the database snapshots that aren't kept by snapshot_retention.",
+	Long: `Deletes the database snapshots in the .ddev/db_snapshots folder that aren't kept by the snapshot_retention rules in the project or global configuration.
+Snapshots are also pruned automatically after every new snapshot.`,
+	Example: `ddev snapshot prune
+ddev snapshot prune --dry-run
+ddev snapshot prune --all`,
+	Run: func(_ *cobra.Command, args []string) {
+		apps, err := getRequestedProjects(args, snapshotAll)
+		if err != nil {
+			util.Failed("Unable to get project(s) %v: %v", args, err)
+		}
+		if len(apps) > 0 {
+			instrumentationApp = apps[0]
+		}
+
+		for _, app := range apps {
+			if app.GetSnapshotRetention().IsEmpty() {
+				util.Warning("No snapshot_retention is configured for project %s, not pruning snapshots", app.GetName())
+				continue
+			}
+			pruned, reclaimed, err := app.PruneSnapshots(snapshotPruneDryRun)
+			if err != nil {
+				util.Failed("Failed to prune snapshots of project %s: %v", app.GetName(), err)
+			}
+			switch {
+			case len(pruned) == 0:
+				util.Success("No snapshots of project %s need to be pruned", app.GetName())
+			case snapshotPruneDryRun:
+				for _, snapshot := range pruned {
+					util.Success("Would delete snapshot '%s' (%s)", snapshot.Name, util.FormatBytes(snapshot.Size))
+				}
+				util.Success("Pruning would delete %d snapshot(s) of project %s and reclaim %s", len(pruned), app.GetName(), util.FormatBytes(reclaimed))
+			default:
+				util.Success("Pruned %d snapshot(s) of project %s, reclaimed %s", len(pruned), app.GetName(), util.FormatBytes(reclaimed))
+			}
+		}
+	},
+}
+
+func init() {
+	DdevSnapshotPruneCommand.Flags().BoolVarP(&snapshotAll, "all", "a", false, "Prune snapshots of all projects")
+	DdevSnapshotPruneCommand.Flags().BoolVar(&snapshotPruneDryRun, "dry-run", false, "Show which snapshots would be deleted without deleting them")
+	DdevSnapshotCommand.AddCommand(DdevSnapshotPruneCommand)
+}
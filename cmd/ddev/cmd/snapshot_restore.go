@@ -13,7 +13,8 @@ var DdevSnapshotRestoreCommand = &cobra.Command{
 	Use:   "restore [snapshot_name]",
 	Short: "Restore a project's database to the provided snapshot version.",
 	Long: `Uses mariabackup command to restore a project database to a particular snapshot from the .ddev/db_snapshots folder.
-Example: "ddev snapshot restore d8git_20180717203845"`,
+Example: "ddev snapshot restore d8git_20180717203845"
+With --migrate, a snapshot taken with a different MariaDB or MySQL version is restored into a temporary database server of its original version, dumped, and imported into the project's current database.`,
 	Run: func(_ *cobra.Command, args []string) {
 		var snapshotName string
 
@@ -66,7 +67,11 @@ Example: "ddev snapshot restore d8git_20180717203845"`,
 
 		// Normalize the snapshot name
 
-		if err := app.RestoreSnapshot(snapshotName); err != nil {
+		restore := app.RestoreSnapshot
+		if snapshotRestoreMigrate {
+			restore = app.RestoreSnapshotWithMigration
+		}
+		if err := restore(snapshotName); err != nil {
 			util.Failed("Failed to restore snapshot %s for project %s: %v", snapshotName, app.GetName(), err)
 		}
 	},
@@ -74,5 +79,6 @@ Example: "ddev snapshot restore d8git_20180717203845"`,
 
 func init() {
 	DdevSnapshotRestoreCommand.Flags().BoolVarP(&snapshotRestoreLatest, "latest", "", false, "use latest snapshot")
+	DdevSnapshotRestoreCommand.Flags().BoolVar(&snapshotRestoreMigrate, "migrate", false, "Convert a snapshot taken with a different MariaDB/MySQL version to the current version")
 	DdevSnapshotCommand.AddCommand(DdevSnapshotRestoreCommand)
 }
@@ -31,8 +31,11 @@ project directory to start that project, or you can start stopped projects in
 any directory by running 'ddev start projectname [projectname ...]'`,
 	Example: `ddev start
 ddev start <project1> <project2>
-ddev start --all`,
-	PreRun: func(_ *cobra.Command, _ []string) {
+ddev start --all
+ddev start --rerun-hooks
+ddev start --events=jsonl`,
+	PreRun: func(cmd *cobra.Command, _ []string) {
+		checkEventsFlag(cmd)
 		dockerutil.EnsureDdevNetwork()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -127,12 +130,14 @@ ddev start --all`,
 		}
 
 		noCache, _ := cmd.Flags().GetBool("no-cache")
+		rerunHooks, _ := cmd.Flags().GetBool("rerun-hooks")
 
 		for _, project := range projects {
 			if err := ddevapp.CheckForMissingProjectFiles(project); err != nil {
 				util.Failed("Failed to start %s: %v", project.GetName(), err)
 			}
 			project.NoCache = noCache
+			project.RerunHooks = rerunHooks
 
 			output.UserOut.Printf("Starting %s...", project.GetName())
 
@@ -157,6 +162,10 @@ ddev start --all`,
 }
 
 func emitReachProjectMessage(project *ddevapp.DdevApp) {
+	if output.EventsEnabled() {
+		_, _, urls := project.GetAllURLs()
+		output.EmitEvent(output.Event{Type: output.EventURLs, Project: project.GetName(), URLs: urls})
+	}
 	if project.GetPrimaryURL() != "" {
 		util.Success("Your project can be reached at %s\nSee 'ddev describe' for alternate URLs.", project.GetPrimaryURL())
 	}
@@ -166,8 +175,10 @@ func init() {
 	StartCmd.Flags().BoolVarP(&startAll, "all", "a", false, "Start all projects")
 	StartCmd.Flags().BoolP("skip-confirmation", "y", false, "Skip any confirmation steps")
 	StartCmd.Flags().BoolP("no-cache", "", false, "Rebuild custom Docker image layers without cache")
+	StartCmd.Flags().Bool("rerun-hooks", false, "Run hook tasks even if their inputs or the project configuration didn't change")
 	StartCmd.Flags().String("profiles", "", "Start optional comma-separated docker compose profiles")
 	StartCmd.Flags().BoolP("select", "s", false, "Interactively select a project to start")
+	addEventsFlag(StartCmd)
 	err := StartCmd.Flags().MarkHidden("select")
 	if err != nil {
 		util.Warning("Unexpected error marking flag as hidden: %v", err)
@@ -49,8 +49,10 @@ To snapshot the database on stop, use "ddev stop --snapshot"; A snapshot is auto
 ddev stop proj1 proj2 proj3
 ddev stop --all
 ddev stop --all --stop-ssh-agent
-ddev stop --remove-data`,
+ddev stop --remove-data
+ddev stop --events=jsonl`,
 	Run: func(cmd *cobra.Command, args []string) {
+		checkEventsFlag(cmd)
 		if createSnapshot && stopOmitSnapshot {
 			util.Failed("Illegal option combination: --snapshot and --omit-snapshot:")
 		}
@@ -155,6 +157,7 @@ func init() {
 	DdevStopCmd.Flags().BoolVarP(&stopAll, "all", "a", false, "Stop and remove all running or container-stopped projects and remove from global projects list")
 	DdevStopCmd.Flags().BoolVarP(&stopSSHAgent, "stop-ssh-agent", "", false, "Stop the ddev-ssh-agent container")
 	DdevStopCmd.Flags().BoolVarP(&unlist, "unlist", "U", false, "Remove the project from global project list, it won't show in ddev list until started again")
+	addEventsFlag(DdevStopCmd)
 
 	RootCmd.AddCommand(DdevStopCmd)
 }
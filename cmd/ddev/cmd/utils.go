@@ -8,6 +8,8 @@ import (
 
 	"github.com/ddev/ddev/pkg/ddevapp"
 	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -158,3 +160,17 @@ func prettyCmd(cmd []string) string {
 	}
 	return strings.Join(out, " ")
 }
+
+// addEventsFlag adds the --events flag for machine-readable progress events
+func addEventsFlag(cmd *cobra.Command) {
+	cmd.Flags().String("events", "", fmt.Sprintf("Write machine-readable progress events to stdout in the given format (%s), other output goes to stderr", output.EventsFormatJSONL))
+	_ = cmd.RegisterFlagCompletionFunc("events", configCompletionFunc([]string{output.EventsFormatJSONL}))
+}
+
+// checkEventsFlag fails if the --events flag has an unsupported format
+func checkEventsFlag(cmd *cobra.Command) {
+	format, _ := cmd.Flags().GetString("events")
+	if err := output.ValidateEventsFormat(format); err != nil {
+		util.Failed("%v", err)
+	}
+}
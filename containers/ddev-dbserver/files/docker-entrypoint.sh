@@ -40,31 +40,53 @@ function serverwait {
 if [ ! -d /mnt/snapshots ]; then
   ln -sf /mnt/ddev_config/ddev_snapshots /mnt/snapshots
 fi
+# Decompress and extract a snapshot stream into the current directory
+function extract_snapshot {
+  local snapshot_file=$1
+  case "${snapshot_file##*.}" in
+    zst)
+      # Only use zstdmt if multithreading is supported (zstd 1.2.0+).
+      "$(command -v zstdmt 2>/dev/null || echo zstd)" -dc --quiet "${snapshot_file}" | ${STREAMTOOL} -x
+      ;;
+    gz)
+      gunzip -c "${snapshot_file}" | ${STREAMTOOL} -x
+      ;;
+    *)
+      echo "Unknown snapshot compression: .${snapshot_file##*.}"
+      exit 101
+      ;;
+  esac
+}
+
+# Incremental snapshots are passed after the base snapshot, oldest first,
+# and are applied to the base snapshot in that order.
+incremental_targets=()
 # If we have a restore_snapshot arg, get the snapshot file/directory
 # otherwise, fail and abort startup
-if [ $# = "2" ] && [ "${1:-}" = "restore_snapshot" ] ; then
+if [ $# -ge 2 ] && [ "${1:-}" = "restore_snapshot" ] ; then
   snapshot_basename=${2:-nothingthere}
   snapshot="/mnt/snapshots/${snapshot_basename}"
   # If a compressed snapshot file is passed in, decompress and extract stream
   if [ -f "$snapshot" ]; then
     echo "Restoring from snapshot file $snapshot"
-    ext="${snapshot_basename##*.}"
     target="/var/tmp/${snapshot_basename}"
     mkdir -p "${target}"
     cd "${target}"
-    case "$ext" in
-      zst)
-        # Only use zstdmt if multithreading is supported (zstd 1.2.0+).
-        "$(command -v zstdmt 2>/dev/null || echo zstd)" -dc --quiet "${snapshot}" | ${STREAMTOOL} -x
-        ;;
-      gz)
-        gunzip -c "${snapshot}" | ${STREAMTOOL} -x
-        ;;
-      *)
-        echo "Unknown snapshot compression: .$ext"
+    extract_snapshot "${snapshot}"
+    shift 2
+    for incremental_basename in "$@"; do
+      incremental="/mnt/snapshots/${incremental_basename}"
+      if [ ! -f "${incremental}" ]; then
+        echo "$incremental does not exist, not attempting restore of snapshot"
         exit 101
-        ;;
-    esac
+      fi
+      echo "Applying incremental snapshot file $incremental"
+      incremental_target="/var/tmp/${incremental_basename}"
+      mkdir -p "${incremental_target}"
+      cd "${incremental_target}"
+      extract_snapshot "${incremental}"
+      incremental_targets+=("${incremental_target}")
+    done
     rm -rf ${DATADIR}/*
   # Otherwise use it as is from the directory
   elif [ -d "$snapshot" ] ; then
@@ -187,7 +209,22 @@ if [ ! -f "${DATADIR}/db_mariadb_version.txt" ]; then
     name=$(basename $target)
 
     rm -rf ${DATADIR}/* ${DATADIR}/.[a-z]*
-    ${BACKUPTOOL} --datadir=${DATADIR} --prepare --skip-innodb-use-native-aio --target-dir "$target" --user=root --password=root 2>&1 | tee "/var/log/mariabackup_prepare_$name.log"
+    # When incrementals follow, only the redo log may be applied to the base and
+    # all but the last incremental, otherwise they can't be merged.
+    incremental_count=${#incremental_targets[@]}
+    prepare_opts=""
+    if [ "${incremental_count}" -gt 0 ]; then
+      prepare_opts="--apply-log-only"
+    fi
+    ${BACKUPTOOL} --datadir=${DATADIR} --prepare ${prepare_opts} --skip-innodb-use-native-aio --target-dir "$target" --user=root --password=root 2>&1 | tee "/var/log/mariabackup_prepare_$name.log"
+    for (( i=0; i<incremental_count; i++ )); do
+      prepare_opts="--apply-log-only"
+      if [ $i -eq $(( incremental_count - 1 )) ]; then
+        prepare_opts=""
+      fi
+      incremental_target=${incremental_targets[$i]}
+      ${BACKUPTOOL} --datadir=${DATADIR} --prepare ${prepare_opts} --skip-innodb-use-native-aio --target-dir "$target" --incremental-dir "${incremental_target}" --user=root --password=root 2>&1 | tee "/var/log/mariabackup_prepare_$(basename ${incremental_target}).log"
+    done
     ${BACKUPTOOL} --datadir=${DATADIR} --copy-back --skip-innodb-use-native-aio --force-non-empty-directories --target-dir "$target" --user=root --password=root 2>&1 | tee "/var/log/mariabackup_copy_back_$name.log"
     echo ${server_db_version} >${DATADIR}/db_mariadb_version.txt
     echo "Database initialized from ${target}"
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 	"github.com/ddev/ddev/pkg/fileutil"
 	"github.com/ddev/ddev/pkg/nodeps"
 	"github.com/ddev/ddev/pkg/util"
+	"github.com/otiai10/copy"
 	"github.com/ulikunitz/xz"
 )
 
@@ -544,10 +546,22 @@ func Tar(src string, tarballFilePath string, exclusion string) error {
 // and a cleanup function.
 // It's the caller's responsibility to call the cleanup function.
 func DownloadAndExtractTarball(url string, removeTopLevel bool) (string, func(), error) {
+	extractedDir, cleanup, _, err := DownloadAndExtractTarballWithChecksum(url, removeTopLevel)
+	return extractedDir, cleanup, err
+}
+
+// DownloadAndExtractTarballWithChecksum is DownloadAndExtractTarball that also
+// returns the checksum of the downloaded tarball, see TarballChecksum.
+// The url can be a file:// URL of a tarball, or of a directory, which is
+// copied and has no checksum.
+func DownloadAndExtractTarballWithChecksum(url string, removeTopLevel bool) (string, func(), string, error) {
+	if strings.HasPrefix(url, "file://") {
+		return extractLocalWithChecksum(FileURLPath(url), removeTopLevel)
+	}
 	base := filepath.Base(url)
 	f, err := os.CreateTemp("", fmt.Sprintf("%s_*.tar.gz", base))
 	if err != nil {
-		return "", nil, fmt.Errorf("unable to create temp file: %v", err)
+		return "", nil, "", fmt.Errorf("unable to create temp file: %v", err)
 	}
 	defer func() {
 		_ = f.Close()
@@ -561,10 +575,58 @@ func DownloadAndExtractTarball(url string, removeTopLevel bool) (string, func(),
 
 	err = util.DownloadFile(tarball, url, true, "")
 	if err != nil {
-		return "", nil, err
+		return "", nil, "", err
+	}
+	checksum, err := TarballChecksum(tarball)
+	if err != nil {
+		return "", nil, "", err
 	}
 	extractedDir, cleanup, err := ExtractTarballWithCleanup(tarball, removeTopLevel)
-	return extractedDir, cleanup, err
+	return extractedDir, cleanup, checksum, err
+}
+
+// extractLocalWithChecksum extracts a local tarball, or copies a directory,
+// into a new temp directory
+func extractLocalWithChecksum(path string, removeTopLevel bool) (string, func(), string, error) {
+	if fileutil.IsDirectory(path) {
+		tmpDir, err := os.MkdirTemp("", fmt.Sprintf("ddev_%s_*", filepath.Base(path)))
+		if err != nil {
+			return "", nil, "", fmt.Errorf("unable to create temp dir: %v", err)
+		}
+		cleanup := func() { _ = os.RemoveAll(tmpDir) }
+		if err = copy.Copy(path, tmpDir); err != nil {
+			return "", cleanup, "", fmt.Errorf("unable to copy %s: %v", path, err)
+		}
+		return tmpDir, cleanup, "", nil
+	}
+	checksum, err := TarballChecksum(path)
+	if err != nil {
+		return "", nil, "", err
+	}
+	extractedDir, cleanup, err := ExtractTarballWithCleanup(path, removeTopLevel)
+	return extractedDir, cleanup, checksum, err
+}
+
+// FileURLPath returns the local path of a file:// URL
+func FileURLPath(fileURL string) string {
+	p := strings.TrimPrefix(fileURL, "file://")
+	if u, err := url.Parse(fileURL); err == nil {
+		p = u.Path
+	}
+	// file:///C:/dir on Windows
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+// TarballChecksum returns the SHA-256 checksum of a tarball as "sha256:<hex>"
+func TarballChecksum(tarball string) (string, error) {
+	checksum, err := fileutil.FileSHA256(tarball)
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + checksum, nil
 }
 
 // ExtractTarballWithCleanup takes a tarball file and extracts it into a temp directory
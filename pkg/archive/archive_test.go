@@ -175,6 +175,19 @@ func TestDownloadAndExtractTarball(t *testing.T) {
 	require.NoDirExists(t, dir)
 }
 
+// TestTarballChecksum tests TarballChecksum
+func TestTarballChecksum(t *testing.T) {
+	tarball := filepath.Join(t.TempDir(), "test.tar.gz")
+	require.NoError(t, os.WriteFile(tarball, []byte("hello"), 0644))
+
+	checksum, err := archive.TarballChecksum(tarball)
+	require.NoError(t, err)
+	require.Equal(t, "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", checksum)
+
+	_, err = archive.TarballChecksum(filepath.Join(t.TempDir(), "missing.tar.gz"))
+	require.Error(t, err)
+}
+
 // TestUntarPathTraversal verifies that path traversal attempts in tar archives are rejected
 func TestUntarPathTraversal(t *testing.T) {
 	destDir := testcommon.CreateTmpDir(t.Name())
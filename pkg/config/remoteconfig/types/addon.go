@@ -70,6 +70,12 @@ type Addon struct {
 	UpdatedAt             string         `json:"updated_at"`
 	WorkflowStatus        string         `json:"workflow_status"`
 	Stars                 int            `json:"stars"`
+	// TarballURL is the tarball of TagName, for add-ons not downloaded from GitHub
+	TarballURL string `json:"tarball_url,omitempty"`
+	// Versions are the tarballs of the available versions, for add-ons not downloaded from GitHub
+	Versions map[string]string `json:"versions,omitempty"`
+	// Registry is the name of the add-on registry the add-on comes from
+	Registry string `json:"registry,omitempty"`
 }
 
 // AddonData represents the complete add-on registry from addons.ddev.com
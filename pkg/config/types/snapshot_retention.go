@@ -0,0 +1,44 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/docker/go-units"
+)
+
+// SnapshotRetention describes which database snapshots are kept when
+// snapshots are pruned. Rules left empty are not applied.
+type SnapshotRetention struct {
+	// KeepLast keeps the most recent N snapshots
+	KeepLast int `yaml:"keep_last,omitempty"`
+	// KeepDaily keeps the most recent snapshot of each of the last D days
+	KeepDaily int `yaml:"keep_daily,omitempty"`
+	// MaxSize caps the total size of the kept snapshots, for example "10GB"
+	MaxSize string `yaml:"max_size,omitempty"`
+}
+
+// IsEmpty returns true if no retention rule is configured
+func (r SnapshotRetention) IsEmpty() bool {
+	return r.KeepLast == 0 && r.KeepDaily == 0 && r.MaxSize == ""
+}
+
+// GetMaxSizeBytes returns MaxSize in bytes, or 0 if it's not set
+func (r SnapshotRetention) GetMaxSizeBytes() (int64, error) {
+	if r.MaxSize == "" {
+		return 0, nil
+	}
+	size, err := units.RAMInBytes(r.MaxSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid snapshot_retention max_size '%s', use a size like '500MB' or '10GB': %v", r.MaxSize, err)
+	}
+	return size, nil
+}
+
+// Validate returns an error if the retention rules can't be applied
+func (r SnapshotRetention) Validate() error {
+	if r.KeepLast < 0 || r.KeepDaily < 0 {
+		return fmt.Errorf("snapshot_retention keep_last and keep_daily can't be negative")
+	}
+	_, err := r.GetMaxSizeBytes()
+	return err
+}
@@ -0,0 +1,625 @@
+package ddevapp
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/nodeps"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/otiai10/copy"
+	"go.yaml.in/yaml/v4"
+)
+
+// AddonAction is a declarative action of the actions of install.yaml. Unlike
+// pre_install_actions and post_install_actions, which are scripts, it's run
+// by DDEV itself, can be checked without changing anything, and generates
+// the actions that undo it when the add-on is removed.
+// Exactly one of its fields is set.
+type AddonAction struct {
+	// SetEnv sets variables in a .env file in .ddev, .env by default
+	SetEnv *AddonEnvAction `yaml:"set_env,omitempty"`
+	// AppendWebEnvironment adds NAME=value items to the web_environment of the project
+	AppendWebEnvironment []string `yaml:"append_web_environment,omitempty"`
+	// MergeYaml merges content into a .ddev/config.*.yaml file
+	MergeYaml *AddonYamlAction `yaml:"merge_yaml,omitempty"`
+	// AddHostname adds hostnames to the additional_hostnames of the project
+	AddHostname []string `yaml:"add_hostname,omitempty"`
+	// RequireProjectType fails the installation unless the project is of one of these types
+	RequireProjectType []string `yaml:"require_project_type,omitempty"`
+	// CopyIfMissing copies a file or directory of the add-on unless it already exists
+	CopyIfMissing *AddonFileAction `yaml:"copy_if_missing,omitempty"`
+	// RenderTemplate renders a Go template of the add-on into a file
+	RenderTemplate *AddonFileAction `yaml:"render_template,omitempty"`
+
+	// The removal actions, generated when the actions above are run
+
+	// UnsetEnv removes variables from a .env file if they still have the values set by the add-on
+	UnsetEnv *AddonEnvAction `yaml:"unset_env,omitempty"`
+	// UnmergeYaml removes merged content from a .ddev/config.*.yaml file
+	UnmergeYaml *AddonYamlAction `yaml:"unmerge_yaml,omitempty"`
+	// RemoveFile removes a file if it has the #ddev-generated signature
+	RemoveFile string `yaml:"remove_file,omitempty"`
+}
+
+// AddonEnvAction is the set_env and unset_env actions
+type AddonEnvAction struct {
+	// File is relative to the .ddev directory, .env by default
+	File string            `yaml:"file,omitempty"`
+	Vars map[string]string `yaml:"vars"`
+}
+
+// AddonYamlAction is the merge_yaml and unmerge_yaml actions
+type AddonYamlAction struct {
+	// File is a config.*.yaml file of the .ddev directory
+	File    string         `yaml:"file"`
+	Content map[string]any `yaml:"content"`
+	// Previous has the values replaced by merge_yaml, which unmerge_yaml restores
+	Previous map[string]any `yaml:"previous,omitempty"`
+}
+
+// AddonFileAction is the copy_if_missing and render_template actions
+type AddonFileAction struct {
+	// Src is relative to the add-on directory
+	Src string `yaml:"src"`
+	// Dest is relative to the .ddev directory, and must be in the project
+	Dest string `yaml:"dest"`
+}
+
+// AddonActionPlan describes what an action does or would do
+type AddonActionPlan struct {
+	Description string
+	// Files are the files the action writes, relative to the project root
+	Files []string
+	// Skipped is true when the action leaves a file alone because it doesn't have #ddev-generated
+	Skipped bool
+}
+
+// Type returns the type of the action, the name of its field in install.yaml
+func (a AddonAction) Type() (string, error) {
+	fields := []struct {
+		name string
+		set  bool
+	}{
+		{"set_env", a.SetEnv != nil},
+		{"append_web_environment", len(a.AppendWebEnvironment) > 0},
+		{"merge_yaml", a.MergeYaml != nil},
+		{"add_hostname", len(a.AddHostname) > 0},
+		{"require_project_type", len(a.RequireProjectType) > 0},
+		{"copy_if_missing", a.CopyIfMissing != nil},
+		{"render_template", a.RenderTemplate != nil},
+		{"unset_env", a.UnsetEnv != nil},
+		{"unmerge_yaml", a.UnmergeYaml != nil},
+		{"remove_file", a.RemoveFile != ""},
+	}
+	var types []string
+	for _, f := range fields {
+		if f.set {
+			types = append(types, f.name)
+		}
+	}
+	switch len(types) {
+	case 0:
+		return "", fmt.Errorf("the action has no type")
+	case 1:
+		return types[0], nil
+	default:
+		return "", fmt.Errorf("the action has several types: %s", strings.Join(types, ", "))
+	}
+}
+
+// addonActionRunner runs the actions of an add-on
+type addonActionRunner struct {
+	app      *DdevApp
+	desc     InstallDesc
+	addonDir string
+	dryRun   bool
+}
+
+// PlanAddonActions checks the actions of an add-on extracted in addonDir and
+// describes what they would do, without changing anything
+func PlanAddonActions(app *DdevApp, addonDir string, desc InstallDesc) ([]AddonActionPlan, error) {
+	r := &addonActionRunner{app: app, desc: desc, addonDir: addonDir, dryRun: true}
+	var plans []AddonActionPlan
+	for i, action := range desc.Actions {
+		plan, _, err := r.run(action)
+		if err != nil {
+			return nil, fmt.Errorf("action (%d): %v", i, err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// RunAddonActions runs the actions of an add-on extracted in addonDir. It
+// returns the removal actions that undo them, including the ones of a
+// previous installation of the add-on, to be recorded in its manifest.
+func RunAddonActions(app *DdevApp, addonDir string, desc InstallDesc) ([]AddonAction, error) {
+	var removals []AddonAction
+	if manifests, err := GatherAllManifests(app); err == nil {
+		if m, ok := manifests[desc.Name]; ok {
+			removals = append(removals, m.GeneratedRemovalActions...)
+		}
+	}
+
+	if len(desc.Actions) > 0 {
+		util.Success("\nExecuting actions:")
+	}
+	r := &addonActionRunner{app: app, desc: desc, addonDir: addonDir}
+	for i, action := range desc.Actions {
+		plan, removal, err := r.run(action)
+		if err != nil {
+			return nil, fmt.Errorf("could not process action (%d): %v", i, err)
+		}
+		if plan.Skipped {
+			util.Warning("%s", plan.Description)
+		} else {
+			util.Success("%c %s", '\U0001F44D', plan.Description)
+		}
+		if removal != nil && !slices.ContainsFunc(removals, func(a AddonAction) bool { return reflect.DeepEqual(a, *removal) }) {
+			removals = append(removals, *removal)
+		}
+	}
+	return removals, nil
+}
+
+// RunAddonRemovalActions runs the removal actions generated by the actions
+// of an add-on, the latest first
+func RunAddonRemovalActions(app *DdevApp, removals []AddonAction) {
+	r := &addonActionRunner{app: app}
+	for i := len(removals) - 1; i >= 0; i-- {
+		plan, _, err := r.run(removals[i])
+		switch {
+		case err != nil:
+			util.Warning("could not process removal action (%d): %v", i, err)
+		case plan.Skipped:
+			util.Warning("%s", plan.Description)
+		default:
+			util.Success("%c %s", '\U0001F44D', plan.Description)
+		}
+	}
+}
+
+// run runs an action, or only checks it in dry-run mode, and returns its removal action, if any
+func (r *addonActionRunner) run(action AddonAction) (AddonActionPlan, *AddonAction, error) {
+	actionType, err := action.Type()
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	switch actionType {
+	case "set_env":
+		return r.setEnv(action.SetEnv)
+	case "append_web_environment":
+		return r.mergeYaml(&AddonYamlAction{File: r.addonConfigFile(), Content: map[string]any{"web_environment": stringsToAny(action.AppendWebEnvironment)}})
+	case "merge_yaml":
+		return r.mergeYaml(action.MergeYaml)
+	case "add_hostname":
+		return r.mergeYaml(&AddonYamlAction{File: r.addonConfigFile(), Content: map[string]any{"additional_hostnames": stringsToAny(action.AddHostname)}})
+	case "require_project_type":
+		if !slices.Contains(action.RequireProjectType, r.app.Type) {
+			return AddonActionPlan{}, nil, fmt.Errorf("the '%s' add-on requires a project of type %s, but this project is of type '%s'", r.desc.Name, strings.Join(action.RequireProjectType, ", "), r.app.Type)
+		}
+		return AddonActionPlan{Description: fmt.Sprintf("The project type '%s' is supported", r.app.Type)}, nil, nil
+	case "copy_if_missing":
+		return r.copyIfMissing(action.CopyIfMissing)
+	case "render_template":
+		return r.renderTemplate(action.RenderTemplate)
+	case "unset_env":
+		return r.unsetEnv(action.UnsetEnv)
+	case "unmerge_yaml":
+		return r.unmergeYaml(action.UnmergeYaml)
+	default:
+		return r.removeFile(action.RemoveFile)
+	}
+}
+
+// addonConfigFile is the config file in which append_web_environment and add_hostname write
+func (r *addonActionRunner) addonConfigFile() string {
+	return fmt.Sprintf("config.addon-%s.yaml", r.desc.Name)
+}
+
+// projectPath returns the absolute path of a file relative to the .ddev
+// directory, which must be in the project, and its path relative to the project
+func (r *addonActionRunner) projectPath(file string) (string, string, error) {
+	if file == "" {
+		return "", "", fmt.Errorf("no file specified")
+	}
+	if filepath.IsAbs(file) {
+		return "", "", fmt.Errorf("'%s' must be relative to the .ddev directory", file)
+	}
+	path := r.app.GetConfigPath(file)
+	rel, err := filepath.Rel(r.app.AppRoot, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("'%s' is outside of the project", file)
+	}
+	return path, filepath.ToSlash(rel), nil
+}
+
+// addonPath returns the absolute path of a file of the add-on
+func (r *addonActionRunner) addonPath(file string) (string, error) {
+	path := filepath.Join(r.addonDir, file)
+	rel, err := filepath.Rel(r.addonDir, path)
+	if file == "" || filepath.IsAbs(file) || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("'%s' is not a file of the add-on", file)
+	}
+	if !fileutil.FileExists(path) {
+		return "", fmt.Errorf("'%s' does not exist in the add-on", file)
+	}
+	return path, nil
+}
+
+// readEnvFile reads a .env file of an env action, which is empty if it doesn't exist
+func (r *addonActionRunner) readEnvFile(a *AddonEnvAction) (string, string, map[string]string, string, error) {
+	file := a.File
+	if file == "" {
+		file = ".env"
+	}
+	path, rel, err := r.projectPath(file)
+	if err != nil {
+		return "", "", nil, "", err
+	}
+	if !fileutil.FileExists(path) {
+		return path, rel, map[string]string{}, "", nil
+	}
+	envMap, envText, err := ReadProjectEnvFile(path)
+	if err != nil {
+		return "", "", nil, "", fmt.Errorf("unable to read %s: %v", path, err)
+	}
+	return path, rel, envMap, envText, nil
+}
+
+// setEnv runs a set_env action
+func (r *addonActionRunner) setEnv(a *AddonEnvAction) (AddonActionPlan, *AddonAction, error) {
+	if len(a.Vars) == 0 {
+		return AddonActionPlan{}, nil, fmt.Errorf("set_env has no vars")
+	}
+	path, rel, envMap, envText, err := r.readEnvFile(a)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	changed := map[string]string{}
+	for k, v := range a.Vars {
+		if k == "" || strings.ContainsAny(k, "= \t\r\n") {
+			return AddonActionPlan{}, nil, fmt.Errorf("invalid variable name '%s'", k)
+		}
+		if current, ok := envMap[k]; !ok || current != v {
+			changed[k] = v
+		}
+	}
+	plan := AddonActionPlan{Description: fmt.Sprintf("Set %s in %s", strings.Join(sortedKeys(a.Vars), ", "), rel)}
+	if len(changed) == 0 {
+		return plan, nil, nil
+	}
+	plan.Files = []string{rel}
+	if !r.dryRun {
+		if err = WriteProjectEnvFile(path, changed, envText); err != nil {
+			return AddonActionPlan{}, nil, fmt.Errorf("unable to write %s: %v", path, err)
+		}
+	}
+	return plan, &AddonAction{UnsetEnv: &AddonEnvAction{File: a.File, Vars: changed}}, nil
+}
+
+// unsetEnv runs an unset_env action
+func (r *addonActionRunner) unsetEnv(a *AddonEnvAction) (AddonActionPlan, *AddonAction, error) {
+	path, rel, envMap, envText, err := r.readEnvFile(a)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	var removed []string
+	for _, k := range sortedKeys(a.Vars) {
+		if current, ok := envMap[k]; ok && current == a.Vars[k] {
+			removed = append(removed, k)
+		}
+	}
+	plan := AddonActionPlan{Description: fmt.Sprintf("Unset %s in %s", strings.Join(sortedKeys(a.Vars), ", "), rel)}
+	if len(removed) == 0 {
+		return plan, nil, nil
+	}
+	plan.Files = []string{rel}
+	if !r.dryRun {
+		if err = os.WriteFile(path, []byte(removeProjectEnvFileVars(envText, removed)), 0644); err != nil {
+			return AddonActionPlan{}, nil, fmt.Errorf("unable to write %s: %v", path, err)
+		}
+	}
+	return plan, nil, nil
+}
+
+// readYamlFile reads the config.*.yaml file of a yaml action, which is empty
+// if it doesn't exist. ok is false if the file doesn't have #ddev-generated.
+func (r *addonActionRunner) readYamlFile(a *AddonYamlAction) (path string, rel string, content map[string]any, ok bool, err error) {
+	if a.File != filepath.Base(a.File) || !strings.HasPrefix(a.File, "config.") || !strings.HasSuffix(a.File, ".yaml") || a.File == "config.yaml" {
+		return "", "", nil, false, fmt.Errorf("'%s' is not a config.*.yaml file of the .ddev directory", a.File)
+	}
+	path, rel, err = r.projectPath(a.File)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+	content = map[string]any{}
+	if !fileutil.FileExists(path) {
+		return path, rel, content, true, nil
+	}
+	if fileutil.CheckSignatureOrNoFile(path, nodeps.DdevFileSignature) != nil {
+		return path, rel, nil, false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+	if err = yaml.Unmarshal(data, &content); err != nil {
+		return "", "", nil, false, fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	if content == nil {
+		content = map[string]any{}
+	}
+	return path, rel, content, true, nil
+}
+
+// writeYamlFile writes a config.*.yaml file with the #ddev-generated
+// signature, or removes it if it's empty
+func writeYamlFile(path string, content map[string]any) error {
+	if len(content) == 0 {
+		return os.Remove(path)
+	}
+	var out bytes.Buffer
+	out.WriteString(nodeps.DdevFileSignature + "\n")
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(content); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// mergeYaml runs a merge_yaml action
+func (r *addonActionRunner) mergeYaml(a *AddonYamlAction) (AddonActionPlan, *AddonAction, error) {
+	if len(a.Content) == 0 {
+		return AddonActionPlan{}, nil, fmt.Errorf("merge_yaml has no content")
+	}
+	path, rel, content, ok, err := r.readYamlFile(a)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	if !ok {
+		return AddonActionPlan{Description: fmt.Sprintf("NOT merging %s into %s, which doesn't have #ddev-generated", strings.Join(sortedKeys(a.Content), ", "), rel), Skipped: true}, nil, nil
+	}
+	plan := AddonActionPlan{Description: fmt.Sprintf("Merge %s into %s", strings.Join(sortedKeys(a.Content), ", "), rel)}
+	changed, previous := mergeYamlContent(content, a.Content)
+	if len(changed) == 0 {
+		return plan, nil, nil
+	}
+	plan.Files = []string{rel}
+	if !r.dryRun {
+		if err = writeYamlFile(path, content); err != nil {
+			return AddonActionPlan{}, nil, fmt.Errorf("unable to write %s: %v", path, err)
+		}
+	}
+	return plan, &AddonAction{UnmergeYaml: &AddonYamlAction{File: a.File, Content: changed, Previous: previous}}, nil
+}
+
+// unmergeYaml runs an unmerge_yaml action
+func (r *addonActionRunner) unmergeYaml(a *AddonYamlAction) (AddonActionPlan, *AddonAction, error) {
+	path, rel, content, ok, err := r.readYamlFile(a)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	if !ok {
+		return AddonActionPlan{Description: fmt.Sprintf("NOT removing %s from %s, which doesn't have #ddev-generated", strings.Join(sortedKeys(a.Content), ", "), rel), Skipped: true}, nil, nil
+	}
+	plan := AddonActionPlan{Description: fmt.Sprintf("Remove %s from %s", strings.Join(sortedKeys(a.Content), ", "), rel)}
+	if !fileutil.FileExists(path) {
+		return plan, nil, nil
+	}
+	plan.Files = []string{rel}
+	if !r.dryRun {
+		unmergeYamlContent(content, a.Content, a.Previous)
+		if err = writeYamlFile(path, content); err != nil {
+			return AddonActionPlan{}, nil, fmt.Errorf("unable to write %s: %v", path, err)
+		}
+	}
+	return plan, nil, nil
+}
+
+// copyIfMissing runs a copy_if_missing action
+func (r *addonActionRunner) copyIfMissing(a *AddonFileAction) (AddonActionPlan, *AddonAction, error) {
+	src, err := r.addonPath(a.Src)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	dest, rel, err := r.projectPath(a.Dest)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	if fileutil.FileExists(dest) {
+		return AddonActionPlan{Description: fmt.Sprintf("Keep the existing %s", rel)}, nil, nil
+	}
+	if !r.dryRun {
+		if err = copy.Copy(src, dest); err != nil {
+			return AddonActionPlan{}, nil, fmt.Errorf("unable to copy %v to %v: %v", src, dest, err)
+		}
+	}
+	return AddonActionPlan{Description: fmt.Sprintf("Copy %s to %s", a.Src, rel), Files: []string{rel}}, &AddonAction{RemoveFile: a.Dest}, nil
+}
+
+// renderTemplate runs a render_template action
+func (r *addonActionRunner) renderTemplate(a *AddonFileAction) (AddonActionPlan, *AddonAction, error) {
+	src, err := r.addonPath(a.Src)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	dest, rel, err := r.projectPath(a.Dest)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	if err = fileutil.CheckSignatureOrNoFile(dest, nodeps.DdevFileSignature); err != nil {
+		return AddonActionPlan{Description: fmt.Sprintf("NOT overwriting %s, which doesn't have #ddev-generated", rel), Skipped: true}, nil, nil
+	}
+	text, err := os.ReadFile(src)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	t, err := template.New(a.Src).Funcs(getTemplateFuncMap()).Parse(string(text))
+	if err != nil {
+		return AddonActionPlan{}, nil, fmt.Errorf("could not parse template %s: %v", a.Src, err)
+	}
+	data, err := getAddonTemplateData(r.app, r.desc)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	var doc bytes.Buffer
+	if err = t.Execute(&doc, data); err != nil {
+		return AddonActionPlan{}, nil, fmt.Errorf("could not execute template %s: %v", a.Src, err)
+	}
+	if !r.dryRun {
+		if err = os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return AddonActionPlan{}, nil, err
+		}
+		if err = os.WriteFile(dest, doc.Bytes(), 0644); err != nil {
+			return AddonActionPlan{}, nil, fmt.Errorf("unable to write %s: %v", dest, err)
+		}
+	}
+	return AddonActionPlan{Description: fmt.Sprintf("Render %s to %s", a.Src, rel), Files: []string{rel}}, &AddonAction{RemoveFile: a.Dest}, nil
+}
+
+// removeFile runs a remove_file action
+func (r *addonActionRunner) removeFile(file string) (AddonActionPlan, *AddonAction, error) {
+	path, rel, err := r.projectPath(file)
+	if err != nil {
+		return AddonActionPlan{}, nil, err
+	}
+	if err = fileutil.CheckSignatureOrNoFile(path, nodeps.DdevFileSignature); err != nil {
+		return AddonActionPlan{Description: fmt.Sprintf("Unwilling to remove '%s' because it does not have #ddev-generated in it; you can manually delete it if it is safe to delete.", rel), Skipped: true}, nil, nil
+	}
+	if !r.dryRun {
+		if err = os.RemoveAll(path); err != nil {
+			return AddonActionPlan{}, nil, err
+		}
+	}
+	return AddonActionPlan{Description: fmt.Sprintf("Remove %s", rel), Files: []string{rel}}, nil, nil
+}
+
+// mergeYamlContent merges content into target: maps are merged, list items
+// are appended unless they are already there, and other values are replaced.
+// It returns what it changed and the values it replaced, which unmergeYamlContent
+// removes and restores.
+func mergeYamlContent(target map[string]any, content map[string]any) (map[string]any, map[string]any) {
+	changed := map[string]any{}
+	previous := map[string]any{}
+	for k, v := range content {
+		current, exists := target[k]
+		switch v := v.(type) {
+		case map[string]any:
+			sub, ok := current.(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+			}
+			if c, p := mergeYamlContent(sub, v); len(c) > 0 {
+				target[k] = sub
+				changed[k] = c
+				if exists && !ok {
+					previous[k] = current
+				} else if len(p) > 0 {
+					previous[k] = p
+				}
+			}
+		case []any:
+			list, ok := current.([]any)
+			var added []any
+			for _, item := range v {
+				if !slices.ContainsFunc(list, func(e any) bool { return reflect.DeepEqual(e, item) }) {
+					list = append(list, item)
+					added = append(added, item)
+				}
+			}
+			if len(added) > 0 {
+				target[k] = list
+				changed[k] = added
+				if exists && !ok {
+					previous[k] = current
+				}
+			}
+		default:
+			if !exists || !reflect.DeepEqual(current, v) {
+				target[k] = v
+				changed[k] = v
+				if exists {
+					previous[k] = current
+				}
+			}
+		}
+	}
+	return changed, previous
+}
+
+// unmergeYamlContent removes content merged by mergeYamlContent from target,
+// except values that have been changed since, and restores the previous values
+// that were replaced
+func unmergeYamlContent(target map[string]any, content map[string]any, previous map[string]any) {
+	// removeOrRestore removes a key that has nothing left from the merge
+	removeOrRestore := func(k string) {
+		if p, ok := previous[k]; ok {
+			target[k] = p
+		} else {
+			delete(target, k)
+		}
+	}
+	for k, v := range content {
+		switch v := v.(type) {
+		case map[string]any:
+			if sub, ok := target[k].(map[string]any); ok {
+				subPrevious, isSubPrevious := previous[k].(map[string]any)
+				unmergeYamlContent(sub, v, subPrevious)
+				if len(sub) == 0 {
+					if isSubPrevious {
+						delete(target, k)
+					} else {
+						removeOrRestore(k)
+					}
+				}
+			}
+		case []any:
+			if list, ok := target[k].([]any); ok {
+				list = slices.DeleteFunc(list, func(e any) bool {
+					return slices.ContainsFunc(v, func(item any) bool { return reflect.DeepEqual(e, item) })
+				})
+				if len(list) == 0 {
+					removeOrRestore(k)
+				} else {
+					target[k] = list
+				}
+			}
+		default:
+			if reflect.DeepEqual(target[k], v) {
+				removeOrRestore(k)
+			}
+		}
+	}
+}
+
+// stringsToAny converts a list of strings to a yaml list
+func stringsToAny(s []string) []any {
+	l := make([]any, 0, len(s))
+	for _, v := range s {
+		l = append(l, v)
+	}
+	return l
+}
+
+// sortedKeys returns the sorted keys of a map
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,165 @@
+package ddevapp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+// TestAddonActions checks that the declarative actions of install.yaml are
+// planned without changes, run, and undone by their generated removal actions
+func TestAddonActions(t *testing.T) {
+	assert := asrt.New(t)
+
+	app := &DdevApp{Name: "actions", AppRoot: t.TempDir(), Type: "drupal11"}
+	require.NoError(t, os.MkdirAll(app.GetConfigPath(""), 0755))
+	require.NoError(t, os.WriteFile(app.GetConfigPath(".env"), []byte("KEEP=1\n"), 0644))
+
+	addonDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(addonDir, "settings.test.php"), []byte("<?php\n// #ddev-generated\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(addonDir, "test.conf.tmpl"), []byte("#ddev-generated\nproject={{ .DdevProjectConfig.name }}\n"), 0644))
+
+	var desc InstallDesc
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: test
+actions:
+  - require_project_type: [drupal10, drupal11]
+  - set_env:
+      vars:
+        TEST_HOST: test
+  - append_web_environment:
+      - TEST_PORT=1234
+  - add_hostname:
+      - test-ui
+  - merge_yaml:
+      file: config.extra.yaml
+      content:
+        webimage_extra_packages: [redis-tools]
+        web_extra_daemons:
+          - name: test
+            command: sleep infinity
+  - copy_if_missing:
+      src: settings.test.php
+      dest: ../web/sites/default/settings.test.php
+  - render_template:
+      src: test.conf.tmpl
+      dest: test/test.conf
+`), &desc))
+
+	plans, err := PlanAddonActions(app, addonDir, desc)
+	require.NoError(t, err)
+	require.Len(t, plans, 7)
+	assert.Equal("Set TEST_HOST in .ddev/.env", plans[1].Description)
+	assert.Equal([]string{".ddev/config.addon-test.yaml"}, plans[2].Files)
+	assert.Equal([]string{"web/sites/default/settings.test.php"}, plans[5].Files)
+	assert.NoFileExists(app.GetConfigPath("config.addon-test.yaml"))
+	assert.NoFileExists(app.GetConfigPath("test/test.conf"))
+
+	removals, err := RunAddonActions(app, addonDir, desc)
+	require.NoError(t, err)
+	require.Len(t, removals, 6)
+
+	env, err := os.ReadFile(app.GetConfigPath(".env"))
+	require.NoError(t, err)
+	assert.Equal("KEEP=1\nTEST_HOST=\"test\"\n", string(env))
+	config, err := os.ReadFile(app.GetConfigPath("config.addon-test.yaml"))
+	require.NoError(t, err)
+	assert.Equal("#ddev-generated\nadditional_hostnames:\n  - test-ui\nweb_environment:\n  - TEST_PORT=1234\n", string(config))
+	config, err = os.ReadFile(app.GetConfigPath("config.extra.yaml"))
+	require.NoError(t, err)
+	assert.Contains(string(config), "webimage_extra_packages:\n  - redis-tools\n")
+	assert.FileExists(filepath.Join(app.AppRoot, "web", "sites", "default", "settings.test.php"))
+	rendered, err := os.ReadFile(app.GetConfigPath("test/test.conf"))
+	require.NoError(t, err)
+	assert.Equal("#ddev-generated\nproject=actions\n", string(rendered))
+
+	// The removal actions are recorded in the manifest
+	data, err := yaml.Marshal(AddonManifest{GeneratedRemovalActions: removals})
+	require.NoError(t, err)
+	var manifest AddonManifest
+	require.NoError(t, yaml.Unmarshal(data, &manifest))
+
+	// Files changed since installation are kept
+	require.NoError(t, os.WriteFile(app.GetConfigPath("config.extra.yaml"), append(config, []byte("omit_containers: [db]\n")...), 0644))
+
+	RunAddonRemovalActions(app, manifest.GeneratedRemovalActions)
+	env, err = os.ReadFile(app.GetConfigPath(".env"))
+	require.NoError(t, err)
+	assert.Equal("KEEP=1\n", string(env))
+	assert.NoFileExists(app.GetConfigPath("config.addon-test.yaml"))
+	config, err = os.ReadFile(app.GetConfigPath("config.extra.yaml"))
+	require.NoError(t, err)
+	assert.Equal("#ddev-generated\nomit_containers:\n  - db\n", string(config))
+	assert.NoFileExists(filepath.Join(app.AppRoot, "web", "sites", "default", "settings.test.php"))
+	assert.NoFileExists(app.GetConfigPath("test/test.conf"))
+
+	// The project type is checked before anything is changed
+	app.Type = "wordpress"
+	_, err = PlanAddonActions(app, addonDir, desc)
+	assert.ErrorContains(err, "the 'test' add-on requires a project of type drupal10, drupal11, but this project is of type 'wordpress'")
+
+	// and before the dependencies are installed
+	dependentDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dependentDir, "install.yaml"), []byte("name: dependent\ndependencies:\n  - ddev/ddev-does-not-exist\nactions:\n  - require_project_type: [drupal11]\n"), 0644))
+	err = InstallAddonFromDirectory(app, dependentDir, "", "", false)
+	assert.ErrorContains(err, "the 'dependent' add-on requires a project of type drupal11, but this project is of type 'wordpress'")
+
+	// Actions can't write outside of the project or have several types
+	_, err = PlanAddonActions(app, addonDir, InstallDesc{Name: "test", Actions: []AddonAction{{CopyIfMissing: &AddonFileAction{Src: "settings.test.php", Dest: "../../settings.test.php"}}}})
+	assert.ErrorContains(err, "is outside of the project")
+	_, err = PlanAddonActions(app, addonDir, InstallDesc{Name: "test", Actions: []AddonAction{{AddHostname: []string{"a"}, RemoveFile: "b"}}})
+	assert.ErrorContains(err, "the action has several types: add_hostname, remove_file")
+}
+
+// TestMergeYamlContent checks that unmerging restores the values replaced by a merge
+func TestMergeYamlContent(t *testing.T) {
+	assert := asrt.New(t)
+
+	var target, content map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(`
+php_version: "8.2"
+keep: true
+hooks: none
+web_environment: [A=1]
+nested:
+  timezone: UTC
+  other: 1
+`), &target))
+	require.NoError(t, yaml.Unmarshal([]byte(`
+php_version: "8.4"
+hooks: [post-start]
+web_environment: [B=2]
+nested:
+  timezone: Europe/Paris
+added: 1
+`), &content))
+	original, err := yaml.Marshal(target)
+	require.NoError(t, err)
+
+	changed, previous := mergeYamlContent(target, content)
+	assert.Equal("8.4", target["php_version"])
+	assert.Equal([]any{"post-start"}, target["hooks"])
+	assert.Equal(map[string]any{"php_version": "8.2", "hooks": "none", "nested": map[string]any{"timezone": "UTC"}}, previous)
+
+	// The removal action keeps the previous values in the manifest
+	data, err := yaml.Marshal(AddonYamlAction{File: "config.yaml", Content: changed, Previous: previous})
+	require.NoError(t, err)
+	var removal AddonYamlAction
+	require.NoError(t, yaml.Unmarshal(data, &removal))
+
+	unmergeYamlContent(target, removal.Content, removal.Previous)
+	restored, err := yaml.Marshal(target)
+	require.NoError(t, err)
+	assert.Equal(string(original), string(restored))
+
+	// Values changed since the merge are kept
+	changed, previous = mergeYamlContent(target, content)
+	target["php_version"] = "8.3"
+	unmergeYamlContent(target, changed, previous)
+	assert.Equal("8.3", target["php_version"])
+	assert.Equal("none", target["hooks"])
+}
@@ -0,0 +1,364 @@
+package ddevapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ddev/ddev/pkg/archive"
+	"github.com/ddev/ddev/pkg/config/remoteconfig/types"
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
+	"go.yaml.in/yaml/v4"
+)
+
+// AddonRegistryIndex is the index of an add-on registry directory, like the
+// ones written by 'ddev add-on mirror'
+const AddonRegistryIndex = "addons.json"
+
+// localAddonVersion is the version of the add-ons of a registry directory of add-ons
+const localAddonVersion = "local"
+
+// readAddonRegistry returns the add-ons of an add-on registry
+func readAddonRegistry(r globalconfig.AddonRegistry) (*types.AddonData, error) {
+	location := r.Location
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		cacheName := ".addon-data"
+		if r.Name != globalconfig.PublicAddonRegistryName {
+			cacheName = ".addon-data-" + r.Name
+		}
+		return getCachedAddonRegistry(location, cacheName)
+	}
+
+	path, err := addonRegistryPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var addonData *types.AddonData
+	switch {
+	case fileutil.IsDirectory(path) && fileutil.FileExists(filepath.Join(path, AddonRegistryIndex)):
+		addonData, err = readAddonRegistryIndex(filepath.Join(path, AddonRegistryIndex), true)
+	case fileutil.IsDirectory(path):
+		addonData, err = scanAddonDirectories(path, r.Name)
+	default:
+		addonData, err = readAddonRegistryIndex(path, true)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the '%s' add-on registry at %s: %v", r.Name, location, err)
+	}
+	return addonData, nil
+}
+
+// addonRegistryPath returns the local path of a registry that isn't a URL
+func addonRegistryPath(r globalconfig.AddonRegistry) (string, error) {
+	path := r.Location
+	if strings.HasPrefix(path, "file://") {
+		path = archive.FileURLPath(path)
+	}
+	return util.ExpandHomedir(path)
+}
+
+// isAddonRegistryInDir reports whether a registry is the directory dir, or its index
+func isAddonRegistryInDir(r globalconfig.AddonRegistry, dir string) bool {
+	if strings.HasPrefix(r.Location, "http://") || strings.HasPrefix(r.Location, "https://") {
+		return false
+	}
+	path, err := addonRegistryPath(r)
+	if err != nil {
+		return false
+	}
+	registryInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if !registryInfo.IsDir() {
+		if registryInfo, err = os.Stat(filepath.Dir(path)); err != nil {
+			return false
+		}
+	}
+	dirInfo, err := os.Stat(dir)
+	return err == nil && os.SameFile(registryInfo, dirInfo)
+}
+
+// readAddonRegistryIndex reads a local add-on registry index. When resolve is true,
+// its relative tarball paths are turned into file:// URLs.
+func readAddonRegistryIndex(indexPath string, resolve bool) (*types.AddonData, error) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	addonData := &types.AddonData{}
+	if err = json.Unmarshal(data, addonData); err != nil {
+		return nil, err
+	}
+	if !resolve {
+		return addonData, nil
+	}
+	dir := filepath.Dir(indexPath)
+	for i := range addonData.Addons {
+		addon := &addonData.Addons[i]
+		addon.TarballURL = resolveAddonTarballURL(dir, addon.TarballURL)
+		for version, tarball := range addon.Versions {
+			addon.Versions[version] = resolveAddonTarballURL(dir, tarball)
+		}
+	}
+	return addonData, nil
+}
+
+// resolveAddonTarballURL turns a tarball path relative to the directory of a local index into a file:// URL
+func resolveAddonTarballURL(dir string, tarball string) string {
+	if tarball == "" || strings.Contains(tarball, "://") {
+		return tarball
+	}
+	if !filepath.IsAbs(tarball) {
+		tarball = filepath.Join(dir, filepath.FromSlash(tarball))
+	}
+	return output.FileURL(tarball)
+}
+
+// scanAddonDirectories returns the add-ons in <name> or <owner>/<name>
+// subdirectories of dir. Add-ons in <name> subdirectories are named
+// <registry>/<name>.
+func scanAddonDirectories(dir string, registryName string) (*types.AddonData, error) {
+	addonData := &types.AddonData{}
+	add := func(owner, name, path string) error {
+		yamlContent, err := os.ReadFile(filepath.Join(path, "install.yaml"))
+		if err != nil {
+			return err
+		}
+		var desc InstallDesc
+		if err = yaml.Unmarshal(yamlContent, &desc); err != nil {
+			return fmt.Errorf("unable to parse %s: %v", filepath.Join(path, "install.yaml"), err)
+		}
+		addonData.Addons = append(addonData.Addons, types.Addon{
+			Title:                 name,
+			GitHubURL:             output.FileURL(path),
+			Description:           fmt.Sprintf("Add-on in %s", path),
+			User:                  owner,
+			Repo:                  name,
+			TagName:               types.FlexibleString{Value: localAddonVersion, IsSet: true},
+			DdevVersionConstraint: desc.DdevVersionConstraint,
+			Dependencies:          desc.Dependencies,
+			TarballURL:            output.FileURL(path),
+		})
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if fileutil.FileExists(filepath.Join(path, "install.yaml")) {
+			if err = add(registryName, e.Name(), path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		subEntries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range subEntries {
+			subPath := filepath.Join(path, s.Name())
+			if s.IsDir() && fileutil.FileExists(filepath.Join(subPath, "install.yaml")) {
+				if err = add(e.Name(), s.Name(), subPath); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	addonData.TotalAddonsCount = len(addonData.Addons)
+	return addonData, nil
+}
+
+// getAddonTarballURLFromRegistries returns the tarball of a version of an
+// add-on, the latest one if version is empty, from the registry with the
+// highest priority that has it. It's not found when that registry is the
+// public one, whose add-ons are downloaded from GitHub. The registry in the
+// excludeDir directory, if it's not empty, isn't used.
+func getAddonTarballURLFromRegistries(ownerRepo string, version string, excludeDir string) (string, string, bool) {
+	addonData, err := getAddonRegistryExcept(excludeDir)
+	if err != nil {
+		util.Debug("Unable to read the add-on registries: %v", err)
+		return "", "", false
+	}
+	addon := addonData.FindAddon(ownerRepo)
+	if addon == nil || addon.Registry == globalconfig.PublicAddonRegistryName {
+		return "", "", false
+	}
+	if version == "" {
+		version = addon.TagName.Value
+	}
+	if tarballURL, ok := addon.Versions[version]; ok && version != "" {
+		return tarballURL, version, true
+	}
+	if addon.TarballURL != "" && version == addon.TagName.Value {
+		return addon.TarballURL, version, true
+	}
+	return "", "", false
+}
+
+// MirrorAddons downloads the tarballs of add-ons and of their dependencies
+// into dir, as <owner>/<repo>/<version>.tar.gz, and records them in its
+// addons.json index, so that dir can be used as an add-on registry offline.
+// Add-ons are "owner/repo" for their latest version or "owner/repo:version".
+func MirrorAddons(dir string, addons []string) (*types.AddonData, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	indexPath := filepath.Join(dir, AddonRegistryIndex)
+	mirror := &types.AddonData{}
+	if fileutil.FileExists(indexPath) {
+		var err error
+		if mirror, err = readAddonRegistryIndex(indexPath, false); err != nil {
+			return nil, fmt.Errorf("unable to read %s: %v", indexPath, err)
+		}
+	}
+
+	queue := append([]string{}, addons...)
+	done := map[string]bool{}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		if done[ref] {
+			continue
+		}
+		done[ref] = true
+
+		ownerRepo, requestedVersion, _ := strings.Cut(ref, ":")
+		if !IsGithubRef(ownerRepo) {
+			return nil, fmt.Errorf("unable to mirror '%s', add-ons must be owner/repo or owner/repo:version", ref)
+		}
+		// The mirror itself isn't used, so that new releases are found when it's
+		// one of the registries
+		tarballURL, version, err := getAddonTarballURL(ownerRepo, requestedVersion, false, 0, dir)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(tarballURL, "file://") && fileutil.IsDirectory(archive.FileURLPath(tarballURL)) {
+			util.Warning("Not mirroring %s, which is a local directory: %s", ownerRepo, archive.FileURLPath(tarballURL))
+			continue
+		}
+
+		tarball := filepath.ToSlash(filepath.Join(ownerRepo, strings.ReplaceAll(version, "/", "_")+".tar.gz"))
+		dest := filepath.Join(dir, filepath.FromSlash(tarball))
+		if fileutil.FileExists(dest) {
+			util.Success("%s:%s is already mirrored", ownerRepo, version)
+		} else {
+			util.Success("Mirroring %s:%s", ownerRepo, version)
+			if err = fetchAddonTarball(tarballURL, dest); err != nil {
+				return nil, fmt.Errorf("unable to download %s: %v", tarballURL, err)
+			}
+		}
+
+		desc, err := readTarballInstallDesc(dest)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the install.yaml of %s:%s: %v", ownerRepo, version, err)
+		}
+		recordMirroredAddon(mirror, ownerRepo, version, tarball, desc)
+		for _, dep := range desc.Dependencies {
+			if IsGithubRef(dep) {
+				queue = append(queue, dep)
+			} else {
+				util.Warning("Not mirroring '%s', a dependency of %s, only owner/repo dependencies are mirrored", dep, ownerRepo)
+			}
+		}
+	}
+
+	mirror.UpdatedDateTime = time.Now().UTC()
+	mirror.TotalAddonsCount = len(mirror.Addons)
+	mirror.OfficialAddonsCount, mirror.ContribAddonsCount = 0, 0
+	for _, addon := range mirror.Addons {
+		if addon.Type == "official" {
+			mirror.OfficialAddonsCount++
+		} else {
+			mirror.ContribAddonsCount++
+		}
+	}
+	data, err := json.MarshalIndent(mirror, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(indexPath, append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	return mirror, nil
+}
+
+// recordMirroredAddon adds a mirrored version of an add-on to the index of a mirror.
+// The latest mirrored version is the one installed by default.
+func recordMirroredAddon(mirror *types.AddonData, ownerRepo string, version string, tarball string, desc InstallDesc) {
+	addon := mirror.FindAddon(ownerRepo)
+	if addon == nil {
+		owner, repo, _ := strings.Cut(ownerRepo, "/")
+		newAddon := types.Addon{Title: repo, User: owner, Repo: repo}
+		if addonData, err := getAddonRegistryWithFallback(); err == nil {
+			if known := addonData.FindAddon(ownerRepo); known != nil && known.Registry == globalconfig.PublicAddonRegistryName {
+				newAddon.Title = known.Title
+				newAddon.Description = known.Description
+				newAddon.GitHubURL = known.GitHubURL
+				newAddon.Type = known.Type
+			}
+		}
+		mirror.Addons = append(mirror.Addons, newAddon)
+		addon = &mirror.Addons[len(mirror.Addons)-1]
+	}
+	addon.Registry = ""
+	if addon.Versions == nil {
+		addon.Versions = map[string]string{}
+	}
+	addon.Versions[version] = tarball
+	if current := addon.TagName.Value; current == "" || current == version || IsAddonVersionNewer(current, version) {
+		addon.TagName = types.FlexibleString{Value: version, IsSet: true}
+		addon.TarballURL = tarball
+		addon.Dependencies = desc.Dependencies
+		addon.DdevVersionConstraint = desc.DdevVersionConstraint
+	}
+}
+
+// fetchAddonTarball downloads or copies the tarball of an add-on to dest
+func fetchAddonTarball(tarballURL string, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp := dest + ".download"
+	defer func() {
+		_ = os.Remove(tmp)
+	}()
+	var err error
+	if strings.HasPrefix(tarballURL, "file://") {
+		err = fileutil.CopyFile(archive.FileURLPath(tarballURL), tmp)
+	} else {
+		err = util.DownloadFile(tmp, tarballURL, true, "")
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// readTarballInstallDesc reads the install.yaml of the add-on in a tarball
+func readTarballInstallDesc(tarball string) (InstallDesc, error) {
+	var desc InstallDesc
+	extractedDir, cleanup, err := archive.ExtractTarballWithCleanup(tarball, true)
+	defer cleanup()
+	if err != nil {
+		return desc, err
+	}
+	yamlContent, err := os.ReadFile(filepath.Join(extractedDir, "install.yaml"))
+	if err != nil {
+		return desc, err
+	}
+	err = yaml.Unmarshal(yamlContent, &desc)
+	return desc, err
+}
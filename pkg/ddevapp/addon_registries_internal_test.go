@@ -0,0 +1,105 @@
+package ddevapp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ddev/ddev/pkg/config/remoteconfig/types"
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/output"
+	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddonRegistries checks that add-ons are found in local registries, by priority
+func TestAddonRegistries(t *testing.T) {
+	assert := asrt.New(t)
+	// The registries are read from the global config
+	t.Setenv("DDEV_XDG_CONFIG_HOME", t.TempDir())
+	t.Cleanup(globalconfig.EnsureGlobalConfig)
+	setRegistries := func(registries []globalconfig.AddonRegistry) {
+		globalconfig.EnsureGlobalConfig()
+		globalconfig.DdevGlobalConfig.AddonRegistries = registries
+		require.NoError(t, globalconfig.WriteGlobalConfig(globalconfig.DdevGlobalConfig))
+	}
+
+	// A directory of add-ons
+	addonsDir := t.TempDir()
+	for _, dir := range []string{"acme/ddev-queue", "ddev-search"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(addonsDir, dir), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(addonsDir, dir, "install.yaml"), []byte("name: test\ndependencies:\n  - ddev/ddev-test\n"), 0644))
+	}
+
+	// An index with a tarball
+	indexDir := t.TempDir()
+	require.NoError(t, fileutil.CopyFile(filepath.Join("testdata", "TestInstallAddonFromLockEntryChecksum", "addon.tar.gz"), filepath.Join(indexDir, "addon.tar.gz")))
+	index, err := json.Marshal(types.AddonData{Addons: []types.Addon{
+		{User: "ddev", Repo: "ddev-test", TagName: types.FlexibleString{Value: "v1.0.0", IsSet: true}, TarballURL: "addon.tar.gz"},
+		{User: "acme", Repo: "ddev-queue", TagName: types.FlexibleString{Value: "v1.0.0", IsSet: true}, TarballURL: "addon.tar.gz"},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(indexDir, AddonRegistryIndex), index, 0644))
+
+	setRegistries([]globalconfig.AddonRegistry{
+		{Name: globalconfig.PublicAddonRegistryName, Disabled: true},
+		{Name: "index", Location: indexDir},
+		{Name: "internal", Location: addonsDir, Priority: 10},
+	})
+
+	addonData, err := getAddonRegistryWithFallback()
+	require.NoError(t, err)
+	assert.Equal(3, addonData.TotalAddonsCount)
+	queue := addonData.FindAddon("acme/ddev-queue")
+	require.NotNil(t, queue)
+	assert.Equal("internal", queue.Registry)
+	assert.Equal([]string{"ddev/ddev-test"}, queue.Dependencies)
+	search := addonData.FindAddon("internal/ddev-search")
+	require.NotNil(t, search)
+	assert.Equal(output.FileURL(filepath.Join(addonsDir, "ddev-search")), search.TarballURL)
+
+	tarballURL, version, err := GetAddonTarballURL("acme/ddev-queue", "", false, 0)
+	require.NoError(t, err)
+	assert.Equal(output.FileURL(filepath.Join(addonsDir, "acme", "ddev-queue")), tarballURL)
+	assert.Equal("local", version)
+
+	tarballURL, version, err = GetAddonTarballURL("ddev/ddev-test", "", false, 0)
+	require.NoError(t, err)
+	assert.Equal(output.FileURL(filepath.Join(indexDir, "addon.tar.gz")), tarballURL)
+	assert.Equal("v1.0.0", version)
+
+	// Mirror the add-on of the index, then use the mirror instead
+	mirrorDir := t.TempDir()
+	mirror, err := MirrorAddons(mirrorDir, []string{"ddev/ddev-test"})
+	require.NoError(t, err)
+	assert.Equal(1, mirror.TotalAddonsCount)
+	assert.Equal(map[string]string{"v1.0.0": "ddev/ddev-test/v1.0.0.tar.gz"}, mirror.Addons[0].Versions)
+	assert.FileExists(filepath.Join(mirrorDir, "ddev", "ddev-test", "v1.0.0.tar.gz"))
+
+	setRegistries([]globalconfig.AddonRegistry{
+		{Name: globalconfig.PublicAddonRegistryName, Disabled: true},
+		{Name: "mirror", Location: output.FileURL(mirrorDir)},
+	})
+	tarballURL, version, err = GetAddonTarballURL("ddev/ddev-test", "v1.0.0", false, 0)
+	require.NoError(t, err)
+	assert.Equal(output.FileURL(filepath.Join(mirrorDir, "ddev", "ddev-test", "v1.0.0.tar.gz")), tarballURL)
+	assert.Equal("v1.0.0", version)
+
+	// Mirroring again gets the new releases, not the versions of the mirror itself
+	index, err = json.Marshal(types.AddonData{Addons: []types.Addon{
+		{User: "ddev", Repo: "ddev-test", TagName: types.FlexibleString{Value: "v1.1.0", IsSet: true}, TarballURL: "addon.tar.gz"},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(indexDir, AddonRegistryIndex), index, 0644))
+	setRegistries([]globalconfig.AddonRegistry{
+		{Name: globalconfig.PublicAddonRegistryName, Disabled: true},
+		{Name: "index", Location: indexDir},
+		{Name: "mirror", Location: filepath.Join(mirrorDir, AddonRegistryIndex), Priority: 10},
+	})
+	mirror, err = MirrorAddons(mirrorDir, []string{"ddev/ddev-test"})
+	require.NoError(t, err)
+	assert.Equal(map[string]string{"v1.0.0": "ddev/ddev-test/v1.0.0.tar.gz", "v1.1.0": "ddev/ddev-test/v1.1.0.tar.gz"}, mirror.Addons[0].Versions)
+	assert.Equal("v1.1.0", mirror.Addons[0].TagName.Value)
+}
@@ -88,6 +88,8 @@ type InstallDesc struct {
 	RemovalActions        []string          `yaml:"removal_actions,omitempty"`
 	YamlReadFiles         map[string]string `yaml:"yaml_read_files"`
 	Image                 string            `yaml:"image,omitempty"`
+	// Actions are declarative actions run by DDEV after the files are installed
+	Actions []AddonAction `yaml:"actions,omitempty"`
 }
 
 // format of the add-on manifest file
@@ -100,6 +102,8 @@ type AddonManifest struct {
 	ProjectFiles   []string `yaml:"project_files"`
 	GlobalFiles    []string `yaml:"global_files"`
 	RemovalActions []string `yaml:"removal_actions"`
+	// GeneratedRemovalActions undo the actions of install.yaml
+	GeneratedRemovalActions []AddonAction `yaml:"generated_removal_actions,omitempty"`
 }
 
 // GetInstalledAddons returns a list of the installed add-ons
@@ -211,32 +215,9 @@ func processBashHostAction(action string, installDesc InstallDesc, app *DdevApp,
 		return fmt.Errorf("could not parse action '%s': %v", action, err)
 	}
 
-	yamlMap := make(map[string]any)
-	yamlMap["DdevGlobalConfig"], err = util.YamlFileToMap(globalconfig.GetGlobalConfigPath())
-	if err != nil {
-		util.Warning("Unable to read file %s: %v", globalconfig.GetGlobalConfigPath(), err)
-	}
-
-	for name, f := range installDesc.YamlReadFiles {
-		fullPath := filepath.Join(app.GetAppRoot(), os.ExpandEnv(f))
-		yamlMap[name], err = util.YamlFileToMap(fullPath)
-		if err != nil {
-			util.Warning("Unable to import yaml file %s: %v", fullPath, err)
-		}
-	}
-	// Get project config with overrides
-	var projectConfigMap map[string]any
-	if b, err := yaml.Marshal(app); err != nil {
-		util.Warning("Unable to marshal app: %v", err)
-	} else if err = yaml.Unmarshal(b, &projectConfigMap); err != nil {
-		util.Warning("Unable to unmarshal app: %v", err)
-	} else {
-		yamlMap["DdevProjectConfig"] = projectConfigMap
-	}
-
-	dict, err := util.YamlToDict(yamlMap)
+	dict, err := getAddonTemplateData(app, installDesc)
 	if err != nil {
-		return fmt.Errorf("unable to YamlToDict: %v", err)
+		return err
 	}
 
 	var doc bytes.Buffer
@@ -293,6 +274,41 @@ func processBashHostAction(action string, installDesc InstallDesc, app *DdevApp,
 	return err
 }
 
+// getAddonTemplateData returns the data available to the templates of bash
+// actions and render_template actions: DdevGlobalConfig, DdevProjectConfig,
+// and the yaml_read_files of the add-on
+func getAddonTemplateData(app *DdevApp, installDesc InstallDesc) (map[string]any, error) {
+	var err error
+	yamlMap := make(map[string]any)
+	yamlMap["DdevGlobalConfig"], err = util.YamlFileToMap(globalconfig.GetGlobalConfigPath())
+	if err != nil {
+		util.Warning("Unable to read file %s: %v", globalconfig.GetGlobalConfigPath(), err)
+	}
+
+	for name, f := range installDesc.YamlReadFiles {
+		fullPath := filepath.Join(app.GetAppRoot(), os.ExpandEnv(f))
+		yamlMap[name], err = util.YamlFileToMap(fullPath)
+		if err != nil {
+			util.Warning("Unable to import yaml file %s: %v", fullPath, err)
+		}
+	}
+	// Get project config with overrides
+	var projectConfigMap map[string]any
+	if b, err := yaml.Marshal(app); err != nil {
+		util.Warning("Unable to marshal app: %v", err)
+	} else if err = yaml.Unmarshal(b, &projectConfigMap); err != nil {
+		util.Warning("Unable to unmarshal app: %v", err)
+	} else {
+		yamlMap["DdevProjectConfig"] = projectConfigMap
+	}
+
+	dict, err := util.YamlToDict(yamlMap)
+	if err != nil {
+		return nil, fmt.Errorf("unable to YamlToDict: %v", err)
+	}
+	return dict, nil
+}
+
 // getInjectedEnvForBash returns bash export string for env variables
 // that will be used in PreInstallActions and PostInstallActions
 func getInjectedEnvForBash(app *DdevApp, installDesc InstallDesc) (string, error) {
@@ -740,12 +756,71 @@ func ListAvailableAddonsFromRegistry() ([]types.Addon, error) {
 	return addonData.Addons, nil
 }
 
-// getAddonRegistryWithFallback retrieves addon data from cache or downloads if stale
-// It respects the UpdateInterval setting from global config
+// getAddonRegistryWithFallback returns the add-ons of all the configured registries,
+// see globalconfig.GetAddonRegistries. An add-on found in several registries
+// comes from the one with the highest priority.
 func getAddonRegistryWithFallback() (*types.AddonData, error) {
+	return getAddonRegistryExcept("")
+}
+
+// getAddonRegistryExcept is getAddonRegistryWithFallback without the registry
+// in the excludeDir directory, if it's not empty
+func getAddonRegistryExcept(excludeDir string) (*types.AddonData, error) {
 	globalconfig.EnsureGlobalConfig()
+	var registries []globalconfig.AddonRegistry
+	for _, r := range globalconfig.GetAddonRegistries() {
+		if excludeDir == "" || !isAddonRegistryInDir(r, excludeDir) {
+			registries = append(registries, r)
+		}
+	}
+	if len(registries) == 0 {
+		return nil, fmt.Errorf("all the add-on registries are disabled in addon_registries")
+	}
+
+	merged := &types.AddonData{}
+	seen := map[string]bool{}
+	var errs []error
+	for _, r := range registries {
+		addonData, err := readAddonRegistry(r)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, addon := range addonData.Addons {
+			id := addon.User + "/" + addon.Repo
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			addon.Registry = r.Name
+			merged.Addons = append(merged.Addons, addon)
+			if addon.Type == "official" {
+				merged.OfficialAddonsCount++
+			} else {
+				merged.ContribAddonsCount++
+			}
+		}
+		if addonData.UpdatedDateTime.After(merged.UpdatedDateTime) {
+			merged.UpdatedDateTime = addonData.UpdatedDateTime
+		}
+	}
+	merged.TotalAddonsCount = len(merged.Addons)
+
+	if len(errs) == len(registries) {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		util.Warning("%v", err)
+	}
+	return merged, nil
+}
+
+// getCachedAddonRegistry retrieves the add-on data of a registry URL from
+// cache or downloads it if stale. It respects the UpdateInterval setting
+// from global config.
+func getCachedAddonRegistry(addonDataURL string, cacheName string) (*types.AddonData, error) {
 	globalDir := globalconfig.GetGlobalDdevDir()
-	cacheFile := filepath.Join(globalDir, ".addon-data")
+	cacheFile := filepath.Join(globalDir, cacheName)
 	addonStorage := storage.NewAddonFileStorage(cacheFile)
 
 	// Try to read from cache first
@@ -772,7 +847,7 @@ func getAddonRegistryWithFallback() (*types.AddonData, error) {
 	}
 
 	// Cache is stale or missing, try to download fresh data
-	freshData, downloadErr := downloadAddonRegistry()
+	freshData, downloadErr := downloadAddonRegistry(addonDataURL, cacheFile)
 	if downloadErr == nil {
 		return freshData, nil
 	}
@@ -787,17 +862,10 @@ func getAddonRegistryWithFallback() (*types.AddonData, error) {
 	return nil, fmt.Errorf("failed to download add-on registry and no cache available: %w", downloadErr)
 }
 
-// downloadAddonRegistry downloads the add-on registry from the configured URL
-// and caches it in the global config directory
-func downloadAddonRegistry() (*types.AddonData, error) {
+// downloadAddonRegistry downloads an add-on registry and caches it in cacheFile
+func downloadAddonRegistry(addonDataURL string, cacheFile string) (*types.AddonData, error) {
 	globalconfig.EnsureGlobalConfig()
 
-	// Get the addon data URL from global config, or use default
-	addonDataURL := globalconfig.DdevGlobalConfig.RemoteConfig.AddonDataURL
-	if addonDataURL == "" {
-		addonDataURL = globalconfig.DefaultAddonDataURL
-	}
-
 	// Create downloader
 	d := downloader.NewURLJSONCDownloader(addonDataURL)
 
@@ -816,8 +884,7 @@ func downloadAddonRegistry() (*types.AddonData, error) {
 	}
 
 	// Store in global config directory
-	globalDir := globalconfig.GetGlobalDdevDir()
-	addonStorage := storage.NewAddonFileStorage(filepath.Join(globalDir, ".addon-data"))
+	addonStorage := storage.NewAddonFileStorage(cacheFile)
 	err = addonStorage.Write(&addonData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to write add-on registry to cache: %w", err)
@@ -840,6 +907,12 @@ func downloadAddonRegistry() (*types.AddonData, error) {
 //   - version: The version string (tag, branch, or PR reference)
 //   - error: Any error encountered
 func GetAddonTarballURL(ownerRepo, gitRef string, defaultBranch bool, prNumber int) (tarballURL, version string, err error) {
+	return getAddonTarballURL(ownerRepo, gitRef, defaultBranch, prNumber, "")
+}
+
+// getAddonTarballURL is GetAddonTarballURL without the registry in the
+// excludeDir directory, if it's not empty
+func getAddonTarballURL(ownerRepo, gitRef string, defaultBranch bool, prNumber int, excludeDir string) (tarballURL, version string, err error) {
 	// Parse owner/repo
 	parts := strings.Split(ownerRepo, "/")
 	if len(parts) != 2 {
@@ -848,6 +921,14 @@ func GetAddonTarballURL(ownerRepo, gitRef string, defaultBranch bool, prNumber i
 	owner, repo := parts[0], parts[1]
 	baseURL := fmt.Sprintf("https://github.com/%s/%s/tarball", owner, repo)
 
+	// Add-ons of other registries are downloaded from them, unless they are
+	// in the public registry with a higher priority
+	if prNumber == 0 && !defaultBranch && globalconfig.HasCustomAddonRegistries() {
+		if tarballURL, version, ok := getAddonTarballURLFromRegistries(ownerRepo, gitRef, excludeDir); ok {
+			return tarballURL, version, nil
+		}
+	}
+
 	// If specific git ref is requested, use it directly
 	if gitRef != "" {
 		tarballURL = fmt.Sprintf("%s/%s", baseURL, gitRef)
@@ -863,7 +944,7 @@ func GetAddonTarballURL(ownerRepo, gitRef string, defaultBranch bool, prNumber i
 
 	// Use cached registry to get latest commit from default_branch
 	if defaultBranch {
-		addonData, err := getAddonRegistryWithFallback()
+		addonData, err := getAddonRegistryExcept(excludeDir)
 		if err != nil {
 			return "", "", fmt.Errorf("failed to get add-on registry: %w", err)
 		}
@@ -891,7 +972,7 @@ func GetAddonTarballURL(ownerRepo, gitRef string, defaultBranch bool, prNumber i
 
 	// GitHub API failed (rate limit, network, etc.), fall back to cached registry
 	util.Warning("Warning: %v\nFalling back to cached add-on registry...", err)
-	addonData, cacheErr := getAddonRegistryWithFallback()
+	addonData, cacheErr := getAddonRegistryExcept(excludeDir)
 	if cacheErr != nil {
 		// Both GitHub and cache failed, return the GitHub error
 		return "", "", err
@@ -999,6 +1080,7 @@ func RemoveAddon(app *DdevApp, addonName string, verbose bool, skipRemovalAction
 				util.Warning("could not process removal action (%d) '%s': %v", i, desc, err)
 			}
 		}
+		RunAddonRemovalActions(app, manifestData.GeneratedRemovalActions)
 	}
 
 	// Remove any project files
@@ -1041,6 +1123,10 @@ func RemoveAddon(app *DdevApp, addonName string, verbose bool, skipRemovalAction
 	if err != nil {
 		return fmt.Errorf("error removing addon metadata directory %s: %v", manifestData.Name, err)
 	}
+	err = RemoveAddonFromLock(app, manifestData.Name)
+	if err != nil {
+		return fmt.Errorf("error removing %s from %s: %v", manifestData.Name, AddonLockFile, err)
+	}
 	util.Success("Removed add-on %s", addonName)
 	return nil
 }
@@ -1212,6 +1298,7 @@ func installAddonRecursive(app *DdevApp, addonName string, verbose bool) error {
 	parts := strings.Split(addonName, "/")
 	extractedDir := ""
 	tarballURL := ""
+	checksum := ""
 	var cleanup func()
 
 	switch {
@@ -1235,7 +1322,7 @@ func installAddonRecursive(app *DdevApp, addonName string, verbose bool) error {
 	case strings.HasPrefix(addonName, "http://") || strings.HasPrefix(addonName, "https://"):
 		tarballURL = addonName
 		var err error
-		extractedDir, cleanup, err = archive.DownloadAndExtractTarball(tarballURL, true)
+		extractedDir, cleanup, checksum, err = archive.DownloadAndExtractTarballWithChecksum(tarballURL, true)
 		if err != nil {
 			return fmt.Errorf("unable to download %v: %v", addonName, err)
 		}
@@ -1251,7 +1338,7 @@ func installAddonRecursive(app *DdevApp, addonName string, verbose bool) error {
 
 	// If we have a local extraction, handle it directly
 	if extractedDir != "" {
-		return InstallAddonFromDirectory(app, extractedDir, addonName, "unknown", verbose)
+		return installAddonFromDirectory(app, extractedDir, addonName, "unknown", tarballURL, checksum, verbose)
 	}
 
 	return fmt.Errorf("no extraction directory available for addon: %s", addonName)
@@ -1298,6 +1385,12 @@ func InstallAddonFromGitHub(app *DdevApp, addonName, requestedVersion string, ve
 
 // InstallAddonFromDirectory handles installation from a local directory
 func InstallAddonFromDirectory(app *DdevApp, extractedDir, repository, version string, verbose bool) error {
+	return installAddonFromDirectory(app, extractedDir, repository, version, "", "", verbose)
+}
+
+// installAddonFromDirectory installs an add-on extracted in a directory and
+// records it in the lockfile with the tarball it came from, if any
+func installAddonFromDirectory(app *DdevApp, extractedDir, repository, version, tarballURL, checksum string, verbose bool) error {
 	// Parse install.yaml
 	yamlFile := filepath.Join(extractedDir, "install.yaml")
 	yamlContent, err := fileutil.ReadFileIntoString(yamlFile)
@@ -1318,6 +1411,12 @@ func InstallAddonFromDirectory(app *DdevApp, extractedDir, repository, version s
 		}
 	}
 
+	// Check the actions before changing anything, including installing the dependencies
+	_, err = PlanAddonActions(app, extractedDir, s)
+	if err != nil {
+		return fmt.Errorf("unable to install the '%s' add-on: %v", s.Name, err)
+	}
+
 	// Install dependencies - dependencies must be GitHub owner/repo format or URLs
 	if len(s.Dependencies) > 0 {
 		// Validate dependencies are in supported formats
@@ -1422,6 +1521,11 @@ func InstallAddonFromDirectory(app *DdevApp, extractedDir, repository, version s
 		return fmt.Errorf("unable to chdir to %v: %v", app.GetConfigPath(""), err)
 	}
 
+	removals, err := RunAddonActions(app, extractedDir, s)
+	if err != nil {
+		return err
+	}
+
 	// Run post-install actions
 	if len(s.PostInstallActions) > 0 {
 		util.Success("\nExecuting post-install actions:")
@@ -1445,26 +1549,38 @@ func InstallAddonFromDirectory(app *DdevApp, extractedDir, repository, version s
 	}
 
 	// Create manifest file for tracking this installation
-	err = createAddonManifest(app, s.Name, repository, version, s)
+	err = createAddonManifest(app, s.Name, repository, version, s, removals)
 	if err != nil {
 		return fmt.Errorf("failed to create addon manifest: %v", err)
 	}
+	err = RecordAddonInLock(app, AddonLockEntry{
+		Name:         s.Name,
+		Repository:   repository,
+		Version:      version,
+		TarballURL:   tarballURL,
+		Checksum:     checksum,
+		Dependencies: s.Dependencies,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %v", AddonLockFile, err)
+	}
 
 	util.Success("Successfully installed %s from directory", s.Name)
 	return nil
 }
 
 // createAddonManifest creates a manifest file for tracking addon installation
-func createAddonManifest(app *DdevApp, addonName, repository, version string, desc InstallDesc) error {
+func createAddonManifest(app *DdevApp, addonName, repository, version string, desc InstallDesc, generatedRemovalActions []AddonAction) error {
 	manifest := AddonManifest{
-		Name:           addonName,
-		Repository:     repository,
-		Version:        version,
-		Dependencies:   desc.Dependencies,
-		InstallDate:    time.Now().Format(time.RFC3339),
-		ProjectFiles:   desc.ProjectFiles,
-		GlobalFiles:    desc.GlobalFiles,
-		RemovalActions: desc.RemovalActions,
+		Name:                    addonName,
+		Repository:              repository,
+		Version:                 version,
+		Dependencies:            desc.Dependencies,
+		InstallDate:             time.Now().Format(time.RFC3339),
+		ProjectFiles:            desc.ProjectFiles,
+		GlobalFiles:             desc.GlobalFiles,
+		RemovalActions:          desc.RemovalActions,
+		GeneratedRemovalActions: generatedRemovalActions,
 	}
 
 	manifestFile := app.GetConfigPath(fmt.Sprintf("%s/%s/manifest.yaml", AddonMetadataDir, addonName))
@@ -1489,14 +1605,14 @@ func createAddonManifest(app *DdevApp, addonName, repository, version string, de
 // InstallAddonFromTarball handles complete installation process for tarball-based addons
 func InstallAddonFromTarball(app *DdevApp, tarballURL, downloadedRelease, repository string, verbose bool) error {
 	// Extract tarball
-	extractedDir, cleanup, err := archive.DownloadAndExtractTarball(tarballURL, true)
+	extractedDir, cleanup, checksum, err := archive.DownloadAndExtractTarballWithChecksum(tarballURL, true)
 	if err != nil {
 		return fmt.Errorf("unable to download %v: %v", tarballURL, err)
 	}
 	defer cleanup()
 
 	// Use the directory installation method for complete processing
-	return InstallAddonFromDirectory(app, extractedDir, repository, downloadedRelease, verbose)
+	return installAddonFromDirectory(app, extractedDir, repository, downloadedRelease, tarballURL, checksum, verbose)
 }
 
 // ProcessRuntimeDependencies looks for a runtime dependency file generated during
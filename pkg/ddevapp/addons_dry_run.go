@@ -0,0 +1,292 @@
+package ddevapp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ddev/ddev/pkg/archive"
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/nodeps"
+	"go.yaml.in/yaml/v4"
+)
+
+// The statuses of the files of an install plan
+const (
+	// AddonPlanFileCreate is a file that doesn't exist yet
+	AddonPlanFileCreate = "create"
+	// AddonPlanFileOverwrite is an existing file with #ddev-generated that is replaced
+	AddonPlanFileOverwrite = "overwrite"
+	// AddonPlanFileModify is an existing file changed by an action
+	AddonPlanFileModify = "modify"
+	// AddonPlanFileKeep is an existing file without #ddev-generated, which is not overwritten
+	AddonPlanFileKeep = "keep"
+)
+
+// AddonInstallPlan describes everything that installing an add-on would do,
+// for 'ddev add-on get --dry-run'
+type AddonInstallPlan struct {
+	Name                  string `json:"name"`
+	Repository            string `json:"repository"`
+	Version               string `json:"version,omitempty"`
+	DdevVersionConstraint string `json:"ddev_version_constraint,omitempty"`
+	// ProjectFiles are relative to the project root
+	ProjectFiles []AddonFilePlan `json:"project_files"`
+	// GlobalFiles are relative to the global .ddev directory
+	GlobalFiles        []AddonFilePlan    `json:"global_files"`
+	PreInstallActions  []AddonScriptPlan  `json:"pre_install_actions"`
+	Actions            []AddonActionPlan  `json:"actions"`
+	PostInstallActions []AddonScriptPlan  `json:"post_install_actions"`
+	RemovalActions     []AddonScriptPlan  `json:"removal_actions"`
+	Images             []string           `json:"images"`
+	Dependencies       []AddonInstallPlan `json:"dependencies"`
+	InstalledDeps      []string           `json:"installed_dependencies"`
+	SkippedDeps        []string           `json:"skipped_dependencies,omitempty"`
+	Warnings           []string           `json:"warnings,omitempty"`
+	seen               map[string]struct{}
+}
+
+// AddonFilePlan is a file an add-on would write
+type AddonFilePlan struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// AddonScriptPlan is a pre-install, post-install or removal script of an add-on
+type AddonScriptPlan struct {
+	Description string `json:"description"`
+	// Interpreter is "bash" for scripts run on the host, "php" for scripts run in a container
+	Interpreter string `json:"interpreter"`
+	// Image is the image the PHP scripts are run in
+	Image  string `json:"image,omitempty"`
+	Script string `json:"script"`
+}
+
+// PlanAddonInstall describes what installing the add-on extracted in
+// extractedDir would do, without changing anything. Unless skipDeps is true,
+// the dependencies that aren't installed yet are downloaded and planned too.
+func PlanAddonInstall(app *DdevApp, extractedDir, repository, version string, skipDeps bool) (*AddonInstallPlan, error) {
+	manifests, err := GatherAllManifests(app)
+	if err != nil {
+		return nil, fmt.Errorf("unable to gather manifests: %v", err)
+	}
+	return planAddonInstall(app, extractedDir, repository, version, skipDeps, manifests, map[string]bool{})
+}
+
+// planAddonInstall plans the installation of an add-on and of its dependencies
+func planAddonInstall(app *DdevApp, extractedDir, repository, version string, skipDeps bool, manifests map[string]AddonManifest, planned map[string]bool) (*AddonInstallPlan, error) {
+	yamlFile := filepath.Join(extractedDir, "install.yaml")
+	yamlContent, err := os.ReadFile(yamlFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %v: %v", yamlFile, err)
+	}
+	var s InstallDesc
+	if err = yaml.Unmarshal(yamlContent, &s); err != nil {
+		return nil, fmt.Errorf("unable to parse %v: %v", yamlFile, err)
+	}
+
+	plan := &AddonInstallPlan{
+		Name:                  s.Name,
+		Repository:            repository,
+		Version:               version,
+		DdevVersionConstraint: s.DdevVersionConstraint,
+		ProjectFiles:          []AddonFilePlan{},
+		GlobalFiles:           []AddonFilePlan{},
+		Images:                []string{},
+		Dependencies:          []AddonInstallPlan{},
+		InstalledDeps:         []string{},
+		seen:                  map[string]struct{}{},
+	}
+	if s.DdevVersionConstraint != "" {
+		if err = CheckDdevVersionConstraint(s.DdevVersionConstraint, fmt.Sprintf("Unable to install the '%s' add-on", s.Name), ""); err != nil {
+			plan.Warnings = append(plan.Warnings, err.Error())
+		}
+	}
+
+	projectFiles, err := fileutil.ExpandFilesAndDirectories(extractedDir, s.ProjectFiles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to expand files and directories: %v", err)
+	}
+	for _, file := range projectFiles {
+		plan.addProjectFile(filepath.ToSlash(filepath.Join(".ddev", file)), addonFileStatus(app.GetConfigPath(file)))
+		plan.addImages(filepath.Join(extractedDir, file))
+	}
+	globalFiles, err := fileutil.ExpandFilesAndDirectories(extractedDir, s.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to expand global files and directories: %v", err)
+	}
+	for _, file := range globalFiles {
+		plan.GlobalFiles = append(plan.GlobalFiles, AddonFilePlan{
+			Path:   filepath.ToSlash(file),
+			Status: addonFileStatus(filepath.Join(globalconfig.GetGlobalDdevDir(), file)),
+		})
+		plan.addImages(filepath.Join(extractedDir, file))
+	}
+
+	plan.Actions, err = PlanAddonActions(app, extractedDir, s)
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("The actions would fail: %v", err))
+	}
+	for _, a := range plan.Actions {
+		for _, file := range a.Files {
+			status := addonFileStatus(filepath.Join(app.AppRoot, file))
+			switch {
+			case a.Skipped:
+				status = AddonPlanFileKeep
+			case status != AddonPlanFileCreate:
+				status = AddonPlanFileModify
+			}
+			plan.addProjectFile(file, status)
+		}
+	}
+
+	plan.PreInstallActions = planAddonScripts(app, s, s.PreInstallActions)
+	plan.PostInstallActions = planAddonScripts(app, s, s.PostInstallActions)
+	plan.RemovalActions = planAddonScripts(app, s, s.RemovalActions)
+	for _, script := range slices.Concat(plan.PreInstallActions, plan.PostInstallActions, plan.RemovalActions) {
+		if script.Image != "" && !slices.Contains(plan.Images, script.Image) {
+			plan.Images = append(plan.Images, script.Image)
+		}
+	}
+
+	for _, dep := range s.Dependencies {
+		dep = strings.TrimSpace(dep)
+		switch {
+		case dep == "":
+			continue
+		case skipDeps:
+			plan.SkippedDeps = append(plan.SkippedDeps, dep)
+		case isDependencyInstalled(manifests, dep):
+			plan.InstalledDeps = append(plan.InstalledDeps, dep)
+		case planned[NormalizeAddonIdentifier(dep)]:
+			continue
+		default:
+			planned[NormalizeAddonIdentifier(dep)] = true
+			depPlan, err := planAddonDependency(app, dep, manifests, planned)
+			if err != nil {
+				return nil, fmt.Errorf("unable to plan the dependency '%s': %v", dep, err)
+			}
+			plan.Dependencies = append(plan.Dependencies, *depPlan)
+		}
+	}
+	return plan, nil
+}
+
+// planAddonDependency downloads a dependency of an add-on and plans its installation
+func planAddonDependency(app *DdevApp, dep string, manifests map[string]AddonManifest, planned map[string]bool) (*AddonInstallPlan, error) {
+	var extractedDir, version string
+	var cleanup func()
+	var err error
+	switch {
+	case fileutil.IsDirectory(dep):
+		extractedDir = dep
+	case strings.HasPrefix(dep, "http://") || strings.HasPrefix(dep, "https://"):
+		extractedDir, cleanup, err = archive.DownloadAndExtractTarball(dep, true)
+	case IsGithubRef(dep):
+		var tarballURL string
+		tarballURL, version, err = GetAddonTarballURL(dep, "", false, 0)
+		if err == nil {
+			extractedDir, cleanup, err = archive.DownloadAndExtractTarball(tarballURL, true)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported dependency format: %s (must be owner/repo, /path/to/addon, or https://...)", dep)
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return planAddonInstall(app, extractedDir, dep, version, false, manifests, planned)
+}
+
+// addProjectFile adds a file to the project files of a plan, once
+func (plan *AddonInstallPlan) addProjectFile(path string, status string) {
+	if _, ok := plan.seen[path]; ok {
+		return
+	}
+	plan.seen[path] = struct{}{}
+	plan.ProjectFiles = append(plan.ProjectFiles, AddonFilePlan{Path: path, Status: status})
+}
+
+// addImages adds the images used by a docker-compose file or a Dockerfile of an add-on to a plan
+func (plan *AddonInstallPlan) addImages(path string) {
+	var images []string
+	base := filepath.Base(path)
+	switch {
+	case strings.HasPrefix(base, "docker-compose.") && (strings.HasSuffix(base, ".yaml") || strings.HasSuffix(base, ".yml")):
+		var compose struct {
+			Services map[string]struct {
+				Image string `yaml:"image"`
+			} `yaml:"services"`
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || yaml.Unmarshal(content, &compose) != nil {
+			return
+		}
+		for _, service := range compose.Services {
+			if service.Image != "" {
+				images = append(images, service.Image)
+			}
+		}
+		slices.Sort(images)
+	case strings.HasPrefix(base, "Dockerfile"):
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && strings.EqualFold(fields[0], "FROM") {
+				image := fields[1]
+				// Skip options like --platform
+				for i := 1; i < len(fields) && strings.HasPrefix(fields[i], "--"); i++ {
+					if i+1 < len(fields) {
+						image = fields[i+1]
+					}
+				}
+				images = append(images, image)
+			}
+		}
+	}
+	for _, image := range images {
+		if !slices.Contains(plan.Images, image) {
+			plan.Images = append(plan.Images, image)
+		}
+	}
+}
+
+// addonFileStatus returns what installing a file of an add-on at path would do
+func addonFileStatus(path string) string {
+	switch {
+	case !fileutil.FileExists(path):
+		return AddonPlanFileCreate
+	case fileutil.CheckSignatureOrNoFile(path, nodeps.DdevFileSignature) == nil:
+		return AddonPlanFileOverwrite
+	default:
+		return AddonPlanFileKeep
+	}
+}
+
+// planAddonScripts describes the scripts of an add-on
+func planAddonScripts(app *DdevApp, s InstallDesc, scripts []string) []AddonScriptPlan {
+	plans := []AddonScriptPlan{}
+	for _, script := range scripts {
+		p := AddonScriptPlan{Description: strings.TrimSpace(GetAddonDdevDescription(script)), Interpreter: "bash", Script: script}
+		if strings.HasPrefix(strings.TrimSpace(script), "<?php") {
+			p.Interpreter = "php"
+			p.Image = s.Image
+			if p.Image == "" {
+				p.Image = app.WebImage
+			}
+		}
+		plans = append(plans, p)
+	}
+	return plans
+}
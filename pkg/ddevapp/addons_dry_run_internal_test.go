@@ -0,0 +1,96 @@
+package ddevapp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPlanAddonInstall checks that a dry run describes the installation of
+// an add-on and of its dependencies without changing anything
+func TestPlanAddonInstall(t *testing.T) {
+	assert := asrt.New(t)
+
+	app := &DdevApp{Name: "dryrun", AppRoot: t.TempDir(), Type: "php", WebImage: "ddev/ddev-webserver:test"}
+	require.NoError(t, os.MkdirAll(app.GetConfigPath(""), 0755))
+	// An existing file without #ddev-generated is kept
+	require.NoError(t, os.WriteFile(app.GetConfigPath("test.conf"), []byte("custom\n"), 0644))
+
+	files := map[string]string{
+		"addon/install.yaml": `name: test
+project_files:
+  - docker-compose.test.yaml
+  - test.conf
+  - web-build/Dockerfile.test
+global_files:
+  - commands/host/test-global
+pre_install_actions:
+  - |
+    #ddev-description: Check requirements
+    true
+post_install_actions:
+  - |
+    <?php
+    #ddev-description: Configure settings
+    echo "ok";
+actions:
+  - set_env:
+      vars:
+        TEST_HOST: test
+dependencies:
+  - DEP_DIR
+`,
+		"addon/docker-compose.test.yaml":  "#ddev-generated\nservices:\n  test:\n    image: busybox:stable\n",
+		"addon/test.conf":                 "#ddev-generated\n",
+		"addon/web-build/Dockerfile.test": "#ddev-generated\nFROM --platform=linux/amd64 node:22 AS build\n",
+		"addon/commands/host/test-global": "#ddev-generated\n",
+		"dep/install.yaml":                "name: dep\nproject_files:\n  - docker-compose.dep.yaml\n",
+		"dep/docker-compose.dep.yaml":     "#ddev-generated\nservices:\n  dep:\n    image: redis:7\n",
+	}
+	srcDir := t.TempDir()
+	depDir := filepath.Join(srcDir, "dep")
+	for name, content := range files {
+		path := filepath.Join(srcDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		if name == "addon/install.yaml" {
+			content = strings.ReplaceAll(content, "DEP_DIR", depDir)
+		}
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	plan, err := PlanAddonInstall(app, filepath.Join(srcDir, "addon"), "owner/ddev-test", "v1.0.0", false)
+	require.NoError(t, err)
+	assert.Equal("test", plan.Name)
+	assert.Empty(plan.Warnings)
+	assert.Equal([]AddonFilePlan{
+		{Path: ".ddev/docker-compose.test.yaml", Status: AddonPlanFileCreate},
+		{Path: ".ddev/test.conf", Status: AddonPlanFileKeep},
+		{Path: ".ddev/web-build/Dockerfile.test", Status: AddonPlanFileCreate},
+		{Path: ".ddev/.env", Status: AddonPlanFileCreate},
+	}, plan.ProjectFiles)
+	require.Len(t, plan.GlobalFiles, 1)
+	assert.Equal("commands/host/test-global", plan.GlobalFiles[0].Path)
+	require.Len(t, plan.PreInstallActions, 1)
+	assert.Equal("bash", plan.PreInstallActions[0].Interpreter)
+	assert.Equal("Check requirements", plan.PreInstallActions[0].Description)
+	require.Len(t, plan.PostInstallActions, 1)
+	assert.Equal("php", plan.PostInstallActions[0].Interpreter)
+	assert.Equal("ddev/ddev-webserver:test", plan.PostInstallActions[0].Image)
+	assert.Equal([]string{"busybox:stable", "node:22", "ddev/ddev-webserver:test"}, plan.Images)
+	require.Len(t, plan.Dependencies, 1)
+	assert.Equal("dep", plan.Dependencies[0].Name)
+	assert.Equal([]string{"redis:7"}, plan.Dependencies[0].Images)
+
+	// Nothing was installed
+	assert.NoFileExists(app.GetConfigPath("docker-compose.test.yaml"))
+	assert.NoFileExists(app.GetConfigPath(".env"))
+
+	plan, err = PlanAddonInstall(app, filepath.Join(srcDir, "addon"), "owner/ddev-test", "v1.0.0", true)
+	require.NoError(t, err)
+	assert.Empty(plan.Dependencies)
+	assert.Equal([]string{depDir}, plan.SkippedDeps)
+}
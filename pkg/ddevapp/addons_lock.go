@@ -0,0 +1,210 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ddev/ddev/pkg/archive"
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/util"
+	"go.yaml.in/yaml/v4"
+)
+
+// AddonLockFile is the lockfile of the add-ons installed in the project, in .ddev
+const AddonLockFile = "addons.lock.yaml"
+
+// addonLockHeader is written at the top of the lockfile
+const addonLockHeader = `# This file records the add-ons installed in this project, it is updated by
+# 'ddev add-on get' and 'ddev add-on remove'. Commit it so that teammates can
+# install exactly the same add-ons with 'ddev add-on install --from-lock'.
+`
+
+// AddonLock is the content of .ddev/addons.lock.yaml
+type AddonLock struct {
+	Addons []AddonLockEntry `yaml:"addons"`
+}
+
+// AddonLockEntry is an add-on recorded in the lockfile
+type AddonLockEntry struct {
+	Name       string `yaml:"name"`
+	Repository string `yaml:"repository"`
+	Version    string `yaml:"version,omitempty"`
+	TarballURL string `yaml:"tarball_url,omitempty"`
+	// Checksum is the checksum of the tarball, "sha256:<hex>"
+	Checksum string `yaml:"checksum,omitempty"`
+	// Dependencies are the add-ons this one depends on, recorded in the lockfile too
+	Dependencies []string `yaml:"dependencies,omitempty"`
+}
+
+// isLockableAddonSource reports whether an add-on installed from repository
+// can be installed again elsewhere, which isn't the case of local paths
+func isLockableAddonSource(repository string) bool {
+	return IsGithubRef(repository) || strings.HasPrefix(repository, "https://") || strings.HasPrefix(repository, "http://")
+}
+
+// ReadAddonLock reads the lockfile of the project, which is empty if it doesn't exist
+func ReadAddonLock(app *DdevApp) (*AddonLock, error) {
+	lock := &AddonLock{}
+	lockPath := app.GetConfigPath(AddonLockFile)
+	if !fileutil.FileExists(lockPath) {
+		return lock, nil
+	}
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", lockPath, err)
+	}
+	return lock, nil
+}
+
+// WriteAddonLock writes the lockfile of the project, with the add-ons sorted by name
+func WriteAddonLock(app *DdevApp, lock *AddonLock) error {
+	sort.Slice(lock.Addons, func(i, j int) bool {
+		return lock.Addons[i].Name < lock.Addons[j].Name
+	})
+	var out strings.Builder
+	out.WriteString(addonLockHeader)
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(lock); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(app.GetConfigPath(AddonLockFile), []byte(out.String()), 0644)
+}
+
+// RecordAddonInLock adds or replaces an add-on in the lockfile of the project.
+// Add-ons installed from local paths are not recorded.
+func RecordAddonInLock(app *DdevApp, entry AddonLockEntry) error {
+	if !isLockableAddonSource(entry.Repository) {
+		util.Warning("The '%s' add-on is not recorded in %s because it was installed from a local path", entry.Name, AddonLockFile)
+		return nil
+	}
+	lock, err := ReadAddonLock(app)
+	if err != nil {
+		return err
+	}
+	lock.Addons = slices.DeleteFunc(lock.Addons, func(e AddonLockEntry) bool { return e.Name == entry.Name })
+	lock.Addons = append(lock.Addons, entry)
+	return WriteAddonLock(app, lock)
+}
+
+// RemoveAddonFromLock removes an add-on from the lockfile of the project
+func RemoveAddonFromLock(app *DdevApp, name string) error {
+	lock, err := ReadAddonLock(app)
+	if err != nil {
+		return err
+	}
+	before := len(lock.Addons)
+	lock.Addons = slices.DeleteFunc(lock.Addons, func(e AddonLockEntry) bool { return e.Name == name })
+	if len(lock.Addons) == before {
+		return nil
+	}
+	return WriteAddonLock(app, lock)
+}
+
+// sortAddonLockEntries orders the entries of a lockfile so that the
+// dependencies of an add-on come before it
+func sortAddonLockEntries(entries []AddonLockEntry) ([]AddonLockEntry, error) {
+	byID := map[string]int{}
+	for i, e := range entries {
+		byID[e.Name] = i
+		byID[NormalizeAddonIdentifier(e.Repository)] = i
+		byID[e.Repository] = i
+	}
+
+	var sorted []AddonLockEntry
+	state := make([]int, len(entries)) // 0 not visited, 1 visiting, 2 done
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("circular dependency detected: %s", strings.Join(append(path, entries[i].Name), " -> "))
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		for _, dep := range entries[i].Dependencies {
+			j, ok := byID[dep]
+			if !ok {
+				j, ok = byID[NormalizeAddonIdentifier(dep)]
+			}
+			if !ok {
+				return fmt.Errorf("the '%s' add-on depends on '%s', which is not in %s", entries[i].Name, dep, AddonLockFile)
+			}
+			if err := visit(j, append(path, entries[i].Name)); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		sorted = append(sorted, entries[i])
+		return nil
+	}
+
+	for i := range entries {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// InstallAddonsFromLock installs the add-ons of the lockfile of the project
+// at their recorded versions, dependencies first. It fails when a tarball
+// doesn't match its recorded checksum.
+func InstallAddonsFromLock(app *DdevApp, verbose bool) error {
+	if !fileutil.FileExists(app.GetConfigPath(AddonLockFile)) {
+		return fmt.Errorf("there is no %s in this project", app.GetConfigPath(AddonLockFile))
+	}
+	lock, err := ReadAddonLock(app)
+	if err != nil {
+		return err
+	}
+	entries, err := sortAddonLockEntries(lock.Addons)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		manifests, err := GatherAllManifests(app)
+		if err != nil {
+			return fmt.Errorf("unable to gather manifests: %v", err)
+		}
+		if m, ok := manifests[entry.Name]; ok && m.Version == entry.Version && m.Repository == entry.Repository {
+			util.Success("%s %s is already installed", entry.Name, entry.Version)
+			continue
+		}
+		if entry.TarballURL == "" {
+			return fmt.Errorf("the '%s' add-on has no tarball_url in %s", entry.Name, AddonLockFile)
+		}
+
+		util.Success("Installing %s:%s", entry.Repository, entry.Version)
+		if err = installAddonFromLockEntry(app, entry, verbose); err != nil {
+			return fmt.Errorf("unable to install '%s': %v", entry.Name, err)
+		}
+	}
+	return nil
+}
+
+// installAddonFromLockEntry downloads the tarball of an add-on of the lockfile,
+// checks its checksum and installs it
+func installAddonFromLockEntry(app *DdevApp, entry AddonLockEntry, verbose bool) error {
+	extractedDir, cleanup, checksum, err := archive.DownloadAndExtractTarballWithChecksum(entry.TarballURL, true)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to download %v: %v", entry.TarballURL, err)
+	}
+	if entry.Checksum != "" && checksum != entry.Checksum {
+		return fmt.Errorf("the tarball has changed upstream: %s has checksum %s but %s records %s", entry.TarballURL, checksum, AddonLockFile, entry.Checksum)
+	}
+	return installAddonFromDirectory(app, extractedDir, entry.Repository, entry.Version, entry.TarballURL, checksum, verbose)
+}
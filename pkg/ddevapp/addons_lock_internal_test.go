@@ -0,0 +1,114 @@
+package ddevapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ddev/ddev/pkg/archive"
+	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddonLock checks the recording of add-ons in .ddev/addons.lock.yaml
+func TestAddonLock(t *testing.T) {
+	assert := asrt.New(t)
+
+	app := &DdevApp{AppRoot: t.TempDir()}
+	require.NoError(t, os.MkdirAll(app.GetConfigPath(""), 0755))
+
+	lock, err := ReadAddonLock(app)
+	require.NoError(t, err)
+	assert.Empty(lock.Addons)
+
+	redisCommander := AddonLockEntry{
+		Name:         "redis-commander",
+		Repository:   "ddev/ddev-redis-commander",
+		Version:      "v2.1.0",
+		TarballURL:   "https://github.com/ddev/ddev-redis-commander/tarball/v2.1.0",
+		Checksum:     "sha256:abc",
+		Dependencies: []string{"ddev/ddev-redis"},
+	}
+	redis := AddonLockEntry{
+		Name:       "redis",
+		Repository: "ddev/ddev-redis",
+		Version:    "v2.0.0",
+		TarballURL: "https://github.com/ddev/ddev-redis/tarball/v2.0.0",
+		Checksum:   "sha256:def",
+	}
+	require.NoError(t, RecordAddonInLock(app, redisCommander))
+	require.NoError(t, RecordAddonInLock(app, redis))
+	// Add-ons from local paths can't be reproduced
+	require.NoError(t, RecordAddonInLock(app, AddonLockEntry{Name: "local", Repository: "/tmp/local-addon"}))
+
+	// A new version replaces the recorded one
+	redis.Version = "v2.1.0"
+	require.NoError(t, RecordAddonInLock(app, redis))
+
+	lock, err = ReadAddonLock(app)
+	require.NoError(t, err)
+	assert.Equal([]AddonLockEntry{redis, redisCommander}, lock.Addons)
+
+	content, err := os.ReadFile(app.GetConfigPath(AddonLockFile))
+	require.NoError(t, err)
+	assert.Contains(string(content), "ddev add-on install --from-lock")
+	assert.Contains(string(content), "\n  - name: redis\n    repository: ddev/ddev-redis\n")
+
+	require.NoError(t, RemoveAddonFromLock(app, "redis-commander"))
+	lock, err = ReadAddonLock(app)
+	require.NoError(t, err)
+	assert.Equal([]AddonLockEntry{redis}, lock.Addons)
+}
+
+// TestSortAddonLockEntries checks that dependencies are installed first from the lockfile
+func TestSortAddonLockEntries(t *testing.T) {
+	assert := asrt.New(t)
+
+	entries := []AddonLockEntry{
+		{Name: "redis-insight", Repository: "ddev/ddev-redis-insight", Dependencies: []string{"ddev/ddev-redis-commander"}},
+		{Name: "redis-commander", Repository: "ddev/ddev-redis-commander", Dependencies: []string{"ddev/ddev-redis"}},
+		{Name: "solr", Repository: "ddev/ddev-solr"},
+		{Name: "redis", Repository: "ddev/ddev-redis"},
+	}
+	sorted, err := sortAddonLockEntries(entries)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range sorted {
+		names = append(names, e.Name)
+	}
+	assert.Equal([]string{"redis", "redis-commander", "redis-insight", "solr"}, names)
+
+	_, err = sortAddonLockEntries(entries[:2])
+	assert.ErrorContains(err, "depends on 'ddev/ddev-redis', which is not in addons.lock.yaml")
+
+	_, err = sortAddonLockEntries([]AddonLockEntry{
+		{Name: "a", Repository: "owner/a", Dependencies: []string{"owner/b"}},
+		{Name: "b", Repository: "owner/b", Dependencies: []string{"owner/a"}},
+	})
+	assert.ErrorContains(err, "circular dependency detected: a -> b -> a")
+}
+
+// TestInstallAddonFromLockEntryChecksum checks that a tarball that changed upstream is rejected
+func TestInstallAddonFromLockEntryChecksum(t *testing.T) {
+	tarball := filepath.Join("testdata", "TestInstallAddonFromLockEntryChecksum", "addon.tar.gz")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, tarball)
+	}))
+	defer server.Close()
+	checksum, err := archive.TarballChecksum(tarball)
+	require.NoError(t, err)
+	require.NotEqual(t, "sha256:0000", checksum)
+
+	app := &DdevApp{AppRoot: t.TempDir()}
+	err = installAddonFromLockEntry(app, AddonLockEntry{
+		Name:       "test",
+		Repository: "ddev/ddev-test",
+		Version:    "v1.0.0",
+		TarballURL: server.URL + "/addon.tar.gz",
+		Checksum:   "sha256:0000",
+	}, false)
+	require.ErrorContains(t, err, "the tarball has changed upstream")
+	require.ErrorContains(t, err, checksum)
+}
@@ -0,0 +1,364 @@
+package ddevapp
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ddev/ddev/pkg/archive"
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/nodeps"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/pmezard/go-difflib/difflib"
+	"go.yaml.in/yaml/v4"
+)
+
+// Statuses of a project file in an add-on update
+const (
+	AddonFileAdded     = "added"
+	AddonFileModified  = "modified"
+	AddonFileUnchanged = "unchanged"
+	AddonFileRemoved   = "removed"
+	// AddonFileMerged is a file without #ddev-generated whose local changes merge cleanly with the update
+	AddonFileMerged = "merged"
+	// AddonFileConflict is a file without #ddev-generated whose local changes conflict with the update
+	AddonFileConflict = "conflict"
+	// AddonFilePreserved is a file without #ddev-generated that is kept as it is
+	AddonFilePreserved = "preserved"
+)
+
+// AddonMergeConflictSuffix is appended to the name of a file with conflicting
+// local changes to write the merge result with conflict markers
+const AddonMergeConflictSuffix = ".ddev-merge"
+
+// AddonUpdate is an available update of an installed add-on
+type AddonUpdate struct {
+	Name             string `json:"name"`
+	Repository       string `json:"repository"`
+	InstalledVersion string `json:"installed_version"`
+	LatestVersion    string `json:"latest_version"`
+	TarballURL       string `json:"-"`
+	// Checksum is the checksum of the downloaded tarball of the latest version
+	Checksum string `json:"-"`
+}
+
+// IsOutdated reports whether the latest version of the add-on is newer than the installed one
+func (u AddonUpdate) IsOutdated() bool {
+	return IsAddonVersionNewer(u.InstalledVersion, u.LatestVersion)
+}
+
+// AddonFileChange is the change of a project file of an add-on in an update
+type AddonFileChange struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+	// Diff is the unified diff from the current file to the file after the update
+	Diff string `json:"diff,omitempty"`
+	// Content is what is written for a merged or conflicting file
+	Content []byte `json:"-"`
+}
+
+// IsAddonVersionNewer reports whether latest is newer than installed. Semantic
+// versions are compared, other versions like branch names are newer when they differ.
+func IsAddonVersionNewer(installed, latest string) bool {
+	if latest == "" {
+		return false
+	}
+	installedVersion, errInstalled := semver.NewVersion(installed)
+	latestVersion, errLatest := semver.NewVersion(latest)
+	if errInstalled == nil && errLatest == nil {
+		return latestVersion.GreaterThan(installedVersion)
+	}
+	return installed != latest
+}
+
+// GetAddonUpdate finds the latest release of an installed add-on, or the
+// requested version, which must come from a GitHub repository
+func GetAddonUpdate(manifest AddonManifest, requestedVersion string) (AddonUpdate, error) {
+	update := AddonUpdate{
+		Name:             manifest.Name,
+		Repository:       manifest.Repository,
+		InstalledVersion: manifest.Version,
+	}
+	if !IsGithubRef(manifest.Repository) {
+		return update, fmt.Errorf("the '%s' add-on was not installed from a GitHub repository (%s), use `ddev add-on get` to reinstall it", manifest.Name, manifest.Repository)
+	}
+	tarballURL, version, err := GetAddonTarballURL(manifest.Repository, requestedVersion, false, 0)
+	if err != nil {
+		return update, err
+	}
+	update.TarballURL = tarballURL
+	update.LatestVersion = version
+	return update, nil
+}
+
+// getAddonVersionTarballURL returns the tarball URL of the installed version of an add-on
+func getAddonVersionTarballURL(manifest AddonManifest) (string, error) {
+	if !IsGithubRef(manifest.Repository) || manifest.Version == "" {
+		return "", fmt.Errorf("the installed version of '%s' is unknown", manifest.Name)
+	}
+	if pr, ok := strings.CutPrefix(manifest.Version, "pr-"); ok {
+		if prNumber, err := strconv.Atoi(pr); err == nil {
+			tarballURL, _, err := GetAddonTarballURL(manifest.Repository, "", false, prNumber)
+			return tarballURL, err
+		}
+	}
+	tarballURL, _, err := GetAddonTarballURL(manifest.Repository, manifest.Version, false, 0)
+	return tarballURL, err
+}
+
+// PlanAddonUpdate compares the project files of an installed add-on with the
+// ones of the new version extracted in newDir. Files that lost their #ddev-generated
+// signature are three-way merged when the installed version of the add-on is
+// available in baseDir, and preserved otherwise.
+func PlanAddonUpdate(app *DdevApp, manifest AddonManifest, newDir string, baseDir string) ([]AddonFileChange, error) {
+	var desc InstallDesc
+	yamlContent, err := os.ReadFile(filepath.Join(newDir, "install.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(yamlContent, &desc); err != nil {
+		return nil, fmt.Errorf("unable to parse install.yaml: %v", err)
+	}
+	newFiles, err := fileutil.ExpandFilesAndDirectories(newDir, desc.ProjectFiles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to expand files and directories: %v", err)
+	}
+
+	var changes []AddonFileChange
+	for _, file := range newFiles {
+		newContent, err := os.ReadFile(filepath.Join(newDir, file))
+		if err != nil {
+			return nil, err
+		}
+		dest := app.GetConfigPath(file)
+		if !fileutil.FileExists(dest) {
+			changes = append(changes, AddonFileChange{File: file, Status: AddonFileAdded, Diff: unifiedDiff(file, nil, newContent)})
+			continue
+		}
+		current, err := os.ReadFile(dest)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(current, newContent) {
+			changes = append(changes, AddonFileChange{File: file, Status: AddonFileUnchanged})
+			continue
+		}
+		if fileutil.CheckSignatureOrNoFile(dest, nodeps.DdevFileSignature) == nil {
+			changes = append(changes, AddonFileChange{File: file, Status: AddonFileModified, Diff: unifiedDiff(file, current, newContent)})
+			continue
+		}
+
+		// The user took ownership of the file by removing #ddev-generated
+		change := AddonFileChange{File: file, Status: AddonFilePreserved}
+		if baseDir != "" {
+			if base, err := os.ReadFile(filepath.Join(baseDir, file)); err == nil {
+				merged, conflict := mergeAddonFile(current, base, newContent, manifest.Version, desc.Name)
+				change.Content = merged
+				change.Status = AddonFileMerged
+				if conflict {
+					change.Status = AddonFileConflict
+				}
+			}
+		}
+		if change.Status == AddonFileMerged {
+			change.Diff = unifiedDiff(file, current, change.Content)
+		} else {
+			change.Diff = unifiedDiff(file, current, newContent)
+		}
+		changes = append(changes, change)
+	}
+
+	// Files of the installed version that are no longer in the add-on
+	for _, file := range manifest.ProjectFiles {
+		if nodeps.ArrayContainsString(desc.ProjectFiles, file) {
+			continue
+		}
+		dest := app.GetConfigPath(file)
+		if !fileutil.FileExists(dest) {
+			continue
+		}
+		if fileutil.CheckSignatureOrNoFile(dest, nodeps.DdevFileSignature) != nil {
+			changes = append(changes, AddonFileChange{File: file, Status: AddonFilePreserved})
+			continue
+		}
+		change := AddonFileChange{File: file, Status: AddonFileRemoved}
+		if !fileutil.IsDirectory(dest) {
+			current, err := os.ReadFile(dest)
+			if err != nil {
+				return nil, err
+			}
+			change.Diff = unifiedDiff(file, current, nil)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// UpdateAddon installs the new version of an add-on extracted in newDir,
+// applying the changes planned by PlanAddonUpdate
+func UpdateAddon(app *DdevApp, manifest AddonManifest, update AddonUpdate, newDir string, changes []AddonFileChange, verbose bool) error {
+	// Files without #ddev-generated are left alone by the installation
+	err := installAddonFromDirectory(app, newDir, manifest.Repository, update.LatestVersion, update.TarballURL, update.Checksum, verbose)
+	if err != nil {
+		return err
+	}
+
+	// The files the new version doesn't have anymore are only removed once
+	// it is installed, so a failed update leaves the previous version in place
+	for _, change := range changes {
+		dest := app.GetConfigPath(change.File)
+		switch change.Status {
+		case AddonFileRemoved:
+			if err = os.RemoveAll(dest); err != nil {
+				return fmt.Errorf("unable to remove %s: %v", change.File, err)
+			}
+			util.Success("%c %s removed", '\U0001F5D1', change.File)
+		case AddonFileMerged:
+			if err = os.WriteFile(dest, change.Content, 0644); err != nil {
+				return fmt.Errorf("unable to write %s: %v", dest, err)
+			}
+			util.Success("Merged the update of %s with your changes", change.File)
+		case AddonFileConflict:
+			if err = os.WriteFile(dest+AddonMergeConflictSuffix, change.Content, 0644); err != nil {
+				return fmt.Errorf("unable to write %s: %v", dest+AddonMergeConflictSuffix, err)
+			}
+			util.Warning("Your changes to %s conflict with the update. It was not changed; resolve the conflicts in %s and replace it.", dest, dest+AddonMergeConflictSuffix)
+		}
+	}
+	return ProcessRuntimeDependencies(app, manifest.Name, verbose)
+}
+
+// DownloadAddonBase downloads and extracts the installed version of an add-on,
+// used as the base of three-way merges
+func DownloadAddonBase(manifest AddonManifest) (string, func(), error) {
+	tarballURL, err := getAddonVersionTarballURL(manifest)
+	if err != nil {
+		return "", func() {}, err
+	}
+	return archive.DownloadAndExtractTarball(tarballURL, true)
+}
+
+// unifiedDiff returns the unified diff of a file from a to b
+func unifiedDiff(file string, a, b []byte) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(a),
+		B:        splitLines(b),
+		FromFile: "a/" + file,
+		ToFile:   "b/" + file,
+		Context:  3,
+	})
+	return diff
+}
+
+// splitLines splits content in lines, keeping the line endings
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// mergeHunk replaces the lines from start to end of the base with lines
+type mergeHunk struct {
+	start, end int
+	lines      []string
+}
+
+// diffHunks returns the changes from base to other
+func diffHunks(base, other []string) []mergeHunk {
+	var hunks []mergeHunk
+	for _, op := range difflib.NewMatcher(base, other).GetOpCodes() {
+		if op.Tag != 'e' {
+			hunks = append(hunks, mergeHunk{start: op.I1, end: op.I2, lines: other[op.J1:op.J2]})
+		}
+	}
+	return hunks
+}
+
+// applyHunks returns base[start:end] with the hunks applied
+func applyHunks(base []string, start, end int, hunks []mergeHunk) []string {
+	var result []string
+	pos := start
+	for _, h := range hunks {
+		result = append(result, base[pos:h.start]...)
+		result = append(result, h.lines...)
+		pos = h.end
+	}
+	return append(result, base[pos:end]...)
+}
+
+// mergeAddonFile three-way merges the local changes to a file of an add-on
+// with the changes between its installed version (base) and the new one (other).
+// Overlapping changes are conflicts, marked as git does.
+func mergeAddonFile(local, base, other []byte, baseVersion, otherName string) ([]byte, bool) {
+	localLines, baseLines, otherLines := splitLines(local), splitLines(base), splitLines(other)
+	localHunks, otherHunks := diffHunks(baseLines, localLines), diffHunks(baseLines, otherLines)
+
+	var merged []string
+	conflict := false
+	pos, l, o := 0, 0, 0
+	for l < len(localHunks) || o < len(otherHunks) {
+		// Start a chunk with the first change, and extend it with the changes touching it
+		var start, end int
+		if o == len(otherHunks) || (l < len(localHunks) && localHunks[l].start <= otherHunks[o].start) {
+			start, end = localHunks[l].start, localHunks[l].end
+		} else {
+			start, end = otherHunks[o].start, otherHunks[o].end
+		}
+		lEnd, oEnd := l, o
+		for extended := true; extended; {
+			extended = false
+			if lEnd < len(localHunks) && localHunks[lEnd].start <= end {
+				end = max(end, localHunks[lEnd].end)
+				lEnd++
+				extended = true
+			}
+			if oEnd < len(otherHunks) && otherHunks[oEnd].start <= end {
+				end = max(end, otherHunks[oEnd].end)
+				oEnd++
+				extended = true
+			}
+		}
+
+		merged = append(merged, baseLines[pos:start]...)
+		localChunk := applyHunks(baseLines, start, end, localHunks[l:lEnd])
+		otherChunk := applyHunks(baseLines, start, end, otherHunks[o:oEnd])
+		switch {
+		case lEnd == l:
+			merged = append(merged, otherChunk...)
+		case oEnd == o, strings.Join(localChunk, "") == strings.Join(otherChunk, ""):
+			merged = append(merged, localChunk...)
+		default:
+			conflict = true
+			merged = append(merged, "<<<<<<< local\n")
+			merged = append(merged, withFinalNewline(localChunk)...)
+			merged = append(merged, fmt.Sprintf("||||||| %s\n", baseVersion))
+			merged = append(merged, withFinalNewline(baseLines[start:end])...)
+			merged = append(merged, "=======\n")
+			merged = append(merged, withFinalNewline(otherChunk)...)
+			merged = append(merged, fmt.Sprintf(">>>>>>> %s\n", otherName))
+		}
+		pos, l, o = end, lEnd, oEnd
+	}
+	merged = append(merged, baseLines[pos:]...)
+
+	return []byte(strings.Join(merged, "")), conflict
+}
+
+// withFinalNewline makes sure the last line ends with a newline, so a conflict marker can follow it
+func withFinalNewline(lines []string) []string {
+	if len(lines) == 0 || strings.HasSuffix(lines[len(lines)-1], "\n") {
+		return lines
+	}
+	out := append([]string{}, lines...)
+	out[len(out)-1] += "\n"
+	return out
+}
@@ -0,0 +1,125 @@
+package ddevapp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIsAddonVersionNewer checks the comparison of add-on versions
+func TestIsAddonVersionNewer(t *testing.T) {
+	assert := asrt.New(t)
+
+	assert.True(IsAddonVersionNewer("v1.2.0", "v1.10.0"))
+	assert.True(IsAddonVersionNewer("1.2.0", "v2.0.0"))
+	assert.False(IsAddonVersionNewer("v1.10.0", "v1.2.0"))
+	assert.False(IsAddonVersionNewer("v1.2.0", "v1.2.0"))
+	assert.False(IsAddonVersionNewer("v1.2.0", ""))
+	// Branches and PRs are replaced by the latest release
+	assert.True(IsAddonVersionNewer("main", "v1.2.0"))
+	assert.True(IsAddonVersionNewer("pr-54", "v1.2.0"))
+}
+
+// TestMergeAddonFile checks the three-way merge of the files without #ddev-generated
+func TestMergeAddonFile(t *testing.T) {
+	assert := asrt.New(t)
+
+	base := []byte("#ddev-generated\nservices:\n  redis:\n    image: redis:6\n    restart: always\n")
+
+	// Local and upstream change different lines
+	local := []byte("services:\n  redis:\n    image: redis:6\n    restart: always\n    mem_limit: 1g\n")
+	other := []byte("#ddev-generated\nservices:\n  redis:\n    image: redis:7\n    restart: always\n")
+	merged, conflict := mergeAddonFile(local, base, other, "v1.0.0", "redis")
+	assert.False(conflict)
+	assert.Equal("services:\n  redis:\n    image: redis:7\n    restart: always\n    mem_limit: 1g\n", string(merged))
+
+	// Both change the same line in the same way
+	merged, conflict = mergeAddonFile(other, base, other, "v1.0.0", "redis")
+	assert.False(conflict)
+	assert.Equal(string(other), string(merged))
+
+	// Both change the same line differently
+	local = []byte("services:\n  redis:\n    image: redis:6.2\n    restart: always\n")
+	merged, conflict = mergeAddonFile(local, base, other, "v1.0.0", "redis")
+	assert.True(conflict)
+	assert.Contains(string(merged), "<<<<<<< local\n    image: redis:6.2\n||||||| v1.0.0\n    image: redis:6\n=======\n    image: redis:7\n>>>>>>> redis\n")
+	assert.Contains(string(merged), "restart: always\n")
+}
+
+// TestPlanAddonUpdate checks the changes of the project files in an add-on update
+func TestPlanAddonUpdate(t *testing.T) {
+	assert := asrt.New(t)
+
+	app := &DdevApp{AppRoot: t.TempDir()}
+	baseDir := t.TempDir()
+	newDir := t.TempDir()
+	writeFiles := func(dir string, files map[string]string) {
+		for name, content := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+		}
+	}
+
+	writeFiles(baseDir, map[string]string{
+		"docker-compose.redis.yaml": "#ddev-generated\nimage: redis:6\nrestart: always\n",
+		"redis/redis.conf":          "#ddev-generated\nmaxmemory 1g\nsave 60 1\n",
+		"redis/scripts/old.sh":      "#ddev-generated\necho old\n",
+		"commands/redis/redis-cli":  "#ddev-generated\nredis-cli\n",
+	})
+	writeFiles(app.GetConfigPath(""), map[string]string{
+		"docker-compose.redis.yaml": "#ddev-generated\nimage: redis:6\nrestart: always\n",
+		// The user took ownership of redis.conf
+		"redis/redis.conf":         "maxmemory 2g\nsave 60 1\n",
+		"redis/scripts/old.sh":     "#ddev-generated\necho old\n",
+		"commands/redis/redis-cli": "#ddev-generated\nredis-cli\n",
+	})
+	writeFiles(newDir, map[string]string{
+		"install.yaml":              "name: redis\nproject_files:\n  - docker-compose.redis.yaml\n  - redis/redis.conf\n  - commands/redis\n  - redis/new.sh\n",
+		"docker-compose.redis.yaml": "#ddev-generated\nimage: redis:7\nrestart: always\n",
+		"redis/redis.conf":          "#ddev-generated\nmaxmemory 1g\nsave 60 1\nappendonly yes\n",
+		"redis/new.sh":              "#ddev-generated\necho new\n",
+		"commands/redis/redis-cli":  "#ddev-generated\nredis-cli\n",
+	})
+	manifest := AddonManifest{
+		Name:         "redis",
+		Repository:   "ddev/ddev-redis",
+		Version:      "v1.0.0",
+		ProjectFiles: []string{"docker-compose.redis.yaml", "redis/redis.conf", "redis/scripts/old.sh", "commands/redis"},
+	}
+
+	statuses := func(changes []AddonFileChange) map[string]string {
+		s := map[string]string{}
+		for _, c := range changes {
+			s[c.File] = c.Status
+		}
+		return s
+	}
+
+	// Without the installed version, redis.conf can only be preserved
+	changes, err := PlanAddonUpdate(app, manifest, newDir, "")
+	require.NoError(t, err)
+	assert.Equal(map[string]string{
+		"docker-compose.redis.yaml":                     AddonFileModified,
+		"redis/redis.conf":                              AddonFilePreserved,
+		"redis/new.sh":                                  AddonFileAdded,
+		filepath.Join("commands", "redis", "redis-cli"): AddonFileUnchanged,
+		"redis/scripts/old.sh":                          AddonFileRemoved,
+	}, statuses(changes))
+
+	changes, err = PlanAddonUpdate(app, manifest, newDir, baseDir)
+	require.NoError(t, err)
+	for _, c := range changes {
+		switch c.File {
+		case "docker-compose.redis.yaml":
+			assert.Contains(c.Diff, "-image: redis:6\n+image: redis:7\n")
+		case "redis/redis.conf":
+			assert.Equal(AddonFileMerged, c.Status)
+			assert.Equal("maxmemory 2g\nsave 60 1\nappendonly yes\n", string(c.Content))
+		case "redis/scripts/old.sh":
+			assert.Contains(c.Diff, "-echo old\n")
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ddevapp
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/ddev/ddev/pkg/fileutil"
@@ -12,18 +13,30 @@ import (
 // Composer runs Composer commands in the web container, managing pre- and post- hooks
 // returns stdout, stderr, error
 func (app *DdevApp) Composer(args []string) (string, string, error) {
+	return app.composer(args, nil)
+}
+
+// composer runs Composer like Composer(), writing its output to out
+// instead of the terminal if out isn't nil
+func (app *DdevApp) composer(args []string, out io.Writer) (string, string, error) {
 	err := app.ProcessHooks("pre-composer")
 	if err != nil {
 		return "", "", fmt.Errorf("failed to process pre-composer hooks: %v", err)
 	}
 
-	stdout, stderr, err := app.Exec(&ExecOpts{
+	opts := &ExecOpts{
 		Service: "web",
 		Dir:     app.GetComposerRoot(true, true),
 		RawCmd:  append([]string{"composer"}, args...),
 		Tty:     isatty.IsTerminal(os.Stdin.Fd()),
 		Env:     getComposerEnv(),
-	})
+	}
+	if out != nil {
+		opts.Tty = false
+		opts.Stdout = out
+		opts.Stderr = out
+	}
+	stdout, stderr, err := app.Exec(opts)
 	if err != nil {
 		return stdout, stderr, fmt.Errorf("composer command failed: %v", err)
 	}
@@ -620,6 +620,14 @@ func (app *DdevApp) ValidateConfig() error {
 		usedHTTPAndHTTPSPorts[extraPort.HTTPSPort] = true
 	}
 
+	if err := app.RouterMiddlewares.Validate(); err != nil {
+		return fmt.Errorf("the %s project has an invalid router_middlewares: %v", app.Name, err)
+	}
+
+	if err := app.SnapshotRetention.Validate(); err != nil {
+		return fmt.Errorf("the %s project has an invalid snapshot_retention: %v", app.Name, err)
+	}
+
 	// Golang on Windows is not able to time.LoadLocation unless
 	// Go is installed... so skip validation on Windows
 	if !nodeps.IsWindows() {
@@ -1813,7 +1821,9 @@ func PrepDdevDirectory(app *DdevApp) error {
 		".ddev-docker-*.yaml",
 		".*downloads",
 		".homeadditions",
+		".hook-state*",
 		".importdb*",
+		".share-sessions",
 		".webimageBuild",
 		"apache/apache-site.conf",
 		"commands/.gitattributes",
@@ -1882,6 +1892,8 @@ func validateHookYAML(source []byte) error {
 		"exec",
 		"exec-host",
 		"composer",
+		"wait-for",
+		"http",
 	}
 
 	type Validate struct {
@@ -1906,14 +1918,8 @@ func validateHookYAML(source []byte) error {
 		}
 
 		for _, foundTask := range tasks {
-			var match bool
-			for _, validTaskName := range validTasks {
-				if _, ok := foundTask[validTaskName]; ok {
-					match = true
-				}
-			}
-			if !match {
-				return fmt.Errorf("invalid task '%s' defined for hook %s in config.yaml", foundTask, foundHook)
+			if err = validateHookTask(foundHook, foundTask, validTasks); err != nil {
+				return err
 			}
 		}
 	}
@@ -1921,6 +1927,50 @@ func validateHookYAML(source []byte) error {
 	return nil
 }
 
+// validateHookTask validates a task of a hook, including the tasks
+// of a parallel group and the conditions of its `when:`
+func validateHookTask(foundHook string, foundTask map[string]any, validTasks []string) error {
+	if when, ok := foundTask["when"]; ok {
+		if _, err := parseTaskCondition(when); err != nil {
+			return fmt.Errorf("invalid 'when' of task '%s' defined for hook %s in config.yaml: %v", foundTask, foundHook, err)
+		}
+	}
+	if inputs, ok := foundTask["inputs"]; ok {
+		if _, err := parseTaskInputs(inputs); err != nil {
+			return fmt.Errorf("invalid 'inputs' of task '%s' defined for hook %s in config.yaml: %v", foundTask, foundHook, err)
+		}
+	}
+	if group, ok := foundTask["parallel"]; ok {
+		if _, ok := foundTask["inputs"]; ok {
+			return fmt.Errorf("invalid parallel tasks '%v' defined for hook %s in config.yaml, 'inputs' can only be set for each of the tasks", group, foundHook)
+		}
+		items, ok := group.([]any)
+		if !ok || len(items) == 0 {
+			return fmt.Errorf("invalid parallel tasks '%v' defined for hook %s in config.yaml, 'parallel' must be a list of tasks", group, foundHook)
+		}
+		for _, item := range items {
+			t, ok := item.(map[string]any)
+			if !ok {
+				return fmt.Errorf("invalid task '%v' defined for hook %s in config.yaml", item, foundHook)
+			}
+			if _, ok := t["parallel"]; ok {
+				return fmt.Errorf("invalid task '%s' defined for hook %s in config.yaml, parallel tasks can't contain other parallel tasks", t, foundHook)
+			}
+			if err := validateHookTask(foundHook, t, validTasks); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, validTaskName := range validTasks {
+		if _, ok := foundTask[validTaskName]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid task '%s' defined for hook %s in config.yaml", foundTask, foundHook)
+}
+
 // isNotDockerfileContextFile returns true if the given file is NOT a Dockerfile context file
 // We consider files in the .ddev/web-build and .ddev/db-build directory to be context files
 // excluding /Dockerfile*, /pre.Dockerfile*, /prepend.Dockerfile* and /README.txt
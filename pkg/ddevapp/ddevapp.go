@@ -87,71 +87,75 @@ type WebExtraDaemon struct {
 // DdevApp is the struct that represents a DDEV app, mostly its config
 // from config.yaml.
 type DdevApp struct {
-	Name                      string                `yaml:"name,omitempty"`
-	Type                      string                `yaml:"type"`
-	AppRoot                   string                `yaml:"-"`
-	Docroot                   string                `yaml:"docroot"`
-	PHPVersion                string                `yaml:"php_version"`
-	WebserverType             string                `yaml:"webserver_type"`
-	WebImage                  string                `yaml:"webimage,omitempty"`
-	DBImage                   string                `yaml:"dbimage,omitempty"`
-	RouterHTTPPort            string                `yaml:"router_http_port,omitempty"`
-	RouterHTTPSPort           string                `yaml:"router_https_port,omitempty"`
-	XdebugEnabled             bool                  `yaml:"xdebug_enabled"`
-	NoProjectMount            bool                  `yaml:"no_project_mount,omitempty"`
-	AdditionalHostnames       []string              `yaml:"additional_hostnames"`
-	AdditionalFQDNs           []string              `yaml:"additional_fqdns"`
-	MariaDBVersion            string                `yaml:"mariadb_version,omitempty"`
-	MySQLVersion              string                `yaml:"mysql_version,omitempty"`
-	Database                  DatabaseDesc          `yaml:"database"`
-	PerformanceMode           types.PerformanceMode `yaml:"performance_mode,omitempty"`
-	FailOnHookFail            bool                  `yaml:"fail_on_hook_fail,omitempty"`
-	BindAllInterfaces         bool                  `yaml:"bind_all_interfaces,omitempty"`
-	FailOnHookFailGlobal      bool                  `yaml:"-"`
-	ConfigPath                string                `yaml:"-"`
-	DataDir                   string                `yaml:"-"`
-	SiteSettingsPath          string                `yaml:"-"`
-	SiteDdevSettingsFile      string                `yaml:"-"`
-	ProviderInstance          *Provider             `yaml:"-"`
-	Hooks                     map[string][]YAMLTask `yaml:"hooks,omitempty"`
-	UploadDirDeprecated       string                `yaml:"upload_dir,omitempty"`
-	UploadDirs                []string              `yaml:"upload_dirs,omitempty"`
-	WorkingDir                map[string]string     `yaml:"working_dir,omitempty"`
-	OmitContainers            []string              `yaml:"omit_containers,omitempty,flow"`
-	OmitContainersGlobal      []string              `yaml:"-"`
-	HostDBPort                string                `yaml:"host_db_port,omitempty"`
-	HostWebserverPort         string                `yaml:"host_webserver_port,omitempty"`
-	HostHTTPSPort             string                `yaml:"host_https_port,omitempty"`
-	MailpitHTTPPort           string                `yaml:"mailpit_http_port,omitempty"`
-	MailpitHTTPSPort          string                `yaml:"mailpit_https_port,omitempty"`
-	HostMailpitPort           string                `yaml:"host_mailpit_port,omitempty"`
-	WebImageExtraPackages     []string              `yaml:"webimage_extra_packages,omitempty,flow"`
-	DBImageExtraPackages      []string              `yaml:"dbimage_extra_packages,omitempty,flow"`
-	ProjectTLD                string                `yaml:"project_tld,omitempty"`
-	UseDNSWhenPossible        bool                  `yaml:"use_dns_when_possible"`
-	MkcertEnabled             bool                  `yaml:"-"`
-	NgrokArgs                 string                `yaml:"ngrok_args,omitempty"`
-	ShareDefaultProvider      string                `yaml:"share_default_provider,omitempty"`
-	ShareProviderArgs         string                `yaml:"share_provider_args,omitempty"`
-	Timezone                  string                `yaml:"timezone,omitempty"`
-	ComposerRoot              string                `yaml:"composer_root,omitempty"`
-	ComposerVersion           string                `yaml:"composer_version"`
-	DisableSettingsManagement bool                  `yaml:"disable_settings_management,omitempty"`
-	WebEnvironment            []string              `yaml:"web_environment"`
-	NodeJSVersion             string                `yaml:"nodejs_version"`
-	CorepackEnable            bool                  `yaml:"corepack_enable"`
-	DefaultContainerTimeout   string                `yaml:"default_container_timeout,omitempty"`
-	WebExtraExposedPorts      []WebExposedPort      `yaml:"web_extra_exposed_ports,omitempty"`
-	WebExtraDaemons           []WebExtraDaemon      `yaml:"web_extra_daemons,omitempty"`
-	OverrideConfig            bool                  `yaml:"override_config,omitempty"`
-	DisableUploadDirsWarning  bool                  `yaml:"disable_upload_dirs_warning,omitempty"`
-	DdevVersionConstraint     string                `yaml:"ddev_version_constraint,omitempty"`
-	XHGuiHTTPSPort            string                `yaml:"xhgui_https_port,omitempty"`
-	XHGuiHTTPPort             string                `yaml:"xhgui_http_port,omitempty"`
-	HostXHGuiPort             string                `yaml:"host_xhgui_port,omitempty"`
-	XHProfMode                types.XHProfMode      `yaml:"xhprof_mode,omitempty"`
-	ComposeYaml               *composeTypes.Project `yaml:"-"`
-	NoCache                   bool                  `yaml:"-"`
+	Name                      string                  `yaml:"name,omitempty"`
+	Type                      string                  `yaml:"type"`
+	AppRoot                   string                  `yaml:"-"`
+	Docroot                   string                  `yaml:"docroot"`
+	PHPVersion                string                  `yaml:"php_version"`
+	WebserverType             string                  `yaml:"webserver_type"`
+	WebImage                  string                  `yaml:"webimage,omitempty"`
+	DBImage                   string                  `yaml:"dbimage,omitempty"`
+	RouterHTTPPort            string                  `yaml:"router_http_port,omitempty"`
+	RouterHTTPSPort           string                  `yaml:"router_https_port,omitempty"`
+	RouterMiddlewares         *RouterMiddlewares      `yaml:"router_middlewares,omitempty"`
+	XdebugEnabled             bool                    `yaml:"xdebug_enabled"`
+	NoProjectMount            bool                    `yaml:"no_project_mount,omitempty"`
+	AdditionalHostnames       []string                `yaml:"additional_hostnames"`
+	AdditionalFQDNs           []string                `yaml:"additional_fqdns"`
+	MariaDBVersion            string                  `yaml:"mariadb_version,omitempty"`
+	MySQLVersion              string                  `yaml:"mysql_version,omitempty"`
+	Database                  DatabaseDesc            `yaml:"database"`
+	PerformanceMode           types.PerformanceMode   `yaml:"performance_mode,omitempty"`
+	FailOnHookFail            bool                    `yaml:"fail_on_hook_fail,omitempty"`
+	BindAllInterfaces         bool                    `yaml:"bind_all_interfaces,omitempty"`
+	FailOnHookFailGlobal      bool                    `yaml:"-"`
+	ConfigPath                string                  `yaml:"-"`
+	DataDir                   string                  `yaml:"-"`
+	SiteSettingsPath          string                  `yaml:"-"`
+	SiteDdevSettingsFile      string                  `yaml:"-"`
+	ProviderInstance          *Provider               `yaml:"-"`
+	Hooks                     map[string][]YAMLTask   `yaml:"hooks,omitempty"`
+	UploadDirDeprecated       string                  `yaml:"upload_dir,omitempty"`
+	UploadDirs                []string                `yaml:"upload_dirs,omitempty"`
+	WorkingDir                map[string]string       `yaml:"working_dir,omitempty"`
+	OmitContainers            []string                `yaml:"omit_containers,omitempty,flow"`
+	OmitContainersGlobal      []string                `yaml:"-"`
+	HostDBPort                string                  `yaml:"host_db_port,omitempty"`
+	HostWebserverPort         string                  `yaml:"host_webserver_port,omitempty"`
+	HostHTTPSPort             string                  `yaml:"host_https_port,omitempty"`
+	MailpitHTTPPort           string                  `yaml:"mailpit_http_port,omitempty"`
+	MailpitHTTPSPort          string                  `yaml:"mailpit_https_port,omitempty"`
+	HostMailpitPort           string                  `yaml:"host_mailpit_port,omitempty"`
+	WebImageExtraPackages     []string                `yaml:"webimage_extra_packages,omitempty,flow"`
+	DBImageExtraPackages      []string                `yaml:"dbimage_extra_packages,omitempty,flow"`
+	ProjectTLD                string                  `yaml:"project_tld,omitempty"`
+	UseDNSWhenPossible        bool                    `yaml:"use_dns_when_possible"`
+	MkcertEnabled             bool                    `yaml:"-"`
+	NgrokArgs                 string                  `yaml:"ngrok_args,omitempty"`
+	ShareDefaultProvider      string                  `yaml:"share_default_provider,omitempty"`
+	ShareProviderArgs         string                  `yaml:"share_provider_args,omitempty"`
+	Timezone                  string                  `yaml:"timezone,omitempty"`
+	ComposerRoot              string                  `yaml:"composer_root,omitempty"`
+	ComposerVersion           string                  `yaml:"composer_version"`
+	DisableSettingsManagement bool                    `yaml:"disable_settings_management,omitempty"`
+	WebEnvironment            []string                `yaml:"web_environment"`
+	NodeJSVersion             string                  `yaml:"nodejs_version"`
+	CorepackEnable            bool                    `yaml:"corepack_enable"`
+	DefaultContainerTimeout   string                  `yaml:"default_container_timeout,omitempty"`
+	WebExtraExposedPorts      []WebExposedPort        `yaml:"web_extra_exposed_ports,omitempty"`
+	WebExtraDaemons           []WebExtraDaemon        `yaml:"web_extra_daemons,omitempty"`
+	OverrideConfig            bool                    `yaml:"override_config,omitempty"`
+	DisableUploadDirsWarning  bool                    `yaml:"disable_upload_dirs_warning,omitempty"`
+	DdevVersionConstraint     string                  `yaml:"ddev_version_constraint,omitempty"`
+	XHGuiHTTPSPort            string                  `yaml:"xhgui_https_port,omitempty"`
+	XHGuiHTTPPort             string                  `yaml:"xhgui_http_port,omitempty"`
+	HostXHGuiPort             string                  `yaml:"host_xhgui_port,omitempty"`
+	XHProfMode                types.XHProfMode        `yaml:"xhprof_mode,omitempty"`
+	SnapshotRetention         types.SnapshotRetention `yaml:"snapshot_retention,omitempty"`
+	ComposeYaml               *composeTypes.Project   `yaml:"-"`
+	NoCache                   bool                    `yaml:"-"`
+	// RerunHooks runs hook tasks even if their inputs, or the configuration, didn't change
+	RerunHooks bool `yaml:"-"`
 }
 
 // SkipHooks Global variable that's set from --skip-hooks global flag.
@@ -301,6 +305,14 @@ func (app *DdevApp) Describe(short bool) (map[string]any, error) {
 
 	routerStatus, logOutput := GetRouterStatus()
 	appDesc["router_status"] = routerStatus
+
+	if shares, err := app.GetShareSessions(); err == nil && len(shares) > 0 {
+		shareDescs := make([]map[string]any, 0, len(shares))
+		for _, share := range shares {
+			shareDescs = append(shareDescs, map[string]any{"provider": share.Provider, "url": share.URL, "pid": share.PID})
+		}
+		appDesc["shares"] = shareDescs
+	}
 	appDesc["router_status_log"] = logOutput
 	appDesc["ssh_agent_status"] = GetSSHAuthStatus()
 	appDesc["php_version"] = app.GetPhpVersion()
@@ -317,6 +329,10 @@ func (app *DdevApp) Describe(short bool) (map[string]any, error) {
 		return nil, err
 	}
 	services := appDesc["services"].(map[string]map[string]any)
+	routerTCPURLs := map[string]string{}
+	if !IsRouterDisabled(app) {
+		routerTCPURLs = app.GetRouterTCPURLs()
+	}
 	for _, k := range containers {
 		if len(k.Names) == 0 {
 			continue
@@ -448,6 +464,9 @@ func (app *DdevApp) Describe(short bool) (map[string]any, error) {
 				}
 			}
 		}
+		if tcpURL, ok := routerTCPURLs[shortName]; ok {
+			services[shortName]["router_tcp_url"] = tcpURL
+		}
 		if shortName == "web" {
 			services[shortName]["host_http_url"] = app.GetWebContainerDirectHTTPURL()
 			services[shortName]["host_https_url"] = app.GetWebContainerDirectHTTPSURL()
@@ -1402,36 +1421,286 @@ func (app *DdevApp) ProcessHooks(hookName string) error {
 	}
 
 	for _, c := range app.Hooks[hookName] {
-		a := NewTask(app, c)
-		if a == nil {
-			return fmt.Errorf("unable to create task from %v", c)
+		step, err := app.newHookStep(hookName, c)
+		if err != nil {
+			return err
 		}
+		if err = app.runHookStep(hookName, step); err != nil {
+			return err
+		}
+	}
 
-		if hookName == "pre-start" {
-			for k := range c {
-				if k == "exec" || k == "composer" {
-					return fmt.Errorf("pre-start hooks cannot contain %v", k)
-				}
+	return nil
+}
+
+// hookTask is a task of a hook with its `when:` conditions, if any
+type hookTask struct {
+	task Task
+	// label prefixes the output of a task running in parallel
+	label string
+	when  *TaskCondition
+	// inputs are the globs of the files the task is skipped for if they didn't change
+	inputs []string
+}
+
+// hookStep is an entry of a hook, either a single task or a `parallel:` group of tasks
+type hookStep struct {
+	tasks    []hookTask
+	parallel bool
+	// when are the conditions of a parallel group
+	when *TaskCondition
+}
+
+// newHookStep creates the task, or parallel group of tasks, of a hook entry
+func (app *DdevApp) newHookStep(hookName string, c YAMLTask) (*hookStep, error) {
+	group, ok := c["parallel"]
+	if !ok {
+		t, err := app.newHookTask(hookName, c)
+		if err != nil {
+			return nil, err
+		}
+		return &hookStep{tasks: []hookTask{t}}, nil
+	}
+
+	items, ok := group.([]any)
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("'parallel' must be a list of tasks, not %v", group)
+	}
+	if _, ok := c["inputs"]; ok {
+		return nil, fmt.Errorf("'inputs' can only be set for each of the parallel tasks %v", group)
+	}
+	step := &hookStep{parallel: true}
+	if v, ok := c["when"]; ok {
+		var err error
+		if step.when, err = parseTaskCondition(v); err != nil {
+			return nil, fmt.Errorf("invalid 'when' of parallel tasks %v: %v", group, err)
+		}
+	}
+	for i, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unable to create task from %v", item)
+		}
+		if _, ok := m["parallel"]; ok {
+			return nil, fmt.Errorf("parallel tasks can't contain other parallel tasks")
+		}
+		t, err := app.newHookTask(hookName, m)
+		if err != nil {
+			return nil, err
+		}
+		t.label = fmt.Sprintf("task %d", i+1)
+		if name, ok := m["name"].(string); ok && name != "" {
+			t.label = name
+		}
+		step.tasks = append(step.tasks, t)
+	}
+	return step, nil
+}
+
+// newHookTask creates a task of a hook with its conditions
+func (app *DdevApp) newHookTask(hookName string, c YAMLTask) (hookTask, error) {
+	a := NewTask(app, c)
+	if a == nil {
+		return hookTask{}, fmt.Errorf("unable to create task from %v", c)
+	}
+
+	if hookName == "pre-start" {
+		for k := range c {
+			if k == "exec" || k == "composer" || k == "wait-for" || k == "http" {
+				return hookTask{}, fmt.Errorf("pre-start hooks cannot contain %v", k)
 			}
 		}
+	}
 
-		output.UserOut.Debugf("=== Running task: %s, output below", a.GetDescription())
+	t := hookTask{task: a}
+	if v, ok := c["when"]; ok {
+		var err error
+		if t.when, err = parseTaskCondition(v); err != nil {
+			return hookTask{}, fmt.Errorf("invalid 'when' of task %s: %v", a.GetDescription(), err)
+		}
+	}
+	if v, ok := c["inputs"]; ok {
+		var err error
+		if t.inputs, err = parseTaskInputs(v); err != nil {
+			return hookTask{}, fmt.Errorf("invalid 'inputs' of task %s: %v", a.GetDescription(), err)
+		}
+	}
+	return t, nil
+}
 
-		err := a.Execute()
+// runHookStep runs a hook entry. The tasks of a parallel group all run to the end,
+// even when one fails; the failure is handled once they're done.
+func (app *DdevApp) runHookStep(hookName string, step *hookStep) error {
+	if !step.parallel {
+		return app.hookTaskResult(step.tasks[0].task, app.runHookTask(hookName, step.tasks[0]))
+	}
 
+	var descriptions []string
+	for _, t := range step.tasks {
+		descriptions = append(descriptions, t.task.GetDescription())
+	}
+	groupID := hookName + ": parallel: " + strings.Join(descriptions, "; ")
+	if step.when != nil {
+		ok, reason, err := step.when.check(app, groupID)
 		if err != nil {
-			if app.FailOnHookFail || app.FailOnHookFailGlobal {
-				output.UserOut.Errorf("Task failed: %v: %v", a.GetDescription(), err)
-				return fmt.Errorf("task failed: %v", err)
+			return app.hookTaskResult(step.tasks[0].task, fmt.Errorf("unable to check the conditions of the parallel tasks: %v", err))
+		}
+		if !ok {
+			output.UserOut.Debugf("=== Skipping parallel tasks, because %s", reason)
+			for _, t := range step.tasks {
+				emitHookTaskSkippedEvent(app.Name, hookName, t.task)
 			}
-			output.UserOut.Errorf("Task failed: %v: %v", a.GetDescription(), err)
-			output.UserOut.Warn("A task failure does not mean that DDEV failed, but your hook configuration has a command that failed.")
+			return nil
 		}
 	}
 
+	output.UserOut.Debugf("=== Running %d tasks in parallel", len(step.tasks))
+	errs := make([]error, len(step.tasks))
+	var wg sync.WaitGroup
+	for i, t := range step.tasks {
+		wg.Go(func() {
+			errs[i] = app.runHookTask(hookName, t)
+		})
+	}
+	wg.Wait()
+
+	var failed error
+	succeeded := true
+	for i, err := range errs {
+		if err != nil {
+			succeeded = false
+		}
+		if err := app.hookTaskResult(step.tasks[i].task, err); err != nil && failed == nil {
+			failed = err
+		}
+	}
+	if succeeded && step.when != nil {
+		if err := step.when.recordSuccess(app, groupID); err != nil {
+			util.Warning("Unable to record the run of the parallel tasks: %v", err)
+		}
+	}
+	return failed
+}
+
+// runHookTask runs a task of a hook if its conditions hold, and returns the error it failed with.
+// The output of a task with a label is prefixed with it.
+func (app *DdevApp) runHookTask(hookName string, t hookTask) error {
+	taskID := hookName + ": " + t.task.GetDescription()
+	if t.when != nil {
+		ok, reason, err := t.when.check(app, taskID)
+		if err != nil {
+			return fmt.Errorf("unable to check the conditions of the task: %v", err)
+		}
+		if !ok {
+			output.UserOut.Debugf("=== Skipping task: %s, because %s", t.task.GetDescription(), reason)
+			emitHookTaskSkippedEvent(app.Name, hookName, t.task)
+			return nil
+		}
+	}
+
+	if len(t.inputs) > 0 && !app.RerunHooks {
+		unchanged, err := app.hookInputsUnchanged(taskID, t.inputs)
+		if err != nil {
+			return err
+		}
+		if unchanged {
+			output.UserOut.Printf("Skipping task: %s, its inputs didn't change since it last succeeded, use --rerun-hooks to run it anyway", t.task.GetDescription())
+			emitHookTaskSkippedEvent(app.Name, hookName, t.task)
+			return nil
+		}
+	}
+
+	output.UserOut.Debugf("=== Running task: %s, output below", t.task.GetDescription())
+
+	start := time.Now()
+	var err error
+	if ot, ok := t.task.(outputTask); ok && t.label != "" {
+		out := &prefixedLineWriter{prefix: "[" + t.label + "] ", println: output.UserOut.Println}
+		err = ot.executeWithOutput(out)
+		out.Flush()
+		if err != nil {
+			err = errorWithOutput(err, out.captured.String())
+		}
+	} else {
+		err = t.task.Execute()
+	}
+	emitHookTaskEvent(app.Name, hookName, t.task, time.Since(start), err)
+
+	if err == nil && t.when != nil {
+		if err := t.when.recordSuccess(app, taskID); err != nil {
+			util.Warning("Unable to record the run of task %s: %v", t.task.GetDescription(), err)
+		}
+	}
+	// The checksum is taken after the run, because tasks like
+	// `composer install` can update their own inputs
+	if err == nil && len(t.inputs) > 0 {
+		if err := app.recordHookInputs(taskID, t.inputs); err != nil {
+			util.Warning("Unable to record the inputs of task %s: %v", t.task.GetDescription(), err)
+		}
+	}
+	return err
+}
+
+// hookInputsUnchanged reports whether the checksum of the inputs of a task
+// is the one it last succeeded with
+func (app *DdevApp) hookInputsUnchanged(taskID string, inputs []string) (bool, error) {
+	checksum, err := app.inputsChecksum(inputs)
+	if err != nil {
+		return false, fmt.Errorf("unable to compute the checksum of the inputs of the task: %v", err)
+	}
+	s, err := app.readHookState()
+	if err != nil {
+		return false, err
+	}
+	return s.InputChecksums[taskID] == checksum, nil
+}
+
+// recordHookInputs records the checksum of the inputs of a task that succeeded
+func (app *DdevApp) recordHookInputs(taskID string, inputs []string) error {
+	checksum, err := app.inputsChecksum(inputs)
+	if err != nil {
+		return err
+	}
+	return app.updateHookState(func(s *hookState) {
+		if s.InputChecksums == nil {
+			s.InputChecksums = map[string]string{}
+		}
+		s.InputChecksums[taskID] = checksum
+	})
+}
+
+// hookTaskResult reports the failure of a task, and returns it
+// if hook failures are configured to fail the command
+func (app *DdevApp) hookTaskResult(a Task, err error) error {
+	if err == nil {
+		return nil
+	}
+	output.UserOut.Errorf("Task failed: %v: %v", a.GetDescription(), err)
+	if app.FailOnHookFail || app.FailOnHookFailGlobal {
+		return fmt.Errorf("task failed: %v", err)
+	}
+	output.UserOut.Warn("A task failure does not mean that DDEV failed, but your hook configuration has a command that failed.")
 	return nil
 }
 
+// emitHookTaskSkippedEvent emits a --events hook_task event for a task skipped because of its conditions
+func emitHookTaskSkippedEvent(project string, hookName string, task Task) {
+	output.EmitEvent(output.Event{Type: output.EventHookTask, Project: project, Hook: hookName, Task: task.GetDescription(), Status: "skipped"})
+}
+
+// emitHookTaskEvent emits a --events hook_task event with the result of a hook task
+func emitHookTaskEvent(project string, hookName string, task Task, duration time.Duration, err error) {
+	if !output.EventsEnabled() {
+		return
+	}
+	e := output.Event{Type: output.EventHookTask, Project: project, Hook: hookName, Task: task.GetDescription(), Status: "success", Duration: output.EventDuration(duration)}
+	if err != nil {
+		e.Status = "failed"
+		e.Error = err.Error()
+	}
+	output.EmitEvent(e)
+}
+
 // GetDBImage returns the db image to use, preferring an explicitly configured
 // DBImage over the default computed from the database type and version.
 func (app *DdevApp) GetDBImage() string {
@@ -1504,8 +1773,13 @@ func (app *DdevApp) composeBuild(args ...string) (string, error) {
 }
 
 // Start initiates docker-compose up
-func (app *DdevApp) Start() error {
-	var err error
+func (app *DdevApp) Start() (err error) {
+	// With --events, each phase of the start is reported as it starts and finishes
+	phases := output.NewPhaseTracker(app.Name)
+	defer func() {
+		phases.Finish(err)
+	}()
+	phases.Start("prepare")
 
 	RunUpgradeCheck()
 
@@ -1547,11 +1821,13 @@ func (app *DdevApp) Start() error {
 	}
 	warnWSL2WindowsFilesystem(app)
 	warnWSL2NoneMode()
+	phases.Start("pre_start_hooks")
 	err = app.ProcessHooks("pre-start")
 	if err != nil {
 		return err
 	}
 
+	phases.Start("configure")
 	// WriteConfig .ddev-docker-compose-*.yaml
 	err = app.WriteDockerComposeYAML()
 	if err != nil {
@@ -1563,10 +1839,12 @@ func (app *DdevApp) Start() error {
 		return err
 	}
 
+	phases.Start("pull_images")
 	if pullErr := PullBaseContainerImages(additionalImages, app.NoCache); pullErr != nil {
 		util.Warning("Unable to pull Docker images: %v", pullErr)
 	}
 
+	phases.Start("prepare_volumes")
 	// dbNeedsInitialization means the database volume has no database in it yet,
 	// so the db container will seed it from a base_db seed during this start.
 	dbNeedsInitialization := false
@@ -1851,6 +2129,7 @@ func (app *DdevApp) Start() error {
 			output.UserOut.Debugln()
 		}
 	}
+	phases.Start("build")
 	buildDurationStart := util.ElapsedDuration(time.Now())
 
 	_, err = app.composeBuild()
@@ -1901,6 +2180,7 @@ func (app *DdevApp) Start() error {
 		}
 	}
 
+	phases.Start("start_containers")
 	util.Debug("Executing docker-compose -f %s up -d", app.DockerComposeFullRenderedYAMLPath())
 
 	upProject, upErr := dockerutil.LoadComposeProject([]string{app.DockerComposeFullRenderedYAMLPath()}, api.ProjectLoadOptions{
@@ -2049,6 +2329,7 @@ func (app *DdevApp) Start() error {
 	}
 
 	// Wait for web/db containers to become healthy
+	phases.Start("wait_for_containers")
 	dependers := []string{"web"}
 	if !app.IsDBOmitted() {
 		dependers = append(dependers, "db")
@@ -2100,6 +2381,7 @@ func (app *DdevApp) Start() error {
 		}
 	}
 
+	phases.Start("start_router")
 	// Start the router in the background; it's independent of the steps below,
 	// and waiting for it to become ready is the slowest part of startup.
 	var routerWg sync.WaitGroup
@@ -2157,6 +2439,7 @@ func (app *DdevApp) Start() error {
 		}
 	}
 
+	phases.Start("post_start")
 	if _, err = app.CreateSettingsFile(); err != nil {
 		return fmt.Errorf("failed to write settings file %s: %v", app.SiteDdevSettingsFile, err)
 	}
@@ -2166,6 +2449,7 @@ func (app *DdevApp) Start() error {
 		return err
 	}
 
+	phases.Start("post_start_hooks")
 	err = app.ProcessHooks("post-start")
 	if err != nil {
 		return err
@@ -2712,6 +2996,24 @@ func runHostCommandWithCapturedOutput(app *DdevApp, cmd string) error {
 	return nil
 }
 
+// runHostCommandWithOutput runs cmd with bash on the host, in the project root,
+// writing its output to out. Unlike runHostCommandWithCapturedOutput(), stdin isn't
+// connected, so it can run alongside other commands.
+func runHostCommandWithOutput(app *DdevApp, cmd string, out io.Writer) error {
+	bashPath := "bash"
+	if nodeps.IsWindows() {
+		if bashPath = util.FindBashPath(); bashPath == "" {
+			return fmt.Errorf("unable to find bash.exe on Windows")
+		}
+	}
+	_ = app.DockerEnv()
+	c := exec.HostCommand(bashPath, "-c", cmd)
+	c.Dir = app.GetAppRoot()
+	c.Stdout = out
+	c.Stderr = out
+	return c.Run()
+}
+
 // ExecOnHostOrService runs cmd on the host or in the named service.
 // A failure carries the command's output, which is otherwise lost in logs
 // far from the error that mentions it.
@@ -3183,7 +3485,14 @@ func (app *DdevApp) DetermineSettingsPathLocation() (string, error) {
 // Snapshot causes a snapshot of the db to be written into the snapshots volume
 // Returns the name of the snapshot and err
 func (app *DdevApp) Snapshot(snapshotName string) (string, error) {
+	return app.CreateSnapshot(SnapshotOpts{Name: snapshotName})
+}
+
+// CreateSnapshot creates a full or incremental snapshot of the db as described by opts
+// Returns the name of the snapshot and err
+func (app *DdevApp) CreateSnapshot(opts SnapshotOpts) (string, error) {
 	containerSnapshotDirBase := "/var/tmp"
+	snapshotName := opts.Name
 
 	err := app.ProcessHooks("pre-snapshot")
 	if err != nil {
@@ -3214,6 +3523,28 @@ func (app *DdevApp) Snapshot(snapshotName string) (string, error) {
 		return "", fmt.Errorf("snapshot %s already exists, please use another snapshot name or clean up snapshots with `ddev snapshot --cleanup`", snapshotFile)
 	}
 
+	metadata := &SnapshotMetadata{
+		Name:        snapshotName,
+		Created:     time.Now(),
+		Description: opts.Description,
+		DBVersion:   app.Database.Type + "_" + app.Database.Version,
+		DdevVersion: versionconstants.DdevVersion,
+		Compression: snapshotCompressionFromFile(snapshotFile),
+	}
+	metadata.GitCommit, metadata.GitBranch = getSnapshotGitInfo(app.AppRoot)
+	if opts.Incremental || opts.Base != "" {
+		base, err := app.getIncrementalBase(opts.Base)
+		if err != nil {
+			return "", err
+		}
+		// An incremental snapshot the database image can't restore would be of no use
+		if err = checkIncrementalSnapshotSupport(app.GetDBImage()); err != nil {
+			return "", fmt.Errorf("unable to create an incremental snapshot: %v", err)
+		}
+		metadata.Base = base.Name
+		metadata.FromLSN = base.ToLSN
+	}
+
 	// Container side has to use path.Join instead of filepath.Join because they are
 	// targeted at the Linux filesystem, so won't work with filepath on Windows
 	containerSnapshotDir := containerSnapshotDirBase
@@ -3224,9 +3555,18 @@ func (app *DdevApp) Snapshot(snapshotName string) (string, error) {
 		return "", fmt.Errorf("unable to snapshot database, \nyour db container in project %v is not running. \nPlease start the project if you want to snapshot it. \nIf deleting project, you can delete without a snapshot using \n'ddev delete --omit-snapshot --yes', \nwhich will destroy your database", app.Name)
 	}
 
-	util.Success("Creating database snapshot %s", snapshotName)
+	metadata.Databases, err = app.getSnapshotDatabases()
+	if err != nil {
+		util.Warning("Unable to gather database details for snapshot %s: %v", snapshotName, err)
+	}
+
+	if metadata.Base != "" {
+		util.Success("Creating incremental database snapshot %s based on %s", snapshotName, metadata.Base)
+	} else {
+		util.Success("Creating database snapshot %s", snapshotName)
+	}
 
-	c := getBackupCommand(app, path.Join(containerSnapshotDir, snapshotFile))
+	c := getBackupCommand(app, path.Join(containerSnapshotDir, snapshotFile), metadata.FromLSN)
 	stdout, stderr, err := app.Exec(&ExecOpts{
 		Service: "db",
 		Cmd:     fmt.Sprintf(`set -eu -o pipefail; %s `, c),
@@ -3237,6 +3577,20 @@ func (app *DdevApp) Snapshot(snapshotName string) (string, error) {
 		return "", err
 	}
 
+	// mariabackup/xtrabackup leave their checkpoints in the extra LSN dir,
+	// which we need to be able to chain incremental snapshots to this one.
+	if app.Database.Type != nodeps.Postgres {
+		lsnDir := getBackupLSNDir(path.Join(containerSnapshotDir, snapshotFile))
+		stdout, _, err = app.Exec(&ExecOpts{
+			Service: "db",
+			Cmd:     fmt.Sprintf(`cat %s/*checkpoints 2>/dev/null || true; rm -rf %s`, lsnDir, lsnDir),
+		})
+		if err != nil {
+			util.Warning("Unable to read snapshot checkpoints, snapshot %s can't be used as incremental base: %v", snapshotName, err)
+		}
+		metadata.ToLSN = parseBackupCheckpoints(stdout)
+	}
+
 	dbContainer, err := GetContainer(app, "db")
 	if err != nil {
 		return "", err
@@ -3267,6 +3621,19 @@ func (app *DdevApp) Snapshot(snapshotName string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+
+	err = writeSnapshotMetadata(filepath.Join(app.GetConfigPath("db_snapshots"), snapshotFile), metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to write metadata for snapshot %s: %v", snapshotName, err)
+	}
+
+	// Enforce snapshot_retention now that there is a new snapshot
+	if pruned, reclaimed, err := app.PruneSnapshots(false); err != nil {
+		util.Warning("Unable to prune snapshots according to snapshot_retention: %v", err)
+	} else if len(pruned) > 0 {
+		util.Success("Pruned %d snapshot(s) according to snapshot_retention, reclaimed %s", len(pruned), util.FormatBytes(reclaimed))
+	}
+
 	err = app.ProcessHooks("post-snapshot")
 	if err != nil {
 		return snapshotFile, fmt.Errorf("failed to process post-snapshot hooks: %v", err)
@@ -3275,11 +3642,25 @@ func (app *DdevApp) Snapshot(snapshotName string) (string, error) {
 	return snapshotName, nil
 }
 
+// getBackupLSNDir returns the in-container directory where mariabackup/xtrabackup
+// write the checkpoints of the backup streamed to targetFile
+func getBackupLSNDir(targetFile string) string {
+	return "/var/tmp/snapshot_lsn_" + path.Base(targetFile)
+}
+
 // getBackupCommand returns the command to dump the entire db system for the various databases
-func getBackupCommand(app *DdevApp, targetFile string) string {
+// If incrementalLSN is not empty, mariabackup/xtrabackup only back up the changes since that LSN.
+func getBackupCommand(app *DdevApp, targetFile string, incrementalLSN string) string {
 	compressionCommand := app.GetDBCompressionCommand()
 
-	c := fmt.Sprintf(`mariabackup --backup --stream=mbstream --user=root --password=root --socket=/var/tmp/mysql.sock 2>/tmp/snapshot_%[1]s.log | %[2]s > "%[3]s"`, path.Base(targetFile), compressionCommand, targetFile)
+	// Write the checkpoints to a separate directory as well, so that the to_lsn
+	// of this backup can be used as the starting point of incremental backups
+	backupOpts := fmt.Sprintf(`--extra-lsndir="%s"`, getBackupLSNDir(targetFile))
+	if incrementalLSN != "" {
+		backupOpts = backupOpts + " --incremental-lsn=" + incrementalLSN
+	}
+
+	c := fmt.Sprintf(`mariabackup --backup --stream=mbstream %[4]s --user=root --password=root --socket=/var/tmp/mysql.sock 2>/tmp/snapshot_%[1]s.log | %[2]s > "%[3]s"`, path.Base(targetFile), compressionCommand, targetFile, backupOpts)
 
 	oldMariaVersions := []string{"5.5", "10.0"}
 
@@ -3288,7 +3669,7 @@ func getBackupCommand(app *DdevApp, targetFile string) string {
 	case app.Database.Type == nodeps.MariaDB && nodeps.ArrayContainsString(oldMariaVersions, app.Database.Version):
 		fallthrough
 	case app.Database.Type == nodeps.MySQL:
-		c = fmt.Sprintf(`xtrabackup --backup --stream=xbstream --user=root --password=root --socket=/var/tmp/mysql.sock 2>/tmp/snapshot_%[1]s.log | %[2]s > "%[3]s"`, path.Base(targetFile), compressionCommand, targetFile)
+		c = fmt.Sprintf(`xtrabackup --backup --stream=xbstream %[4]s --user=root --password=root --socket=/var/tmp/mysql.sock 2>/tmp/snapshot_%[1]s.log | %[2]s > "%[3]s"`, path.Base(targetFile), compressionCommand, targetFile, backupOpts)
 	case app.Database.Type == nodeps.Postgres:
 		postgresDataPath := app.GetPostgresDataPath()
 		postgresDataDir := app.GetPostgresDataDir()
@@ -3313,6 +3694,9 @@ func getBackupCommand(app *DdevApp, targetFile string) string {
 
 	// Remove any existing file or directory at the target path to avoid "Is a directory" errors
 	cleanupCmd := fmt.Sprintf(`rm -rf "%s"`, targetFile)
+	if app.Database.Type != nodeps.Postgres {
+		cleanupCmd = fmt.Sprintf(`rm -rf "%[1]s" "%[2]s" && mkdir -p "%[2]s"`, targetFile, getBackupLSNDir(targetFile))
+	}
 	c = fmt.Sprintf("%s && %s", cleanupCmd, c)
 	return c
 }
@@ -3338,9 +3722,13 @@ func fullDBFromVersion(v string) string {
 }
 
 // Stop stops and Removes the Docker containers for the project in current directory.
-func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
+func (app *DdevApp) Stop(removeData bool, createSnapshot bool) (err error) {
 	_ = app.DockerEnv()
-	var err error
+	// With --events, each phase of the stop is reported as it starts and finishes
+	phases := output.NewPhaseTracker(app.Name)
+	defer func() {
+		phases.Finish(err)
+	}()
 
 	clear(EphemeralRouterPortsAssigned)
 	if app.Name == "" {
@@ -3349,13 +3737,31 @@ func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
 
 	status, _ := app.SiteStatus()
 	if status != SiteStopped {
+		phases.Start("pre_stop_hooks")
 		err = app.ProcessHooks("pre-stop")
 		if err != nil {
 			return fmt.Errorf("failed to process pre-stop hooks: %v", err)
 		}
 	}
 
+	// Background shares of `ddev share --detach` can't outlive the project
+	stoppedShares, err := app.StopShareSessions("")
+	if err != nil {
+		util.Warning("Unable to stop share sessions: %v", err)
+	}
+	if len(stoppedShares) > 0 {
+		for _, s := range stoppedShares {
+			output.UserOut.Printf("Stopped %s share %s", s.Provider, s.URL)
+		}
+		if status != SiteStopped {
+			if err = app.ProcessHooks("post-share"); err != nil {
+				util.Warning("Failed to process post-share hooks: %v", err)
+			}
+		}
+	}
+
 	if createSnapshot {
+		phases.Start("snapshot")
 		if status != SiteRunning {
 			util.Warning("Must start non-running project to do database snapshot")
 			err = app.Start()
@@ -3370,6 +3776,7 @@ func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
 		}
 	}
 
+	phases.Start("stop_containers")
 	if app.IsMutagenEnabled() {
 		err = SyncAndPauseMutagenSession(app)
 		if err != nil {
@@ -3414,6 +3821,7 @@ func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
 
 	// Remove data/database/projectInfo/hosts entry if we need to.
 	if removeData {
+		phases.Start("remove_data")
 		if app.IsMutagenEnabled() {
 			err = TerminateMutagenSync(app)
 			if err != nil {
@@ -3452,6 +3860,7 @@ func (app *DdevApp) Stop(removeData bool, createSnapshot bool) error {
 	}
 
 	if status != SiteStopped {
+		phases.Start("post_stop_hooks")
 		err = app.ProcessHooks("post-stop")
 		if err != nil {
 			return fmt.Errorf("failed to process post-stop hooks: %v", err)
@@ -72,3 +72,12 @@ func EscapeEnvFileValue(value string) string {
 	// Wrap the value in double quotes
 	return `"` + value + `"`
 }
+
+// removeProjectEnvFileVars removes the lines of variables from the envText of a .env file
+func removeProjectEnvFileVars(envText string, keys []string) string {
+	for _, k := range keys {
+		exp := regexp.MustCompile(fmt.Sprintf(`(?m)^[ \t]*(%s)[ \t]*=.*(\r?\n|$)`, regexp.QuoteMeta(k)))
+		envText = exp.ReplaceAllString(envText, "")
+	}
+	return envText
+}
@@ -0,0 +1,114 @@
+package ddevapp
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ddev/ddev/pkg/config/state/storage/yaml"
+	"github.com/ddev/ddev/pkg/fileutil"
+)
+
+// hookStateFile is the file in .ddev that keeps what hooks need to remember
+// between runs. It's in .ddev/.gitignore.
+const hookStateFile = ".hook-state.yaml"
+
+// hookStateKey is the key of the hook state in hookStateFile
+const hookStateKey = "hooks"
+
+// hookState is what hooks remember between runs
+type hookState struct {
+	// ConfigHashes are the hashes of the project configuration that tasks
+	// with `when: {config_changed: true}` last succeeded with, by task
+	ConfigHashes map[string]string `yaml:"config_hashes,omitempty"`
+	// InputChecksums are the checksums of the `inputs:` of tasks
+	// when they last succeeded, by task
+	InputChecksums map[string]string `yaml:"input_checksums,omitempty"`
+}
+
+// hookStateMu serializes updates of hookStateFile by tasks running in parallel
+var hookStateMu sync.Mutex
+
+// readHookState reads the hook state of the project
+func (app *DdevApp) readHookState() (*hookState, error) {
+	s := &hookState{}
+	if err := yaml.NewState(app.GetConfigPath(hookStateFile)).Get(hookStateKey, s); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", app.GetConfigPath(hookStateFile), err)
+	}
+	return s, nil
+}
+
+// updateHookState changes the hook state of the project with update and saves it
+func (app *DdevApp) updateHookState(update func(s *hookState)) error {
+	hookStateMu.Lock()
+	defer hookStateMu.Unlock()
+	s, err := app.readHookState()
+	if err != nil {
+		return err
+	}
+	update(s)
+	st := yaml.NewState(app.GetConfigPath(hookStateFile))
+	if err = st.Set(hookStateKey, *s); err != nil {
+		return err
+	}
+	if err = st.Save(); err != nil {
+		return fmt.Errorf("unable to write %s: %v", app.GetConfigPath(hookStateFile), err)
+	}
+	return nil
+}
+
+// configHash returns a hash of the project configuration files,
+// config.yaml and the config.*.yaml files that override it
+func (app *DdevApp) configHash() (string, error) {
+	files, err := filepath.Glob(app.GetConfigPath("config.*.y*ml"))
+	if err != nil {
+		return "", err
+	}
+	files = append([]string{app.GetConfigPath("config.yaml")}, files...)
+	h := sha256.New()
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "%s %d\n", filepath.Base(f), len(content))
+		_, _ = h.Write(content)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// inputsChecksum returns a checksum of the files matching the `inputs:` globs of a task,
+// relative to the project root. Directories are included with all of their files.
+// A glob that matches nothing is part of the checksum too, so that a file appearing changes it.
+func (app *DdevApp) inputsChecksum(inputs []string) (string, error) {
+	h := sha256.New()
+	for _, input := range inputs {
+		pattern := input
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(app.GetAppRoot(), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid input '%s': %v", input, err)
+		}
+		sort.Strings(matches)
+		_, _ = fmt.Fprintf(h, "%s %d\n", input, len(matches))
+		for _, m := range matches {
+			var sum string
+			if fileutil.IsDirectory(m) {
+				sum, err = fileutil.HashDir(m)
+			} else {
+				sum, err = fileutil.FileSHA256(m)
+			}
+			if err != nil {
+				return "", err
+			}
+			rel, _ := filepath.Rel(app.GetAppRoot(), m)
+			_, _ = fmt.Fprintf(h, "%s %s\n", filepath.ToSlash(rel), sum)
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
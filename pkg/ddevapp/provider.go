@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/ddev/ddev/pkg/exec"
@@ -32,6 +33,9 @@ type ProviderInfo struct {
 	CodePullCommand      ProviderCommand   `yaml:"code_pull_command,omitempty"`
 	DBPushCommand        ProviderCommand   `yaml:"db_push_command"`
 	FilesPushCommand     ProviderCommand   `yaml:"files_push_command"`
+	DBSanitize           []SanitizeStep    `yaml:"db_sanitize,omitempty"`
+	// Environments are named targets, selected with --env, that add their own environment variables
+	Environments map[string]ProviderEnvironment `yaml:"environments,omitempty"`
 }
 
 // Provider provides generic-specific import functionality.
@@ -39,6 +43,14 @@ type Provider struct {
 	ProviderType string   `yaml:"provider"`
 	app          *DdevApp `yaml:"-"`
 	ProviderInfo `yaml:"providers"`
+	// ForceRedownload discards downloads verified by an earlier, interrupted pull
+	ForceRedownload bool `yaml:"-"`
+	// SkipSanitize skips the db_sanitize steps after the database import
+	SkipSanitize bool `yaml:"-"`
+	// Environment is the name of the selected entry of Environments, if any
+	Environment string `yaml:"-"`
+	// AllowProtected allows pushing to a protected environment
+	AllowProtected bool `yaml:"-"`
 }
 
 // Init handles loading data from saved config.
@@ -56,6 +68,9 @@ func (p *Provider) Init(pType string, app *DdevApp) error {
 	if p.EnvironmentVariables == nil {
 		p.EnvironmentVariables = map[string]string{}
 	}
+	if err = p.Validate(); err != nil {
+		return fmt.Errorf("invalid %s provider configuration in %s: %v", pType, configPath, err)
+	}
 
 	p.ProviderType = pType
 	app.ProviderInstance = p
@@ -63,8 +78,13 @@ func (p *Provider) Init(pType string, app *DdevApp) error {
 }
 
 // Pull performs an import of db and files
-func (app *DdevApp) Pull(provider *Provider, skipDBArg bool, skipFilesArg bool, skipImportArg bool) error {
-	var err error
+func (app *DdevApp) Pull(provider *Provider, skipDBArg bool, skipFilesArg bool, skipImportArg bool) (err error) {
+	// With --events, each phase of the pull is reported as it starts and finishes
+	phases := output.NewPhaseTracker(app.Name)
+	defer func() {
+		phases.Finish(err)
+	}()
+	phases.Start("pre_pull_hooks")
 	err = app.ProcessHooks("pre-pull")
 	if err != nil {
 		return fmt.Errorf("failed to process pre-pull hooks: %v", err)
@@ -73,6 +93,7 @@ func (app *DdevApp) Pull(provider *Provider, skipDBArg bool, skipFilesArg bool,
 	status, _ := app.SiteStatus()
 	if status != SiteRunning {
 		util.Warning("Project is not currently running. Starting project before performing pull.")
+		phases.Start("start_project")
 		err = app.Start()
 		if err != nil {
 			return err
@@ -80,6 +101,7 @@ func (app *DdevApp) Pull(provider *Provider, skipDBArg bool, skipFilesArg bool,
 	}
 
 	if provider.AuthCommand.Command != "" {
+		phases.Start("auth")
 		output.UserOut.Print("Authenticating...")
 		err = provider.app.ExecOnHostOrService(provider.AuthCommand.Service, provider.injectedEnvironment()+"; "+provider.AuthCommand.Command)
 		if err != nil {
@@ -87,58 +109,65 @@ func (app *DdevApp) Pull(provider *Provider, skipDBArg bool, skipFilesArg bool,
 		}
 	}
 
+	phases.Start("download")
+	// The database and files are downloaded concurrently, then imported one after the other
+	var stageNames []string
 	if skipDBArg {
 		output.UserOut.Println("Skipping database pull.")
 	} else {
-		output.UserOut.Println("Obtaining databases...")
-		fileLocation, importPath, err := provider.GetBackup("database")
-		if err != nil {
-			return err
-		}
-		err = app.MutagenSyncFlush()
-		if err != nil {
-			return err
-		}
+		stageNames = append(stageNames, "database")
+	}
+	if skipFilesArg {
+		output.UserOut.Println("Skipping files pull.")
+	} else {
+		stageNames = append(stageNames, "files")
+	}
+	downloads, err := provider.pullStages(stageNames)
+	if err != nil {
+		return err
+	}
+	err = app.MutagenSyncFlush()
+	if err != nil {
+		return err
+	}
 
+	if !skipDBArg {
 		if skipImportArg {
 			output.UserOut.Println("Skipping database import.")
 		} else {
-			err = app.MutagenSyncFlush()
-			if err != nil {
-				return err
-			}
+			phases.Start("import_database")
+			fileLocation := downloads["database"]
 			// With a db_import_command and no db_pull_command there's nothing downloaded to name here,
 			// and importDatabaseBackup() announces itself.
 			if len(fileLocation) > 0 {
 				output.UserOut.Printf("Importing databases %v", fileLocation)
 			}
-			err = provider.importDatabaseBackup(fileLocation, importPath)
+			err = provider.importDatabaseBackup(fileLocation, make([]string, len(fileLocation)))
 			if err != nil {
 				return err
 			}
+			if provider.SkipSanitize {
+				if len(provider.DBSanitize) > 0 {
+					output.UserOut.Println("Skipping database sanitization.")
+				}
+			} else if len(provider.DBSanitize) > 0 {
+				phases.Start("sanitize_database")
+				err = provider.sanitizeDatabase()
+				if err != nil {
+					return err
+				}
+			}
 		}
 	}
 
-	if skipFilesArg {
-		output.UserOut.Println("Skipping files pull.")
-	} else {
-		output.UserOut.Println("Obtaining files...")
-		files, _, err := provider.GetBackup("files")
-		if err != nil {
-			return err
-		}
-
-		err = app.MutagenSyncFlush()
-		if err != nil {
-			return err
-		}
-
+	if !skipFilesArg {
 		if skipImportArg {
 			output.UserOut.Println("Skipping files import.")
 		} else {
+			phases.Start("import_files")
 			output.UserOut.Println("Importing files...")
 			f := ""
-			if len(files) > 0 {
+			if files := downloads["files"]; len(files) > 0 {
 				f = files[0]
 			}
 			err = provider.doFilesImport(f, "")
@@ -147,6 +176,11 @@ func (app *DdevApp) Pull(provider *Provider, skipDBArg bool, skipFilesArg bool,
 			}
 		}
 	}
+	// Imported downloads are not reused, the next pull downloads fresh ones
+	if !skipImportArg {
+		provider.removePullManifest()
+	}
+	phases.Start("post_pull_hooks")
 	err = app.ProcessHooks("post-pull")
 	if err != nil {
 		return fmt.Errorf("failed to process post-pull hooks: %v", err)
@@ -157,7 +191,10 @@ func (app *DdevApp) Pull(provider *Provider, skipDBArg bool, skipFilesArg bool,
 
 // Push pushes db and files up to upstream hosting provider
 func (app *DdevApp) Push(provider *Provider, skipDBArg bool, skipFilesArg bool) error {
-	var err error
+	err := provider.CheckPushAllowed()
+	if err != nil {
+		return err
+	}
 	err = app.ProcessHooks("pre-push")
 	if err != nil {
 		return fmt.Errorf("failed to process pre-push hooks: %v", err)
@@ -215,25 +252,15 @@ func (app *DdevApp) Push(provider *Provider, skipDBArg bool, skipFilesArg bool)
 // Valid values for backupType are "database" or "files".
 // returns []fileURL, []importPath, error
 func (p *Provider) GetBackup(backupType string) ([]string, []string, error) {
-	var err error
-	var fileNames []string
 	if backupType != "database" && backupType != "files" {
 		return nil, nil, fmt.Errorf("could not get backup: %s is not a valid backup type", backupType)
 	}
 
-	p.prepDownloadDir()
-
-	switch backupType {
-	case "database":
-		fileNames, err = p.getDatabaseBackups()
-	case "files":
-		fileNames, err = p.doFilesPullCommand()
-	default:
-		return nil, nil, fmt.Errorf("could not get backup: %s is not a valid backup type", backupType)
-	}
+	downloads, err := p.pullStages([]string{backupType})
 	if err != nil {
 		return nil, nil, err
 	}
+	fileNames := downloads[backupType]
 
 	importPaths := make([]string, len(fileNames))
 	// We don't use importPaths for the providers
@@ -295,93 +322,11 @@ func (p *Provider) UploadFiles() error {
 	return nil
 }
 
-// prepDownloadDir ensures the download cache directories are created and writeable.
-func (p *Provider) prepDownloadDir() {
-	destDir := p.getDownloadDir()
-	filesDir := filepath.Join(destDir, "files")
-	_ = os.RemoveAll(destDir)
-	err := os.MkdirAll(filesDir, 0755)
-	util.CheckErr(err)
-}
-
 func (p *Provider) getDownloadDir() string {
 	destDir := p.app.GetConfigPath(".downloads")
 	return destDir
 }
 
-func (p *Provider) doFilesPullCommand() ([]string, error) {
-	destDir := filepath.Join(p.getDownloadDir(), "files")
-	_ = os.RemoveAll(destDir)
-	_ = os.MkdirAll(destDir, 0755)
-
-	if p.FilesPullCommand.Command == "" {
-		if p.FilesImportCommand.Command == "" {
-			util.Warning("No files_pull_command provided, so skipping files pull")
-		}
-		return nil, nil
-	}
-	s := p.FilesPullCommand.Service
-	if s == "" {
-		s = "web"
-	}
-
-	err := p.app.ExecOnHostOrService(s, p.injectedEnvironment()+"; "+p.FilesPullCommand.Command)
-	if err != nil {
-		return nil, fmt.Errorf("failed to exec %s on %s: %v", p.FilesPullCommand.Command, s, err)
-	}
-
-	return []string{filepath.Join(p.getDownloadDir(), "files")}, nil
-}
-
-// getDatabaseBackups retrieves database using `generic backup database`, then
-// describe until it appears, then download it.
-func (p *Provider) getDatabaseBackups() ([]string, error) {
-	err := os.RemoveAll(p.getDownloadDir())
-	if err != nil {
-		return nil, err
-	}
-	// Make sure the deletion is synced before we recreate
-	err = p.app.MutagenSyncFlush()
-	if err != nil {
-		return nil, err
-	}
-	err = os.Mkdir(p.getDownloadDir(), 0755)
-	if err != nil {
-		return nil, err
-	}
-
-	if p.DBPullCommand.Command == "" {
-		// A db_import_command may do the downloading itself, so there's nothing to warn about.
-		if p.DBImportCommand.Command == "" {
-			util.Warning("No db_pull_command provided, so skipping database pull")
-		}
-		return nil, nil
-	}
-
-	s := p.DBPullCommand.Service
-	if s == "" {
-		s = "web"
-	}
-	err = p.app.MutagenSyncFlush()
-	if err != nil {
-		return nil, err
-	}
-	err = p.app.ExecOnHostOrService(s, p.injectedEnvironment()+"; "+p.DBPullCommand.Command)
-	if err != nil {
-		return nil, fmt.Errorf("failed to exec %s on %s: %v", p.DBPullCommand.Command, s, err)
-	}
-	err = p.app.MutagenSyncFlush()
-	if err != nil {
-		return nil, err
-	}
-
-	sqlTarballs, err := fileutil.ListFilesInDirFullPath(p.getDownloadDir(), true)
-	if err != nil || sqlTarballs == nil {
-		return nil, fmt.Errorf("failed to find downloaded files in %s: %v", p.getDownloadDir(), err)
-	}
-	return sqlTarballs, nil
-}
-
 // importDatabaseBackup will import a slice of downloaded databases
 // If a custom importer is provided, that will be used, otherwise
 // the default is app.ImportDB()
@@ -468,16 +413,31 @@ func (p *Provider) Read(configPath string) error {
 
 // Validate ensures that the current configuration is valid (i.e. the configured pantheon site/environment exists)
 func (p *Provider) Validate() error {
-	return nil
+	return p.validateDBSanitize()
 }
 
 // injectedEnvironment() returns a string with environment variables that should be injected
 // before a command.
 func (p *Provider) injectedEnvironment() string {
+	return p.environmentString(false)
+}
+
+// environmentString renders the export of the environment variables in a stable order,
+// with the values of secret-looking variables masked if mask is set.
+func (p *Provider) environmentString(mask bool) string {
 	s := "true"
 	if len(p.EnvironmentVariables) > 0 {
 		s = "export"
-		for k, v := range p.EnvironmentVariables {
+		keys := make([]string, 0, len(p.EnvironmentVariables))
+		for k := range p.EnvironmentVariables {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := p.EnvironmentVariables[k]
+			if mask && isSecretEnvironmentVariable(k) {
+				v = maskedSecret
+			}
 			v = strings.ReplaceAll(v, " ", `\ `)
 			s = s + fmt.Sprintf(" %s=%s ", k, v)
 		}
@@ -486,9 +446,20 @@ func (p *Provider) injectedEnvironment() string {
 }
 
 // GetInfo returns a human-readable description of the provider's target environment.
-// It executes the info_command defined in the provider YAML if available,
+// It executes the info_command defined in the provider YAML if available, and lists
+// the provider's environments if it has any,
 // otherwise returns an empty string (which triggers the default warning message).
 func (p *Provider) GetInfo() string {
+	info := p.getInfoCommandOutput()
+	envInfo := p.environmentsInfo()
+	if info == "" || envInfo == "" {
+		return info + envInfo
+	}
+	return info + ", " + envInfo
+}
+
+// getInfoCommandOutput returns the output of the info_command, or "" if there's none or it fails
+func (p *Provider) getInfoCommandOutput() string {
 	if p.InfoCommand.Command == "" {
 		return ""
 	}
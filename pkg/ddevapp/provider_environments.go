@@ -0,0 +1,78 @@
+package ddevapp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProviderEnvironment is a named target of a provider, like "prod" or "stage"
+type ProviderEnvironment struct {
+	// EnvironmentVariables are added to, and override, the provider's environment_variables
+	EnvironmentVariables map[string]string `yaml:"environment_variables,omitempty"`
+	// Protected environments can't be pushed to without an explicit override
+	Protected bool `yaml:"protected,omitempty"`
+}
+
+// GetEnvironmentNames returns the sorted names of the provider's environments
+func (p *Provider) GetEnvironmentNames() []string {
+	var names []string
+	for name := range p.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectEnvironment makes the named environment the target of the provider,
+// adding its environment variables to the provider's.
+// An empty name keeps the provider's own environment_variables only.
+func (p *Provider) SelectEnvironment(name string) error {
+	if name == "" {
+		return nil
+	}
+	env, ok := p.Environments[name]
+	if !ok {
+		if len(p.Environments) == 0 {
+			return fmt.Errorf("the %s provider doesn't define any environments", p.ProviderType)
+		}
+		return fmt.Errorf("the %s provider has no environment '%s', available environments are: %s", p.ProviderType, name, strings.Join(p.GetEnvironmentNames(), ", "))
+	}
+	if p.EnvironmentVariables == nil {
+		p.EnvironmentVariables = map[string]string{}
+	}
+	for k, v := range env.EnvironmentVariables {
+		p.EnvironmentVariables[k] = v
+	}
+	p.Environment = name
+	return nil
+}
+
+// CheckPushAllowed returns an error if the selected environment is protected
+// and AllowProtected isn't set
+func (p *Provider) CheckPushAllowed() error {
+	if p.Environment == "" || !p.Environments[p.Environment].Protected || p.AllowProtected {
+		return nil
+	}
+	return fmt.Errorf("the '%s' environment of the %s provider is protected, use --allow-protected to push to it anyway", p.Environment, p.ProviderType)
+}
+
+// environmentsInfo describes the selected environment and the available ones,
+// or returns "" if the provider has no environments
+func (p *Provider) environmentsInfo() string {
+	if len(p.Environments) == 0 {
+		return ""
+	}
+	var available []string
+	for _, name := range p.GetEnvironmentNames() {
+		if p.Environments[name].Protected {
+			name += " [protected]"
+		}
+		available = append(available, name)
+	}
+	selected := "the default environment"
+	if p.Environment != "" {
+		selected = fmt.Sprintf("the '%s' environment", p.Environment)
+	}
+	return fmt.Sprintf("%s (available environments: %s)", selected, strings.Join(available, ", "))
+}
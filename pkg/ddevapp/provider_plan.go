@@ -0,0 +1,202 @@
+package ddevapp
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// maskedSecret replaces the values of secret environment variables in a pull plan
+const maskedSecret = "********"
+
+// secretEnvironmentVariable matches the names of environment variables whose values are masked in a pull plan
+var secretEnvironmentVariable = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASS|KEY|AUTH|CREDENTIAL)`)
+
+// isSecretEnvironmentVariable reports whether the value of the named variable is masked in a pull plan
+func isSecretEnvironmentVariable(name string) bool {
+	return secretEnvironmentVariable.MatchString(name)
+}
+
+// PullPlanStep is a single step of a pull, in the order the pull runs it
+type PullPlanStep struct {
+	// Stage is the part of the pull the step belongs to, like "auth" or "database import"
+	Stage string `json:"stage"`
+	// Service is where Command runs, "host" for the host
+	Service string `json:"service,omitempty"`
+	// Command is the fully resolved command, with secrets masked
+	Command string `json:"command,omitempty"`
+	// Description says what a step without a command does, or why a command isn't run
+	Description string `json:"description,omitempty"`
+}
+
+// PullPlan describes everything a pull would do, without doing any of it
+type PullPlan struct {
+	Provider string `json:"provider"`
+	// ProviderEnvironment is the selected entry of the provider's environments, if any
+	ProviderEnvironment string            `json:"provider_environment,omitempty"`
+	DownloadDir         string            `json:"download_dir"`
+	Environment         map[string]string `json:"environment"`
+	Steps               []PullPlanStep    `json:"steps"`
+	// Databases are the databases that will be overwritten; when they can't be known
+	// before the download, DatabasesNote says how they're chosen
+	Databases     []string `json:"databases,omitempty"`
+	DatabasesNote string   `json:"databases_note,omitempty"`
+	// UploadDirs are the host directories that will be overwritten; when they can't
+	// be known, UploadDirsNote says why
+	UploadDirs     []string `json:"upload_dirs,omitempty"`
+	UploadDirsNote string   `json:"upload_dirs_note,omitempty"`
+}
+
+// GetPullPlan resolves what app.Pull() would do with the same arguments.
+// Nothing is executed and nothing is downloaded; downloads verified by an earlier,
+// interrupted pull are reported as reused.
+func (app *DdevApp) GetPullPlan(provider *Provider, skipDBArg bool, skipFilesArg bool, skipImportArg bool) (*PullPlan, error) {
+	plan := &PullPlan{
+		Provider:            provider.ProviderType,
+		ProviderEnvironment: provider.Environment,
+		DownloadDir:         provider.getDownloadDir(),
+		Environment:         map[string]string{},
+	}
+	for k, v := range provider.EnvironmentVariables {
+		if isSecretEnvironmentVariable(k) {
+			v = maskedSecret
+		}
+		plan.Environment[k] = v
+	}
+	manifest := &pullManifest{Stages: map[string]*pullManifestStage{}}
+	if !provider.ForceRedownload {
+		manifest = provider.readPullManifest()
+	}
+
+	plan.addHookSteps(app, "pre-pull")
+	if provider.AuthCommand.Command != "" {
+		plan.addCommandStep("auth", provider.AuthCommand, provider)
+	}
+
+	var dbFiles []string
+	dbVerified := false
+	if !skipDBArg && provider.DBPullCommand.Command != "" {
+		stage := &pullStage{name: "database", command: provider.DBPullCommand}
+		dbFiles, dbVerified = provider.verifiedPullArtifacts(manifest, stage)
+		plan.addDownloadStep(stage, dbVerified, provider)
+	}
+	if !skipFilesArg && provider.FilesPullCommand.Command != "" {
+		stage := &pullStage{name: "files", command: provider.FilesPullCommand}
+		_, verified := provider.verifiedPullArtifacts(manifest, stage)
+		plan.addDownloadStep(stage, verified, provider)
+	}
+
+	if !skipDBArg && !skipImportArg {
+		plan.addHookSteps(app, "pre-import-db")
+		switch {
+		case provider.DBImportCommand.Command != "":
+			plan.addCommandStep("database import", provider.DBImportCommand, provider)
+			plan.DatabasesNote = "determined by db_import_command"
+		case dbVerified:
+			for _, f := range dbFiles {
+				dbName := strings.Split(path.Base(f), ".")[0]
+				plan.Databases = append(plan.Databases, dbName)
+				plan.Steps = append(plan.Steps, PullPlanStep{Stage: "database import", Service: "db", Description: fmt.Sprintf("Import %s into database '%s'", f, dbName)})
+			}
+		case provider.DBPullCommand.Command != "":
+			plan.DatabasesNote = "named after the downloaded files, for example db.sql.gz is imported into database 'db'"
+			plan.Steps = append(plan.Steps, PullPlanStep{Stage: "database import", Service: "db", Description: "Import each downloaded database file into the database named after it"})
+		}
+		plan.addHookSteps(app, "post-import-db")
+
+		if provider.SkipSanitize {
+			if len(provider.DBSanitize) > 0 {
+				plan.Steps = append(plan.Steps, PullPlanStep{Stage: "database sanitize", Description: "Skipped because of --skip-sanitize"})
+			}
+		} else {
+			steps, err := provider.getDBSanitizeSteps()
+			if err != nil {
+				return nil, err
+			}
+			for _, step := range steps {
+				if step.SQL != "" {
+					database := step.Database
+					if database == "" {
+						database = "db"
+					}
+					plan.Steps = append(plan.Steps, PullPlanStep{Stage: "database sanitize", Service: "db", Command: step.SQL, Description: fmt.Sprintf("SQL run against database '%s'", database)})
+					continue
+				}
+				plan.addCommandStep("database sanitize", ProviderCommand{Command: step.Command, Service: step.Service}, provider)
+			}
+		}
+	}
+
+	if !skipFilesArg && !skipImportArg {
+		switch {
+		case provider.FilesImportCommand.Command != "":
+			plan.addHookSteps(app, "pre-import-files")
+			plan.addCommandStep("files import", provider.FilesImportCommand, provider)
+			plan.addHookSteps(app, "post-import-files")
+			plan.UploadDirsNote = "determined by files_import_command"
+		case provider.FilesPullCommand.Command != "":
+			plan.addHookSteps(app, "pre-import-files")
+			uploadDir := app.GetUploadDir()
+			if uploadDir == "" {
+				plan.UploadDirsNote = "upload_dirs is not set, so the files import will fail"
+				plan.Steps = append(plan.Steps, PullPlanStep{Stage: "files import", Description: "Fails because upload_dirs is not set"})
+			} else {
+				hostUploadDir := app.calculateHostUploadDirFullPath(uploadDir)
+				plan.UploadDirs = []string{hostUploadDir}
+				plan.Steps = append(plan.Steps, PullPlanStep{Stage: "files import", Service: "host", Description: fmt.Sprintf("Replace the contents of %s with the downloaded files", hostUploadDir)})
+			}
+			plan.addHookSteps(app, "post-import-files")
+		}
+	}
+	plan.addHookSteps(app, "post-pull")
+
+	return plan, nil
+}
+
+// addCommandStep adds a provider command with its injected environment, secrets masked
+func (plan *PullPlan) addCommandStep(stage string, command ProviderCommand, provider *Provider) {
+	s := command.Service
+	if s == "" {
+		s = "web"
+	}
+	plan.Steps = append(plan.Steps, PullPlanStep{Stage: stage, Service: s, Command: provider.environmentString(true) + "; " + command.Command})
+}
+
+// addDownloadStep adds the download of a pull stage, or notes that an earlier download is reused
+func (plan *PullPlan) addDownloadStep(stage *pullStage, verified bool, provider *Provider) {
+	plan.addCommandStep(stage.name+" download", stage.command, provider)
+	if verified {
+		plan.Steps[len(plan.Steps)-1].Description = "Not run, the verified download of an earlier pull is reused, use --force-redownload to download it again"
+	}
+}
+
+// addHookSteps adds the tasks of a hook, which are described rather than resolved.
+// Their conditions aren't checked, they're described too.
+func (plan *PullPlan) addHookSteps(app *DdevApp, hookName string) {
+	if SkipHooks {
+		return
+	}
+	for _, c := range app.Hooks[hookName] {
+		step, err := app.newHookStep(hookName, c)
+		if err != nil {
+			continue
+		}
+		for _, t := range step.tasks {
+			description := t.task.GetDescription()
+			if step.parallel {
+				description = fmt.Sprintf("[%s] %s, in parallel", t.label, description)
+				if step.when != nil {
+					description += fmt.Sprintf(" when %s", step.when)
+				}
+			}
+			if t.when != nil {
+				description += fmt.Sprintf(" when %s", t.when)
+			}
+			if len(t.inputs) > 0 {
+				description += fmt.Sprintf(", skipped if %s didn't change", strings.Join(t.inputs, ", "))
+			}
+			plan.Steps = append(plan.Steps, PullPlanStep{Stage: hookName + " hook", Description: description})
+		}
+	}
+}
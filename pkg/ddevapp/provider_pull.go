@@ -0,0 +1,432 @@
+package ddevapp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
+	"go.yaml.in/yaml/v4"
+)
+
+// pullManifestName is the manifest of verified downloads in the download directory
+const pullManifestName = ".pull-manifest.yaml"
+
+// pullProgressInterval is how often the progress of running pull stages is reported
+const pullProgressInterval = 30 * time.Second
+
+// pullManifest records the downloads of completed pull stages, so an interrupted
+// pull can be resumed without downloading them again
+type pullManifest struct {
+	Provider string                        `yaml:"provider"`
+	Stages   map[string]*pullManifestStage `yaml:"stages"`
+}
+
+// pullManifestStage records the downloads of a single pull stage
+type pullManifestStage struct {
+	// Key identifies the command and environment that created the download
+	Key       string    `yaml:"key"`
+	Completed time.Time `yaml:"completed"`
+	// Checksums are the SHA-256 checksums of the files the stage downloaded, relative to
+	// the download dir. Only these files are imported, reused and removed by later pulls.
+	Checksums map[string]string `yaml:"checksums"`
+}
+
+// pullStage is the download step of "database" or "files" in a pull
+type pullStage struct {
+	name    string
+	command ProviderCommand
+	// artifacts are the downloaded files, set when the stage succeeds
+	artifacts []string
+	err       error
+	duration  time.Duration
+}
+
+// readPullManifest reads the manifest of verified downloads, returning an empty
+// manifest if it doesn't exist or belongs to another provider
+func (p *Provider) readPullManifest() *pullManifest {
+	manifest := &pullManifest{Provider: p.ProviderType, Stages: map[string]*pullManifestStage{}}
+	content, err := os.ReadFile(filepath.Join(p.getDownloadDir(), pullManifestName))
+	if err != nil {
+		return manifest
+	}
+	existing := &pullManifest{}
+	if err = yaml.Unmarshal(content, existing); err != nil || existing.Provider != p.ProviderType || existing.Stages == nil {
+		return manifest
+	}
+	return existing
+}
+
+// writePullManifest writes the manifest of verified downloads
+func (p *Provider) writePullManifest(manifest *pullManifest) error {
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(p.getDownloadDir(), pullManifestName), content, 0644)
+}
+
+// removePullManifest forgets the verified downloads, so the next pull downloads again
+func (p *Provider) removePullManifest() {
+	_ = os.Remove(filepath.Join(p.getDownloadDir(), pullManifestName))
+}
+
+// pullStageKey identifies the command and environment of a pull stage,
+// so downloads made for another environment aren't reused
+func (p *Provider) pullStageKey(command ProviderCommand) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(p.injectedEnvironment()+"\n"+command.Service+"\n"+command.Command)))
+}
+
+// pullStages runs the download commands of the named stages ("database", "files")
+// concurrently. Stages whose downloads were already verified by an earlier,
+// interrupted pull are skipped unless ForceRedownload is set.
+// Returns the downloaded files of each stage.
+func (p *Provider) pullStages(stageNames []string) (map[string][]string, error) {
+	if p.ForceRedownload {
+		_ = os.RemoveAll(p.getDownloadDir())
+	}
+	err := os.MkdirAll(filepath.Join(p.getDownloadDir(), "files"), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = p.app.MutagenSyncFlush()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := p.readPullManifest()
+	var all, stages []*pullStage
+	for _, name := range stageNames {
+		stage := &pullStage{name: name, command: p.DBPullCommand}
+		if name == "files" {
+			stage.command = p.FilesPullCommand
+		}
+		all = append(all, stage)
+		if stage.command.Command == "" {
+			// A db_import_command or files_import_command may do the downloading itself,
+			// so there's nothing to warn about.
+			if name == "database" && p.DBImportCommand.Command == "" {
+				util.Warning("No db_pull_command provided, so skipping database pull")
+			}
+			if name == "files" && p.FilesImportCommand.Command == "" {
+				util.Warning("No files_pull_command provided, so skipping files pull")
+			}
+			continue
+		}
+		if artifacts, ok := p.verifiedPullArtifacts(manifest, stage); ok {
+			stage.artifacts = artifacts
+			output.UserOut.Printf("[%s] Using the verified download of an earlier pull, use --force-redownload to download it again", name)
+			continue
+		}
+		if err = p.removePullArtifacts(name, manifest.Stages[name]); err != nil {
+			return nil, err
+		}
+		delete(manifest.Stages, name)
+		stages = append(stages, stage)
+	}
+	if err = p.writePullManifest(manifest); err != nil {
+		return nil, err
+	}
+	// Make sure the deletions are synced before the downloads start
+	err = p.app.MutagenSyncFlush()
+	if err != nil {
+		return nil, err
+	}
+	// The files written in the download dir by the stages are found by comparing
+	// it before and after, so that files that were already there aren't taken for downloads
+	before, err := p.listPullDownloads()
+	if err != nil {
+		return nil, err
+	}
+
+	finished := make(chan *pullStage)
+	running := map[string]bool{}
+	for _, stage := range stages {
+		running[stage.name] = true
+		go func(stage *pullStage) {
+			start := time.Now()
+			output.UserOut.Printf("[%s] Downloading...", stage.name)
+			stage.err = p.runPullStageCommand(stage)
+			stage.duration = time.Since(start)
+			finished <- stage
+		}(stage)
+	}
+	start := time.Now()
+	ticker := time.NewTicker(pullProgressInterval)
+	defer ticker.Stop()
+	for len(running) > 0 {
+		select {
+		case stage := <-finished:
+			delete(running, stage.name)
+		case <-ticker.C:
+			var names []string
+			for name := range running {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			output.UserOut.Printf("Still downloading %s after %s...", strings.Join(names, " and "), time.Since(start).Round(time.Second))
+		}
+	}
+
+	err = p.app.MutagenSyncFlush()
+	if err != nil {
+		return nil, err
+	}
+	written, err := p.writtenPullDownloads(before)
+	if err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, stage := range stages {
+		if stage.err == nil {
+			stage.err = p.recordPullArtifacts(manifest, stage, p.pullStageFiles(stage.name, written, len(stages) > 1))
+		}
+		if stage.err != nil {
+			errs = append(errs, fmt.Sprintf("%s pull failed: %v", stage.name, stage.err))
+			continue
+		}
+		var size int64
+		for _, artifact := range stage.artifacts {
+			s, _ := fileutil.DirSize(artifact)
+			size += s
+		}
+		util.Success("[%s] Downloaded %s in %s", stage.name, util.FormatBytes(size), stage.duration.Round(time.Second))
+	}
+	if err = p.writePullManifest(manifest); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s\nCompleted downloads are kept and reused by the next pull", strings.Join(errs, "\n"))
+	}
+
+	results := map[string][]string{}
+	for _, stage := range all {
+		results[stage.name] = stage.artifacts
+	}
+	return results, nil
+}
+
+// runPullStageCommand runs the pull command of a stage, prefixing its output
+// with the stage name because the stages run concurrently
+func (p *Provider) runPullStageCommand(stage *pullStage) error {
+	cmd := p.injectedEnvironment() + "; " + stage.command.Command
+	out := &prefixedLineWriter{prefix: "[" + stage.name + "] ", println: output.UserOut.Println}
+	service := stage.command.Service
+	if service == "" {
+		service = "web"
+	}
+	var err error
+	if service == "host" {
+		err = runHostCommandWithOutput(p.app, cmd, out)
+	} else {
+		_, _, err = p.app.Exec(&ExecOpts{
+			Service: service,
+			Cmd:     cmd,
+			Stdout:  out,
+			Stderr:  out,
+		})
+	}
+	out.Flush()
+	if err != nil {
+		return errorWithOutput(fmt.Errorf("failed to exec %s on %s: %v", stage.command.Command, service, err), out.captured.String())
+	}
+	return nil
+}
+
+// prefixedLineWriter writes output line by line, each line prefixed, for output of
+// commands running concurrently, like pull stages and parallel hook tasks.
+// It keeps a copy of the output for error messages
+type prefixedLineWriter struct {
+	prefix   string
+	println  func(args ...any)
+	buf      []byte
+	captured bytes.Buffer
+	mu       sync.Mutex
+}
+
+// Write implements io.Writer
+func (w *prefixedLineWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.captured.Write(b)
+	w.buf = append(w.buf, b...)
+	for {
+		// Progress output like dd and rsync uses carriage returns
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if line := bytes.TrimSpace(w.buf[:i]); len(line) > 0 {
+			w.println(w.prefix + string(line))
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes out a last line that has no line ending
+func (w *prefixedLineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if line := bytes.TrimSpace(w.buf); len(line) > 0 {
+		w.println(w.prefix + string(line))
+	}
+	w.buf = nil
+}
+
+// pullStageArtifacts returns the downloaded files of a stage.
+// The files stage downloads into the files subdirectory, the database stage
+// into the download directory itself.
+func (p *Provider) pullStageArtifacts(name string, stage *pullManifestStage) []string {
+	if name == "files" {
+		return []string{filepath.Join(p.getDownloadDir(), "files")}
+	}
+	var artifacts []string
+	for rel := range stage.Checksums {
+		artifacts = append(artifacts, filepath.Join(p.getDownloadDir(), filepath.FromSlash(rel)))
+	}
+	sort.Strings(artifacts)
+	return artifacts
+}
+
+// pullDownloadState is the size and modification time of a file in the download dir
+type pullDownloadState struct {
+	size    int64
+	modTime time.Time
+}
+
+// listPullDownloads returns the files at the top of the download dir, without the manifest
+func (p *Provider) listPullDownloads() (map[string]pullDownloadState, error) {
+	entries, err := os.ReadDir(p.getDownloadDir())
+	if err != nil {
+		return nil, err
+	}
+	downloads := map[string]pullDownloadState{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || entry.Name() == pullManifestName {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		downloads[entry.Name()] = pullDownloadState{size: info.Size(), modTime: info.ModTime()}
+	}
+	return downloads, nil
+}
+
+// writtenPullDownloads returns the files at the top of the download dir that
+// were added or changed since before was listed
+func (p *Provider) writtenPullDownloads(before map[string]pullDownloadState) ([]string, error) {
+	after, err := p.listPullDownloads()
+	if err != nil {
+		return nil, err
+	}
+	var written []string
+	for name, state := range after {
+		if previous, ok := before[name]; !ok || previous.size != state.size || !previous.modTime.Equal(state.modTime) {
+			written = append(written, name)
+		}
+	}
+	sort.Strings(written)
+	return written, nil
+}
+
+// pullStageFiles returns the files a stage downloaded, relative to the download dir:
+// the files subdirectory for the files stage, and the files it wrote at the top of
+// the download dir. When both stages ran at the same time, the archives named
+// files.* that were written there, like files.tgz, belong to the files stage.
+func (p *Provider) pullStageFiles(name string, written []string, concurrent bool) []string {
+	var files []string
+	if name == "files" {
+		filesDir := filepath.Join(p.getDownloadDir(), "files")
+		_ = filepath.WalkDir(filesDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				rel, _ := filepath.Rel(p.getDownloadDir(), path)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+	}
+	for _, f := range written {
+		isFilesArchive := strings.HasPrefix(f, "files.")
+		if !concurrent || isFilesArchive == (name == "files") {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// removePullArtifacts removes the downloads of a stage recorded in the manifest
+// before downloading again, and empties the files subdirectory for the files stage
+func (p *Provider) removePullArtifacts(name string, stage *pullManifestStage) error {
+	if stage != nil {
+		for f := range stage.Checksums {
+			if err := os.RemoveAll(filepath.Join(p.getDownloadDir(), filepath.FromSlash(f))); err != nil {
+				return err
+			}
+		}
+	}
+	if name == "files" {
+		filesDir := filepath.Join(p.getDownloadDir(), "files")
+		if err := os.RemoveAll(filesDir); err != nil {
+			return err
+		}
+		return os.MkdirAll(filesDir, 0755)
+	}
+	return nil
+}
+
+// recordPullArtifacts checksums the downloads of a completed stage into the manifest
+func (p *Provider) recordPullArtifacts(manifest *pullManifest, stage *pullStage, files []string) error {
+	if stage.name == "database" && len(files) == 0 {
+		return fmt.Errorf("failed to find downloaded files in %s", p.getDownloadDir())
+	}
+	checksums := map[string]string{}
+	var err error
+	for _, f := range files {
+		if checksums[f], err = fileutil.FileSHA256(filepath.Join(p.getDownloadDir(), filepath.FromSlash(f))); err != nil {
+			return err
+		}
+	}
+	manifestStage := &pullManifestStage{Key: p.pullStageKey(stage.command), Completed: time.Now(), Checksums: checksums}
+	manifest.Stages[stage.name] = manifestStage
+	stage.artifacts = p.pullStageArtifacts(stage.name, manifestStage)
+	return nil
+}
+
+// verifiedPullArtifacts checks whether the manifest has downloads of the stage,
+// made with the same command and environment, that still match their checksums
+func (p *Provider) verifiedPullArtifacts(manifest *pullManifest, stage *pullStage) ([]string, bool) {
+	manifestStage, ok := manifest.Stages[stage.name]
+	if !ok || manifestStage.Key != p.pullStageKey(stage.command) {
+		return nil, false
+	}
+	for f, recorded := range manifestStage.Checksums {
+		checksum, err := fileutil.FileSHA256(filepath.Join(p.getDownloadDir(), filepath.FromSlash(f)))
+		if err != nil || checksum != recorded {
+			return nil, false
+		}
+	}
+	// Files added to the files subdirectory since are not part of the download
+	if stage.name == "files" {
+		for _, f := range p.pullStageFiles(stage.name, nil, false) {
+			if _, ok := manifestStage.Checksums[f]; !ok {
+				return nil, false
+			}
+		}
+	}
+	return p.pullStageArtifacts(stage.name, manifestStage), true
+}
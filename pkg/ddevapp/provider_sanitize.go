@@ -0,0 +1,169 @@
+package ddevapp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ddev/ddev/pkg/nodeps"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
+)
+
+// SanitizePresetAuto is the db_sanitize preset name that selects the preset for the project type
+const SanitizePresetAuto = "auto"
+
+// SanitizeStep is a single step of a provider's db_sanitize section.
+// Exactly one of Preset, SQL or Command is set.
+type SanitizeStep struct {
+	// Preset is a built-in set of steps for a project type, or "auto" for the project's type
+	Preset string `yaml:"preset,omitempty"`
+	// SQL is a statement run against Database in the db container
+	SQL string `yaml:"sql,omitempty"`
+	// Database is the database SQL is run against, "db" by default
+	Database string `yaml:"database,omitempty"`
+	// Command is a shell command run in Service
+	Command string `yaml:"command,omitempty"`
+	// Service is where Command runs, "web" by default, or "host"
+	Service string `yaml:"service,omitempty"`
+}
+
+// drupalSanitizeSQL replaces the email addresses and password hashes of all Drupal 8+ users
+// except the anonymous user. Log in with `ddev drush uli` afterwards.
+var drupalSanitizeSQL = []string{
+	"UPDATE users_field_data SET mail = CONCAT('user+', uid, '@example.com'), init = CONCAT('user+', uid, '@example.com'), pass = 'sanitized' WHERE uid > 0",
+	"TRUNCATE TABLE sessions",
+}
+
+// drupal7SanitizeSQL is drupalSanitizeSQL for Drupal 6/7 and Backdrop, which keep users in the users table
+var drupal7SanitizeSQL = []string{
+	"UPDATE users SET mail = CONCAT('user+', uid, '@example.com'), init = CONCAT('user+', uid, '@example.com'), pass = 'sanitized' WHERE uid > 0",
+	"TRUNCATE TABLE sessions",
+}
+
+// sanitizePresets are the built-in db_sanitize presets by project type
+var sanitizePresets = map[string][]SanitizeStep{
+	nodeps.AppTypeBackdrop: sqlSanitizeSteps(drupal7SanitizeSQL),
+	nodeps.AppTypeDrupal6:  sqlSanitizeSteps(drupal7SanitizeSQL),
+	nodeps.AppTypeDrupal7:  sqlSanitizeSteps(drupal7SanitizeSQL),
+	nodeps.AppTypeDrupal8:  sqlSanitizeSteps(drupalSanitizeSQL),
+	nodeps.AppTypeDrupal9:  sqlSanitizeSteps(drupalSanitizeSQL),
+	nodeps.AppTypeDrupal10: sqlSanitizeSteps(drupalSanitizeSQL),
+	nodeps.AppTypeDrupal11: sqlSanitizeSteps(drupalSanitizeSQL),
+	nodeps.AppTypeDrupal12: sqlSanitizeSteps(drupalSanitizeSQL),
+	nodeps.AppTypeDrupal:   sqlSanitizeSteps(drupalSanitizeSQL),
+	// WordPress tables have a configurable prefix, so wp-cli is used to find it.
+	// The password of every user becomes "password", WordPress rehashes plain MD5 hashes on login.
+	nodeps.AppTypeWordPress: {{Command: wordpressSanitizeCommand}},
+	nodeps.AppTypeWPBedrock: {{Command: wordpressSanitizeCommand}},
+}
+
+const wordpressSanitizeCommand = `prefix=$(wp db prefix) && wp db query "UPDATE ${prefix}users SET user_email = CONCAT('user+', ID, '@example.com'), user_pass = MD5('password')"`
+
+// sqlSanitizeSteps turns SQL statements into sanitize steps
+func sqlSanitizeSteps(statements []string) []SanitizeStep {
+	var steps []SanitizeStep
+	for _, sql := range statements {
+		steps = append(steps, SanitizeStep{SQL: sql})
+	}
+	return steps
+}
+
+// validateDBSanitize checks the db_sanitize section of the provider
+func (p *Provider) validateDBSanitize() error {
+	for i, step := range p.DBSanitize {
+		set := 0
+		for _, v := range []string{step.Preset, step.SQL, step.Command} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("db_sanitize step %d must have exactly one of preset, sql or command", i+1)
+		}
+		if step.Preset != "" && step.Preset != SanitizePresetAuto {
+			if _, ok := sanitizePresets[step.Preset]; !ok {
+				return fmt.Errorf("db_sanitize step %d has an unknown preset '%s', valid presets are '%s' and the project types %v", i+1, step.Preset, SanitizePresetAuto, GetSanitizePresetNames())
+			}
+		}
+		if step.SQL != "" && step.Service != "" {
+			return fmt.Errorf("db_sanitize step %d: service can only be used with command", i+1)
+		}
+		if step.Command != "" && step.Database != "" {
+			return fmt.Errorf("db_sanitize step %d: database can only be used with sql", i+1)
+		}
+	}
+	return nil
+}
+
+// GetSanitizePresetNames returns the project types that have a built-in db_sanitize preset
+func GetSanitizePresetNames() []string {
+	var names []string
+	for name := range sanitizePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getDBSanitizeSteps resolves the presets in the db_sanitize section into the steps to run
+func (p *Provider) getDBSanitizeSteps() ([]SanitizeStep, error) {
+	var steps []SanitizeStep
+	for _, step := range p.DBSanitize {
+		if step.Preset == "" {
+			steps = append(steps, step)
+			continue
+		}
+		preset := step.Preset
+		if preset == SanitizePresetAuto {
+			preset = p.app.Type
+		}
+		presetSteps, ok := sanitizePresets[preset]
+		if !ok {
+			return nil, fmt.Errorf("there is no built-in db_sanitize preset for project type '%s', please use sql or command steps instead", preset)
+		}
+		steps = append(steps, presetSteps...)
+	}
+	return steps, nil
+}
+
+// sanitizeDatabase runs the db_sanitize steps of the provider after a database import
+func (p *Provider) sanitizeDatabase() error {
+	steps, err := p.getDBSanitizeSteps()
+	if err != nil || len(steps) == 0 {
+		return err
+	}
+	output.UserOut.Printf("Sanitizing database with %d db_sanitize step(s)...", len(steps))
+	for _, step := range steps {
+		if step.SQL != "" {
+			database := step.Database
+			if database == "" {
+				database = "db"
+			}
+			// The statement is passed in the environment to avoid quoting it
+			c := fmt.Sprintf(`%s %s -e "$DDEV_SANITIZE_SQL"`, p.app.GetDBClientCommand(), database)
+			if p.app.Database.Type == nodeps.Postgres {
+				c = fmt.Sprintf(`psql -q -v ON_ERROR_STOP=1 -d %s -c "$DDEV_SANITIZE_SQL"`, database)
+			}
+			_, stderr, err := p.app.Exec(&ExecOpts{
+				Service: "db",
+				Cmd:     c,
+				Env:     []string{"DDEV_SANITIZE_SQL=" + step.SQL},
+			})
+			if err != nil {
+				return fmt.Errorf("db_sanitize failed on '%s': %v, stderr=%s", step.SQL, err, strings.TrimSpace(stderr))
+			}
+			continue
+		}
+		s := step.Service
+		if s == "" {
+			s = "web"
+		}
+		err = p.app.ExecOnHostOrService(s, p.injectedEnvironment()+"; "+step.Command)
+		if err != nil {
+			return fmt.Errorf("db_sanitize failed to exec %s on %s: %v", step.Command, s, err)
+		}
+	}
+	util.Success("Database was sanitized")
+	return nil
+}
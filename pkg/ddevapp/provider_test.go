@@ -1,9 +1,13 @@
 package ddevapp
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	asrt "github.com/stretchr/testify/assert"
+	rqr "github.com/stretchr/testify/require"
 )
 
 // TestProviderInfoField tests that the InfoCommand field exists in ProviderInfo
@@ -23,3 +27,238 @@ func TestProviderInfoField(t *testing.T) {
 	assert.Equal("echo test", p.InfoCommand.Command)
 	assert.Equal("web", p.InfoCommand.Service)
 }
+
+// TestPullStagesResume tests that downloads verified by an interrupted pull are
+// reused, and downloaded again when they were changed or when forced
+func TestPullStagesResume(t *testing.T) {
+	assert := asrt.New(t)
+	require := rqr.New(t)
+
+	app := &DdevApp{Name: "pullresume", AppRoot: t.TempDir()}
+	// The stages run concurrently, so each counts its runs in its own file
+	counterDir := t.TempDir()
+	runs := func(stage string) int {
+		content, _ := os.ReadFile(filepath.Join(counterDir, stage))
+		return len(content)
+	}
+	p := &Provider{
+		ProviderType: "test",
+		app:          app,
+		ProviderInfo: ProviderInfo{
+			DBPullCommand: ProviderCommand{
+				Service: "host",
+				Command: fmt.Sprintf(`printf x >> %s && echo "CREATE TABLE t (id int);" > .ddev/.downloads/db.sql && printf "progress 1\rprogress 2\n"`, filepath.Join(counterDir, "database")),
+			},
+			FilesPullCommand: ProviderCommand{
+				Service: "host",
+				Command: fmt.Sprintf(`printf x >> %s && echo image > .ddev/.downloads/files/image.txt && echo archive > .ddev/.downloads/files.tgz`, filepath.Join(counterDir, "files")),
+			},
+		},
+	}
+	require.NoError(os.MkdirAll(app.GetConfigPath(""), 0755))
+	// Files that were there before aren't downloads, so they aren't imported or removed
+	require.NoError(os.MkdirAll(p.getDownloadDir(), 0755))
+	strayFile := filepath.Join(p.getDownloadDir(), "notes.txt")
+	require.NoError(os.WriteFile(strayFile, []byte("notes"), 0644))
+
+	downloads, err := p.pullStages([]string{"database", "files"})
+	require.NoError(err)
+	assert.Equal([]string{filepath.Join(p.getDownloadDir(), "db.sql")}, downloads["database"])
+	assert.Equal([]string{filepath.Join(p.getDownloadDir(), "files")}, downloads["files"])
+	manifest := p.readPullManifest()
+	assert.Len(manifest.Stages["database"].Checksums, 1)
+	assert.Contains(manifest.Stages["files"].Checksums, "files.tgz")
+	assert.Contains(manifest.Stages["files"].Checksums, "files/image.txt")
+
+	// Verified downloads aren't downloaded again
+	_, err = p.pullStages([]string{"database", "files"})
+	require.NoError(err)
+	assert.Equal(1, runs("database"))
+	assert.Equal(1, runs("files"))
+
+	// A changed download is downloaded again
+	require.NoError(os.WriteFile(filepath.Join(p.getDownloadDir(), "files", "image.txt"), []byte("changed"), 0644))
+	_, err = p.pullStages([]string{"database", "files"})
+	require.NoError(err)
+	assert.Equal(1, runs("database"))
+	assert.Equal(2, runs("files"))
+	assert.FileExists(strayFile)
+
+	// --force-redownload downloads everything again
+	p.ForceRedownload = true
+	_, err = p.pullStages([]string{"database"})
+	require.NoError(err)
+	assert.Equal(2, runs("database"))
+	p.ForceRedownload = false
+
+	// A database download that changed is downloaded again, and stays the only one
+	require.NoError(os.WriteFile(strayFile, []byte("notes"), 0644))
+	require.NoError(os.WriteFile(filepath.Join(p.getDownloadDir(), "db.sql"), []byte("changed"), 0644))
+	downloads, err = p.pullStages([]string{"database"})
+	require.NoError(err)
+	assert.Equal(3, runs("database"))
+	assert.Equal([]string{filepath.Join(p.getDownloadDir(), "db.sql")}, downloads["database"])
+	assert.FileExists(strayFile)
+
+	// A failing stage doesn't discard the download of the other one
+	p.FilesPullCommand.Command = "exit 3"
+	p.removePullManifest()
+	_, err = p.pullStages([]string{"database", "files"})
+	require.ErrorContains(err, "files pull failed")
+	manifest = p.readPullManifest()
+	assert.Contains(manifest.Stages, "database")
+	assert.NotContains(manifest.Stages, "files")
+}
+
+// TestPullStageWriter tests that concurrent stage output is prefixed line by line
+func TestPullStageWriter(t *testing.T) {
+	var lines []string
+	w := &prefixedLineWriter{prefix: "[db] ", println: func(args ...any) { lines = append(lines, fmt.Sprint(args...)) }}
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\r\n10%\r20%"))
+	w.Flush()
+	asrt.Equal(t, []string{"[db] one", "[db] two", "[db] 10%", "[db] 20%"}, lines)
+	asrt.Equal(t, "one\ntwo\r\n10%\r20%", w.captured.String())
+}
+
+// TestDBSanitizeSteps tests validation of db_sanitize and resolution of its presets
+func TestDBSanitizeSteps(t *testing.T) {
+	assert := asrt.New(t)
+
+	p := &Provider{app: &DdevApp{Type: "drupal11"}}
+	p.DBSanitize = []SanitizeStep{
+		{Preset: SanitizePresetAuto},
+		{SQL: "DELETE FROM webform_submission"},
+		{Command: "drush cr", Service: "web"},
+	}
+	assert.NoError(p.Validate())
+	steps, err := p.getDBSanitizeSteps()
+	assert.NoError(err)
+	assert.Equal(append(sqlSanitizeSteps(drupalSanitizeSQL), p.DBSanitize[1:]...), steps)
+
+	p.DBSanitize = []SanitizeStep{{Preset: "wordpress"}}
+	steps, err = p.getDBSanitizeSteps()
+	assert.NoError(err)
+	assert.Equal([]SanitizeStep{{Command: wordpressSanitizeCommand}}, steps)
+
+	// A project type without a preset
+	p.app.Type = "laravel"
+	p.DBSanitize = []SanitizeStep{{Preset: SanitizePresetAuto}}
+	assert.NoError(p.Validate())
+	_, err = p.getDBSanitizeSteps()
+	assert.ErrorContains(err, "laravel")
+
+	for _, invalid := range [][]SanitizeStep{
+		{{}},
+		{{SQL: "SELECT 1", Command: "true"}},
+		{{Preset: "nosuchtype"}},
+		{{SQL: "SELECT 1", Service: "web"}},
+		{{Command: "true", Database: "db"}},
+	} {
+		p.DBSanitize = invalid
+		assert.Error(p.Validate(), "%v", invalid)
+	}
+}
+
+// TestGetPullPlan tests that the pull plan resolves the commands without running them
+func TestGetPullPlan(t *testing.T) {
+	assert := asrt.New(t)
+	require := rqr.New(t)
+
+	app := &DdevApp{Name: "pullplan", AppRoot: t.TempDir(), Type: "drupal11", UploadDirs: []string{"sites/default/files"}}
+	marker := filepath.Join(t.TempDir(), "ran")
+	p := &Provider{
+		ProviderType: "test",
+		app:          app,
+		ProviderInfo: ProviderInfo{
+			EnvironmentVariables: map[string]string{"SITE_ENV": "live", "API_TOKEN": "abcde", "DB_PASSWORD": "hunter2"},
+			AuthCommand:          ProviderCommand{Command: "touch " + marker},
+			DBPullCommand:        ProviderCommand{Service: "host", Command: `echo "CREATE TABLE t (id int);" > .ddev/.downloads/db.sql`},
+			FilesPullCommand:     ProviderCommand{Service: "host", Command: "echo image > .ddev/.downloads/files/image.txt"},
+			DBSanitize:           []SanitizeStep{{SQL: "DELETE FROM cache"}},
+		},
+	}
+	require.NoError(os.MkdirAll(app.GetConfigPath(""), 0755))
+
+	plan, err := app.GetPullPlan(p, false, false, false)
+	require.NoError(err)
+	assert.NoFileExists(marker)
+	assert.Equal(p.getDownloadDir(), plan.DownloadDir)
+	assert.Equal(map[string]string{"SITE_ENV": "live", "API_TOKEN": maskedSecret, "DB_PASSWORD": maskedSecret}, plan.Environment)
+	var stages []string
+	for _, step := range plan.Steps {
+		stages = append(stages, step.Stage)
+		assert.NotContains(step.Command, "abcde")
+		assert.NotContains(step.Command, "hunter2")
+	}
+	assert.Equal([]string{"auth", "database download", "files download", "database import", "database sanitize", "files import"}, stages)
+	assert.Equal("web", plan.Steps[0].Service)
+	assert.Equal("export API_TOKEN=********  DB_PASSWORD=********  SITE_ENV=live ; touch "+marker, plan.Steps[0].Command)
+	assert.Empty(plan.Databases)
+	assert.NotEmpty(plan.DatabasesNote)
+	assert.Equal([]string{filepath.Join(app.AppRoot, "sites/default/files")}, plan.UploadDirs)
+
+	// Verified downloads of an earlier pull name the exact databases
+	_, err = p.pullStages([]string{"database", "files"})
+	require.NoError(err)
+	plan, err = app.GetPullPlan(p, false, true, false)
+	require.NoError(err)
+	assert.Equal([]string{"db"}, plan.Databases)
+	assert.Contains(plan.Steps[1].Description, "reused")
+	assert.Empty(plan.UploadDirs)
+
+	p.SkipSanitize = true
+	p.DBImportCommand = ProviderCommand{Command: "import-it"}
+	plan, err = app.GetPullPlan(p, false, false, true)
+	require.NoError(err)
+	assert.Empty(plan.Databases)
+	assert.Empty(plan.DatabasesNote)
+	assert.Len(plan.Steps, 3)
+}
+
+// TestProviderEnvironments tests selecting a provider environment and the push protection
+func TestProviderEnvironments(t *testing.T) {
+	assert := asrt.New(t)
+	require := rqr.New(t)
+
+	configPath := filepath.Join(t.TempDir(), "test.yaml")
+	require.NoError(os.WriteFile(configPath, []byte(`
+environment_variables:
+  project_id: site
+  env: dev
+environments:
+  prod:
+    protected: true
+    environment_variables:
+      env: prod
+  stage:
+    environment_variables:
+      env: stage
+      extra: "1"
+`), 0644))
+	p := &Provider{ProviderType: "test"}
+	require.NoError(p.Read(configPath))
+	assert.Equal([]string{"prod", "stage"}, p.GetEnvironmentNames())
+	assert.Equal("the default environment (available environments: prod [protected], stage)", p.GetInfo())
+
+	assert.NoError(p.SelectEnvironment("stage"))
+	assert.Equal(map[string]string{"project_id": "site", "env": "stage", "extra": "1"}, p.EnvironmentVariables)
+	assert.NoError(p.CheckPushAllowed())
+
+	err := p.SelectEnvironment("nosuchenv")
+	assert.ErrorContains(err, "prod, stage")
+
+	assert.NoError(p.SelectEnvironment("prod"))
+	assert.Equal("prod", p.EnvironmentVariables["env"])
+	assert.Contains(p.GetInfo(), "the 'prod' environment")
+	assert.ErrorContains(p.CheckPushAllowed(), "protected")
+	p.AllowProtected = true
+	assert.NoError(p.CheckPushAllowed())
+
+	// Push refuses before running anything
+	p.AllowProtected = false
+	err = (&DdevApp{}).Push(p, false, false)
+	assert.ErrorContains(err, "protected")
+
+	assert.ErrorContains((&Provider{ProviderType: "test"}).SelectEnvironment("prod"), "doesn't define any environments")
+}
@@ -115,8 +115,8 @@ func StartDdevRouter() error {
 	if !needsRecreation {
 		existingPorts, portErr := dockerutil.GetBoundHostPorts(router.ID)
 		existingHostnames, aliasErr := dockerutil.GetRouterNetworkAliases(router.ID)
-		// TraefikMonitorPort is bound by the router but omitted by determineRouterPorts.
-		neededPorts := append(determineRouterPorts(activeApps), globalconfig.DdevGlobalConfig.TraefikMonitorPort)
+		// TraefikMonitorPort is bound by the router but omitted by determineRouterPublishedPorts.
+		neededPorts := append(determineRouterPublishedPorts(activeApps), globalconfig.DdevGlobalConfig.TraefikMonitorPort)
 		switch {
 		case portErr != nil || aliasErr != nil:
 			needsRecreation = true
@@ -229,7 +229,7 @@ func StartDdevRouter() error {
 
 // generateRouterCompose() generates the ~/.ddev/.router-compose.yaml and ~/.ddev/.router-compose-full.yaml
 func generateRouterCompose(activeApps []*DdevApp) (string, error) {
-	exposedPorts := determineRouterPorts(activeApps)
+	exposedPorts := determineRouterPublishedPorts(activeApps)
 
 	routerComposeBasePath := RouterComposeYAMLPath()
 	routerComposeFullPath := FullRenderedRouterComposeYAMLPath()
@@ -510,6 +510,15 @@ func determineRouterPorts(activeApps []*DdevApp) []string {
 	return uniquePorts
 }
 
+// determineRouterPublishedPorts returns the ports the router binds on the host,
+// the ports of its HTTP entrypoints plus the ones of its TCP entrypoints
+func determineRouterPublishedPorts(activeApps []*DdevApp) []string {
+	httpPorts := determineRouterPorts(activeApps)
+	ports := append(slices.Clone(httpPorts), determineRouterTCPPorts(activeApps, httpPorts)...)
+	slices.Sort(ports)
+	return ports
+}
+
 // getConfigBasedRouterPorts collects port mappings from configuration files of all active projects
 func getConfigBasedRouterPorts(activeApps []*DdevApp) []string {
 	var routerPorts []string
@@ -639,7 +648,7 @@ func CheckRouterPorts(activeApps []*DdevApp) error {
 			return err
 		}
 	}
-	newRouterPorts := determineRouterPorts(activeApps)
+	newRouterPorts := determineRouterPublishedPorts(activeApps)
 
 	// Check if any of the new ports are already in use
 	var portError error
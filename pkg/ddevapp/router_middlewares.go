@@ -0,0 +1,224 @@
+package ddevapp
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RouterMiddlewares are Traefik middlewares applied to all the HTTP and HTTPS
+// routers of the project, from router_middlewares in config.yaml.
+// They are applied in the order of the fields.
+type RouterMiddlewares struct {
+	IPAllowList   []string              `yaml:"ip_allow_list,omitempty"`
+	RedirectRegex []RouterRedirectRegex `yaml:"redirect_regex,omitempty"`
+	BasicAuth     *RouterBasicAuth      `yaml:"basic_auth,omitempty"`
+	RateLimit     *RouterRateLimit      `yaml:"rate_limit,omitempty"`
+	Headers       *RouterHeaders        `yaml:"headers,omitempty"`
+}
+
+// RouterBasicAuth protects the project with HTTP basic authentication
+type RouterBasicAuth struct {
+	// Users are "user:password" entries, plain passwords are hashed with bcrypt
+	// and htpasswd hashes ($2y$, $apr1$, {SHA}) are used as they are.
+	// The hash of a plain password is kept as long as the password doesn't change.
+	Users []string `yaml:"users"`
+	Realm string   `yaml:"realm,omitempty"`
+}
+
+// RouterRedirectRegex redirects the URLs matching Regex to Replacement
+type RouterRedirectRegex struct {
+	Regex       string `yaml:"regex"`
+	Replacement string `yaml:"replacement"`
+	Permanent   bool   `yaml:"permanent,omitempty"`
+}
+
+// RouterRateLimit limits the average number of requests per second of each client
+type RouterRateLimit struct {
+	Average int `yaml:"average"`
+	Burst   int `yaml:"burst,omitempty"`
+}
+
+// RouterHeaders adds headers to the requests and the responses, an empty value removes the header
+type RouterHeaders struct {
+	Request  map[string]string `yaml:"request,omitempty"`
+	Response map[string]string `yaml:"response,omitempty"`
+}
+
+// htpasswdHashPrefixes are the prefixes of the htpasswd hashes Traefik supports
+var htpasswdHashPrefixes = []string{"$2y$", "$2a$", "$2b$", "$apr1$", "{SHA}"}
+
+// Validate checks the router_middlewares of the project
+func (m *RouterMiddlewares) Validate() error {
+	if m == nil {
+		return nil
+	}
+	for _, ip := range m.IPAllowList {
+		if net.ParseIP(ip) == nil {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return fmt.Errorf("ip_allow_list: '%s' is not an IP address or range", ip)
+			}
+		}
+	}
+	for _, r := range m.RedirectRegex {
+		if _, err := regexp.Compile(r.Regex); err != nil || r.Regex == "" {
+			return fmt.Errorf("redirect_regex: invalid regex '%s': %v", r.Regex, err)
+		}
+		if r.Replacement == "" {
+			return fmt.Errorf("redirect_regex: no replacement for regex '%s'", r.Regex)
+		}
+	}
+	if m.BasicAuth != nil {
+		if len(m.BasicAuth.Users) == 0 {
+			return fmt.Errorf("basic_auth: no users defined")
+		}
+		for _, u := range m.BasicAuth.Users {
+			if user, password, ok := strings.Cut(u, ":"); !ok || user == "" || password == "" {
+				return fmt.Errorf("basic_auth: users must be 'user:password', got '%s'", u)
+			}
+		}
+	}
+	if m.RateLimit != nil && (m.RateLimit.Average <= 0 || m.RateLimit.Burst < 0) {
+		return fmt.Errorf("rate_limit: average must be positive and burst can't be negative")
+	}
+	if m.Headers != nil {
+		for name := range m.Headers.Request {
+			if name == "" || strings.ContainsAny(name, " :") {
+				return fmt.Errorf("headers: invalid request header name '%s'", name)
+			}
+		}
+		for name := range m.Headers.Response {
+			if name == "" || strings.ContainsAny(name, " :") {
+				return fmt.Errorf("headers: invalid response header name '%s'", name)
+			}
+		}
+	}
+	return nil
+}
+
+// traefikMiddlewares returns the names of the Traefik middlewares of the project
+// in the order they apply, and their definitions as YAML for the http.middlewares
+// section of the project Traefik config. The basic_auth hashes of the existing
+// config in previousConfigFile are reused, so that it only changes with the passwords.
+func (m *RouterMiddlewares) traefikMiddlewares(appName string, previousConfigFile string) ([]string, string, error) {
+	if m == nil {
+		return nil, "", nil
+	}
+	var names []string
+	definitions := map[string]any{}
+	add := func(name string, definition map[string]any) {
+		name = appName + "-" + name
+		names = append(names, name)
+		definitions[name] = definition
+	}
+
+	if len(m.IPAllowList) > 0 {
+		add("ipAllowList", map[string]any{"ipAllowList": map[string]any{"sourceRange": m.IPAllowList}})
+	}
+	for i, r := range m.RedirectRegex {
+		add(fmt.Sprintf("redirectRegex-%d", i+1), map[string]any{"redirectRegex": map[string]any{
+			"regex":       r.Regex,
+			"replacement": r.Replacement,
+			"permanent":   r.Permanent,
+		}})
+	}
+	if m.BasicAuth != nil {
+		previousHashes := previousBasicAuthHashes(previousConfigFile, appName+"-basicAuth")
+		users := make([]string, 0, len(m.BasicAuth.Users))
+		for _, u := range m.BasicAuth.Users {
+			user, password, _ := strings.Cut(u, ":")
+			hashed, err := htpasswdHash(password, previousHashes[user])
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to hash basic_auth password of user %s: %v", user, err)
+			}
+			users = append(users, user+":"+hashed)
+		}
+		basicAuth := map[string]any{"users": users}
+		if m.BasicAuth.Realm != "" {
+			basicAuth["realm"] = m.BasicAuth.Realm
+		}
+		add("basicAuth", map[string]any{"basicAuth": basicAuth})
+	}
+	if m.RateLimit != nil {
+		rateLimit := map[string]any{"average": m.RateLimit.Average}
+		if m.RateLimit.Burst > 0 {
+			rateLimit["burst"] = m.RateLimit.Burst
+		}
+		add("rateLimit", map[string]any{"rateLimit": rateLimit})
+	}
+	if m.Headers != nil && (len(m.Headers.Request) > 0 || len(m.Headers.Response) > 0) {
+		headers := map[string]any{}
+		if len(m.Headers.Request) > 0 {
+			headers["customRequestHeaders"] = m.Headers.Request
+		}
+		if len(m.Headers.Response) > 0 {
+			headers["customResponseHeaders"] = m.Headers.Response
+		}
+		add("headers", map[string]any{"headers": headers})
+	}
+
+	if len(names) == 0 {
+		return nil, "", nil
+	}
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(definitions); err != nil {
+		return nil, "", err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, "", err
+	}
+	return names, out.String(), nil
+}
+
+// previousBasicAuthHashes returns the hashed passwords by user of the basicAuth
+// middleware in an existing Traefik config file
+func previousBasicAuthHashes(configFile string, middlewareName string) map[string]string {
+	hashes := map[string]string{}
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return hashes
+	}
+	var config struct {
+		HTTP struct {
+			Middlewares map[string]struct {
+				BasicAuth struct {
+					Users []string `yaml:"users"`
+				} `yaml:"basicAuth"`
+			} `yaml:"middlewares"`
+		} `yaml:"http"`
+	}
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return hashes
+	}
+	for _, u := range config.HTTP.Middlewares[middlewareName].BasicAuth.Users {
+		if user, hashed, ok := strings.Cut(u, ":"); ok {
+			hashes[user] = hashed
+		}
+	}
+	return hashes
+}
+
+// htpasswdHash returns password hashed with bcrypt, unless it's already an htpasswd hash.
+// As bcrypt hashes are salted, the previous hash is returned when it matches password.
+func htpasswdHash(password string, previous string) (string, error) {
+	for _, prefix := range htpasswdHashPrefixes {
+		if strings.HasPrefix(password, prefix) {
+			return password, nil
+		}
+	}
+	if previous != "" && bcrypt.CompareHashAndPassword([]byte(previous), []byte(password)) == nil {
+		return previous, nil
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
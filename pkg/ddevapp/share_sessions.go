@@ -0,0 +1,138 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/util"
+	"go.yaml.in/yaml/v4"
+)
+
+// ShareSession is a share of the project running in the background,
+// started with `ddev share --detach`
+type ShareSession struct {
+	Provider string    `yaml:"provider"`
+	PID      int       `yaml:"pid"`
+	URL      string    `yaml:"url"`
+	Started  time.Time `yaml:"started"`
+	// ProcessStart is the start time of the process PID as the system reports it,
+	// so that a process reusing the PID after a reboot isn't taken for the provider
+	ProcessStart string `yaml:"process_start"`
+}
+
+// GetShareSessionPath returns the path of a file of the share session of a provider
+// in .ddev/.share-sessions, the state with ".yaml", the provider output with ".out",
+// and its log with ".log"
+func (app *DdevApp) GetShareSessionPath(provider string, ext string) string {
+	return app.GetConfigPath(filepath.Join(".share-sessions", provider+ext))
+}
+
+// SaveShareSession records a share session of the project, with the start time of its process
+func (app *DdevApp) SaveShareSession(s ShareSession) error {
+	var err error
+	if s.ProcessStart, err = shareProcessStartTime(s.PID); err != nil {
+		return fmt.Errorf("unable to get the start time of share process %d: %v", s.PID, err)
+	}
+	statePath := app.GetShareSessionPath(s.Provider, ".yaml")
+	if err = os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statePath, data, 0644)
+}
+
+// GetShareSessions returns the active share sessions of the project, sorted by provider
+func (app *DdevApp) GetShareSessions() ([]ShareSession, error) {
+	sessions, _, err := app.readShareSessions()
+	return sessions, err
+}
+
+// readShareSessions returns the active share sessions of the project, sorted by provider,
+// and the providers of the stale sessions, which are invalid or whose provider process is gone
+func (app *DdevApp) readShareSessions() ([]ShareSession, []string, error) {
+	sessionsDir := app.GetConfigPath(".share-sessions")
+	if !fileutil.IsDirectory(sessionsDir) {
+		return nil, nil, nil
+	}
+	statePaths, err := filepath.Glob(filepath.Join(sessionsDir, "*.yaml"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sessions []ShareSession
+	var stale []string
+	for _, statePath := range statePaths {
+		data, err := os.ReadFile(statePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		var s ShareSession
+		if err = yaml.Unmarshal(data, &s); err != nil || s.PID <= 0 {
+			util.Debug("Ignoring invalid share session %s: %v", statePath, err)
+			stale = append(stale, strings.TrimSuffix(filepath.Base(statePath), ".yaml"))
+			continue
+		}
+		if !s.isRunning() {
+			util.Debug("Share session %s of %s is no longer running", s.Provider, app.Name)
+			stale = append(stale, s.Provider)
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Provider < sessions[j].Provider
+	})
+	return sessions, stale, nil
+}
+
+// isRunning reports whether the process of a share session is still the one
+// that was started for it, and not another process that got its PID
+func (s ShareSession) isRunning() bool {
+	if !shareProcessIsRunning(s.PID) {
+		return false
+	}
+	processStart, err := shareProcessStartTime(s.PID)
+	return err == nil && s.ProcessStart != "" && processStart == s.ProcessStart
+}
+
+// StopShareSessions stops the share sessions of the project, of all providers
+// when provider is empty, and returns the stopped sessions.
+// The files of stale sessions are removed.
+func (app *DdevApp) StopShareSessions(provider string) ([]ShareSession, error) {
+	sessions, stale, err := app.readShareSessions()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stale {
+		if provider == "" || p == provider {
+			app.removeShareSessionFiles(p)
+		}
+	}
+	var stopped []ShareSession
+	for _, s := range sessions {
+		if provider != "" && s.Provider != provider {
+			continue
+		}
+		if err = killShareProcessTree(s.PID); err != nil {
+			return stopped, fmt.Errorf("failed to stop share session %s (pid %d): %v", s.Provider, s.PID, err)
+		}
+		app.removeShareSessionFiles(s.Provider)
+		stopped = append(stopped, s)
+	}
+	return stopped, nil
+}
+
+// removeShareSessionFiles removes the state, output and log of a share session
+func (app *DdevApp) removeShareSessionFiles(provider string) {
+	for _, ext := range []string{".yaml", ".out", ".log"} {
+		_ = os.Remove(app.GetShareSessionPath(provider, ext))
+	}
+}
@@ -0,0 +1,100 @@
+//go:build !windows
+
+package ddevapp_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ddev/ddev/pkg/ddevapp"
+	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShareSessions checks share sessions are recorded, listed while their process
+// runs, and that stopping them kills the process and removes them, but never
+// a process that only has the same PID
+func TestShareSessions(t *testing.T) {
+	assert := asrt.New(t)
+	app := &ddevapp.DdevApp{Name: "sharesessions", AppRoot: t.TempDir()}
+	require.NoError(t, os.MkdirAll(app.GetConfigPath(""), 0755))
+
+	sessions, err := app.GetShareSessions()
+	require.NoError(t, err)
+	assert.Empty(sessions)
+
+	// Stand-in for a detached share provider
+	provider := exec.Command("sleep", "60")
+	provider.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	require.NoError(t, provider.Start())
+	exited := make(chan struct{})
+	go func() {
+		_ = provider.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		_ = provider.Process.Kill()
+	})
+
+	// Stand-in for an unrelated process that got the PID of a share session after a reboot
+	unrelated := exec.Command("sleep", "60")
+	unrelated.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	require.NoError(t, unrelated.Start())
+	unrelatedExited := make(chan struct{})
+	go func() {
+		_ = unrelated.Wait()
+		close(unrelatedExited)
+	}()
+	t.Cleanup(func() {
+		_ = unrelated.Process.Kill()
+	})
+
+	require.NoError(t, app.SaveShareSession(ddevapp.ShareSession{Provider: "ngrok", PID: provider.Process.Pid, URL: "https://example.ngrok.app", Started: time.Now()}))
+	// A session whose process is gone isn't listed
+	require.Error(t, app.SaveShareSession(ddevapp.ShareSession{Provider: "cloudflared", PID: 999999999, URL: "https://example.trycloudflare.com", Started: time.Now()}))
+	require.NoError(t, os.WriteFile(app.GetShareSessionPath("cloudflared", ".yaml"), []byte("provider: cloudflared\npid: 999999999\nprocess_start: \"1\"\n"), 0644))
+	// Neither is a session whose PID belongs to another process now
+	require.NoError(t, os.WriteFile(app.GetShareSessionPath("localtunnel", ".yaml"), []byte(fmt.Sprintf("provider: localtunnel\npid: %d\nprocess_start: \"1\"\n", unrelated.Process.Pid)), 0644))
+
+	sessions, err = app.GetShareSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal("ngrok", sessions[0].Provider)
+	assert.Equal("https://example.ngrok.app", sessions[0].URL)
+	// Listing doesn't change anything
+	assert.FileExists(app.GetShareSessionPath("cloudflared", ".yaml"))
+	assert.FileExists(app.GetShareSessionPath("localtunnel", ".yaml"))
+
+	// Stopping removes the stale sessions without killing anything
+	stopped, err := app.StopShareSessions("cloudflared")
+	require.NoError(t, err)
+	assert.Empty(stopped)
+	assert.NoFileExists(app.GetShareSessionPath("cloudflared", ".yaml"))
+	stopped, err = app.StopShareSessions("localtunnel")
+	require.NoError(t, err)
+	assert.Empty(stopped)
+	assert.NoFileExists(app.GetShareSessionPath("localtunnel", ".yaml"))
+	select {
+	case <-unrelatedExited:
+		t.Fatal("an unrelated process was stopped")
+	case <-time.After(time.Second):
+	}
+
+	stopped, err = app.StopShareSessions("")
+	require.NoError(t, err)
+	require.Len(t, stopped, 1)
+	select {
+	case <-exited:
+	case <-time.After(10 * time.Second):
+		t.Fatal("share process was not stopped")
+	}
+	assert.NoFileExists(app.GetShareSessionPath("ngrok", ".yaml"))
+
+	sessions, err = app.GetShareSessions()
+	require.NoError(t, err)
+	assert.Empty(sessions)
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+
+package ddevapp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// shareProcessIsRunning reports whether the process pid of a share session exists
+func shareProcessIsRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// shareProcessStartTime returns the start time of process pid as the system reports it,
+// which tells it apart from a later process that reuses the pid
+func shareProcessStartTime(pid int) (string, error) {
+	if runtime.GOOS == "linux" {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return "", err
+		}
+		// The command name in parentheses may contain spaces, starttime is
+		// the 22nd field and the 20th after the command name
+		i := strings.LastIndex(string(stat), ") ")
+		if i < 0 {
+			return "", fmt.Errorf("unable to parse /proc/%d/stat", pid)
+		}
+		f := strings.Fields(string(stat)[i+2:])
+		if len(f) < 20 {
+			return "", fmt.Errorf("unable to parse /proc/%d/stat", pid)
+		}
+		return f[19], nil
+	}
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// killShareProcessTree stops the process group of a share session, which is led by pid
+// because the provider runs in its own session. It gets SIGTERM first so that
+// the provider can close its tunnel, then SIGKILL.
+func killShareProcessTree(pid int) error {
+	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	for range 50 {
+		if !shareProcessIsRunning(pid) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+//go:build windows
+
+package ddevapp
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+
+	"golang.org/x/sys/windows"
+)
+
+// shareProcessIsRunning reports whether the process pid of a share session exists
+func shareProcessIsRunning(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err = windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	// STILL_ACTIVE
+	return code == 259
+}
+
+// shareProcessStartTime returns the creation time of process pid, which tells it
+// apart from a later process that reuses the pid
+func shareProcessStartTime(pid int) (string, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(h)
+	var creation, exit, kernel, user windows.Filetime
+	if err = windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(creation.Nanoseconds(), 10), nil
+}
+
+// killShareProcessTree uses taskkill to terminate the process pid of a share session
+// and all of its descendants, falling back to killing the process alone.
+func killShareProcessTree(pid int) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run(); err != nil {
+		p, err := os.FindProcess(pid)
+		if err != nil {
+			return nil
+		}
+		return p.Kill()
+	}
+	return nil
+}
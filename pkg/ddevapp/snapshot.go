@@ -7,17 +7,22 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	configTypes "github.com/ddev/ddev/pkg/config/types"
+	ddevImages "github.com/ddev/ddev/pkg/docker"
 	"github.com/ddev/ddev/pkg/dockerutil"
+	"github.com/ddev/ddev/pkg/exec"
 	"github.com/ddev/ddev/pkg/fileutil"
 	"github.com/ddev/ddev/pkg/globalconfig"
 	"github.com/ddev/ddev/pkg/nodeps"
 	"github.com/ddev/ddev/pkg/output"
 	"github.com/ddev/ddev/pkg/util"
+	"go.yaml.in/yaml/v4"
 )
 
 type Snapshot struct {
@@ -25,8 +30,57 @@ type Snapshot struct {
 	Created   time.Time
 	Size      int64
 	DBVersion string
+	// Base is the snapshot an incremental snapshot is chained to, empty for full snapshots
+	Base string
+	// The following are only known for snapshots with a metadata sidecar
+	Description string
+	DdevVersion string
+	GitCommit   string
+	GitBranch   string
+	Compression string
+	Databases   []SnapshotDatabase
 }
 
+// SnapshotDatabase describes a database contained in a snapshot
+type SnapshotDatabase struct {
+	Name   string `yaml:"name"`
+	Tables int    `yaml:"tables"`
+}
+
+// SnapshotOpts are the options for creating a snapshot with CreateSnapshot
+type SnapshotOpts struct {
+	// Name of the snapshot, a timestamped name is used if empty
+	Name string
+	// Incremental creates a mariabackup/xtrabackup incremental snapshot
+	// chained to Base, or to the latest snapshot if Base is empty
+	Incremental bool
+	// Base is the snapshot an incremental snapshot is chained to
+	Base string
+	// Description is a user-supplied message stored with the snapshot
+	Description string
+}
+
+// SnapshotMetadata is stored in a <snapshot file>.yaml sidecar next to each snapshot
+type SnapshotMetadata struct {
+	Name        string             `yaml:"name"`
+	Created     time.Time          `yaml:"created"`
+	Description string             `yaml:"description,omitempty"`
+	DBVersion   string             `yaml:"db_version"`
+	DdevVersion string             `yaml:"ddev_version,omitempty"`
+	GitCommit   string             `yaml:"git_commit,omitempty"`
+	GitBranch   string             `yaml:"git_branch,omitempty"`
+	Compression string             `yaml:"compression,omitempty"`
+	Databases   []SnapshotDatabase `yaml:"databases,omitempty"`
+	// Base is the snapshot this incremental snapshot was chained to
+	Base string `yaml:"base,omitempty"`
+	// FromLSN and ToLSN are the InnoDB log sequence numbers covered by the backup
+	FromLSN string `yaml:"from_lsn,omitempty"`
+	ToLSN   string `yaml:"to_lsn,omitempty"`
+}
+
+// snapshotMetadataSuffix is appended to the snapshot filename to get the metadata sidecar
+const snapshotMetadataSuffix = ".yaml"
+
 // SnapshotRestoreDefaultWaitTime is the max time we'll wait for snapshot restore.
 // If default_container_timeout is set higher than that it can be more
 const SnapshotRestoreDefaultWaitTime = 600
@@ -55,9 +109,21 @@ func (app *DdevApp) DeleteSnapshot(snapshotName string) error {
 	if !fileutil.FileExists(hostSnapshot) {
 		return fmt.Errorf("no snapshot '%s' currently exists in project '%s'", snapshotName, app.Name)
 	}
+
+	dependents, err := app.GetSnapshotDependents(snapshotName)
+	if err != nil {
+		return err
+	}
+	if len(dependents) > 0 {
+		return fmt.Errorf("snapshot '%s' can't be deleted because the incremental snapshot(s) '%s' depend on it, please delete them first", snapshotName, strings.Join(dependents, "', '"))
+	}
+
 	if err = os.RemoveAll(hostSnapshot); err != nil {
 		return fmt.Errorf("failed to remove snapshot '%s': %v", hostSnapshot, err)
 	}
+	if err = os.RemoveAll(hostSnapshot + snapshotMetadataSuffix); err != nil {
+		return fmt.Errorf("failed to remove snapshot metadata '%s': %v", hostSnapshot+snapshotMetadataSuffix, err)
+	}
 
 	util.Success("Deleted database snapshot '%s'", snapshotName)
 	err = app.ProcessHooks("post-delete-snapshot")
@@ -159,6 +225,25 @@ func (app *DdevApp) ListSnapshots() ([]Snapshot, error) {
 				Size:      size,
 				DBVersion: dbVersion,
 			}
+			if !f.IsDir() {
+				snapshot.Compression = snapshotCompressionFromFile(f.Name())
+				meta, err := readSnapshotMetadata(filepath.Join(snapshotDir, f.Name()))
+				if err != nil {
+					return snapshots, err
+				}
+				if meta != nil {
+					// The file modification time changes when snapshots are copied around
+					if !meta.Created.IsZero() {
+						snapshot.Created = meta.Created
+					}
+					snapshot.Base = meta.Base
+					snapshot.Description = meta.Description
+					snapshot.DdevVersion = meta.DdevVersion
+					snapshot.GitCommit = meta.GitCommit
+					snapshot.GitBranch = meta.GitBranch
+					snapshot.Databases = meta.Databases
+				}
+			}
 			snapshots = append(snapshots, snapshot)
 		}
 	}
@@ -181,6 +266,16 @@ func (app *DdevApp) RestoreSnapshot(snapshotName string) error {
 	if err != nil {
 		return fmt.Errorf("no snapshot found for name %s: %v", snapshotName, err)
 	}
+	// An incremental snapshot has to be restored together with all the snapshots it's chained to
+	snapshotChain, err := app.GetSnapshotChain(snapshotName)
+	if err != nil {
+		return err
+	}
+	if len(snapshotChain) > 1 {
+		if err = checkIncrementalSnapshotSupport(app.GetDBImage()); err != nil {
+			return fmt.Errorf("unable to restore incremental snapshot '%s': %v", snapshotName, err)
+		}
+	}
 	snapshotFileOrDir := filepath.Join("db_snapshots", snapshotFile)
 
 	hostSnapshotFileOrDir := app.GetConfigPath(snapshotFileOrDir)
@@ -222,7 +317,7 @@ func (app *DdevApp) RestoreSnapshot(snapshotName string) error {
 	}
 
 	if snapshotDBVersion != currentDBVersion {
-		return fmt.Errorf("snapshot '%s' is a DB server '%s' snapshot and is not compatible with the configured DDEV DB server version (%s).  Please restore it using the DB version it was created with, and then you can try upgrading the DDEV DB version", snapshotName, snapshotDBVersion, currentDBVersion)
+		return fmt.Errorf("snapshot '%s' is a DB server '%s' snapshot and is not compatible with the configured DDEV DB server version (%s).  Please restore it using the DB version it was created with, or use `ddev snapshot restore --migrate %s` to convert it to the current DB version", snapshotName, snapshotDBVersion, currentDBVersion, snapshotName)
 	}
 
 	status, _ := app.SiteStatus()
@@ -246,8 +341,6 @@ func (app *DdevApp) RestoreSnapshot(snapshotName string) error {
 	// If we have no bind mounts, we need to copy our snapshot into the snapshots volme
 	// With bind mounts, they'll already be there in the /mnt/ddev_config/db_snapshots folder
 	if globalconfig.DdevGlobalConfig.NoBindMounts {
-		uid, _, _ := dockerutil.GetContainerUser()
-
 		// If the snapshot is an old-style directory-based snapshot, then we have to copy into a subdirectory
 		// named for the snapshot
 		subdir := ""
@@ -255,13 +348,15 @@ func (app *DdevApp) RestoreSnapshot(snapshotName string) error {
 			subdir = snapshotName
 		}
 
-		err = dockerutil.CopyIntoVolume(filepath.Join(app.GetConfigPath("db_snapshots"), snapshotFile), "ddev-"+app.Name+"-snapshots", subdir, uid, "", true)
-		if err != nil {
+		if err = app.copySnapshotChainIntoVolume(snapshotChain, subdir); err != nil {
 			return err
 		}
 	}
 
-	restoreCmd := RestoreSnapshotCommand + " " + snapshotFile
+	if len(snapshotChain) > 1 {
+		util.Success("Restoring incremental snapshot '%s' on top of %s", snapshotName, strings.Join(snapshotChain[:len(snapshotChain)-1], ", "))
+	}
+	restoreCmd := RestoreSnapshotCommand + " " + strings.Join(snapshotChain, " ")
 	// Determine compression type for potential conditional restore handling
 	isGzip := strings.HasSuffix(snapshotFile, ".gz")
 	isZstd := strings.HasSuffix(snapshotFile, ".zst")
@@ -369,3 +464,337 @@ func GetSnapshotFileFromName(name string, app *DdevApp) (string, error) {
 
 	return "", fmt.Errorf("snapshot %s not found in %s", name, snapshotsDir)
 }
+
+// GetSnapshotMetadata returns the metadata stored next to a snapshot, or nil if
+// the snapshot has no metadata sidecar (older or directory-based snapshots)
+func (app *DdevApp) GetSnapshotMetadata(snapshotName string) (*SnapshotMetadata, error) {
+	snapshotFile, err := GetSnapshotFileFromName(snapshotName, app)
+	if err != nil {
+		return nil, err
+	}
+	return readSnapshotMetadata(filepath.Join(app.GetConfigPath("db_snapshots"), snapshotFile))
+}
+
+// readSnapshotMetadata reads the metadata sidecar of the snapshot at hostSnapshotPath
+func readSnapshotMetadata(hostSnapshotPath string) (*SnapshotMetadata, error) {
+	metadataFile := hostSnapshotPath + snapshotMetadataSuffix
+	if !fileutil.FileExists(metadataFile) {
+		return nil, nil
+	}
+	content, err := os.ReadFile(metadataFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot metadata %s: %v", metadataFile, err)
+	}
+	meta := &SnapshotMetadata{}
+	if err = yaml.Unmarshal(content, meta); err != nil {
+		return nil, fmt.Errorf("unable to parse snapshot metadata %s: %v", metadataFile, err)
+	}
+	return meta, nil
+}
+
+// writeSnapshotMetadata writes the metadata sidecar of the snapshot at hostSnapshotPath
+func writeSnapshotMetadata(hostSnapshotPath string, meta *SnapshotMetadata) error {
+	content, err := yaml.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("error marshaling snapshot metadata: %v", err)
+	}
+	return os.WriteFile(hostSnapshotPath+snapshotMetadataSuffix, content, 0644)
+}
+
+// GetSnapshotChain returns the snapshot files needed to restore snapshotName,
+// starting with the full snapshot and ending with snapshotName itself.
+// For a full snapshot this is just the snapshot's own file.
+func (app *DdevApp) GetSnapshotChain(snapshotName string) ([]string, error) {
+	var chain []string
+	seen := map[string]bool{}
+	for name := snapshotName; name != ""; {
+		if seen[name] {
+			return nil, fmt.Errorf("snapshot '%s' has a circular incremental chain at '%s'", snapshotName, name)
+		}
+		seen[name] = true
+
+		snapshotFile, err := GetSnapshotFileFromName(name, app)
+		if err != nil {
+			if name != snapshotName {
+				return nil, fmt.Errorf("snapshot '%s' depends on base snapshot '%s', which is missing: %v", snapshotName, name, err)
+			}
+			return nil, err
+		}
+		chain = append([]string{snapshotFile}, chain...)
+
+		meta, err := readSnapshotMetadata(filepath.Join(app.GetConfigPath("db_snapshots"), snapshotFile))
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil {
+			break
+		}
+		name = meta.Base
+	}
+	return chain, nil
+}
+
+// GetSnapshotDependents returns the names of the incremental snapshots directly chained to snapshotName
+func (app *DdevApp) GetSnapshotDependents(snapshotName string) ([]string, error) {
+	var dependents []string
+	snapshots, err := app.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Base == snapshotName {
+			dependents = append(dependents, snapshot.Name)
+		}
+	}
+	return dependents, nil
+}
+
+// getIncrementalBase returns the metadata of the snapshot an incremental snapshot
+// is chained to, which is baseName or the latest snapshot if baseName is empty
+func (app *DdevApp) getIncrementalBase(baseName string) (*SnapshotMetadata, error) {
+	if app.Database.Type == nodeps.Postgres {
+		return nil, fmt.Errorf("incremental snapshots are only supported with MariaDB and MySQL, not %s", app.Database.Type)
+	}
+	if baseName == "" {
+		latest, err := app.GetLatestSnapshot()
+		if err != nil {
+			return nil, fmt.Errorf("an incremental snapshot needs a base snapshot, please create a full snapshot first: %v", err)
+		}
+		baseName = latest
+	}
+	meta, err := app.GetSnapshotMetadata(baseName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use snapshot '%s' as incremental base: %v", baseName, err)
+	}
+	if meta == nil || meta.ToLSN == "" {
+		return nil, fmt.Errorf("snapshot '%s' has no checkpoint information and can't be used as incremental base, please create a new full snapshot with 'ddev snapshot'", baseName)
+	}
+	currentDBVersion := app.Database.Type + "_" + app.Database.Version
+	if meta.DBVersion != currentDBVersion {
+		return nil, fmt.Errorf("snapshot '%s' is a DB server '%s' snapshot and can't be used as incremental base for the configured DDEV DB server version (%s)", baseName, meta.DBVersion, currentDBVersion)
+	}
+	return meta, nil
+}
+
+// copySnapshotChainIntoVolume copies the files of a snapshot chain into the snapshots
+// volume of the project, for no_bind_mounts. Only the first copy clears the volume,
+// so that the base snapshot and the earlier incrementals are kept for the restore.
+func (app *DdevApp) copySnapshotChainIntoVolume(snapshotChain []string, subdir string) error {
+	uid, _, _ := dockerutil.GetContainerUser()
+	for i, chainFile := range snapshotChain {
+		err := dockerutil.CopyIntoVolume(filepath.Join(app.GetConfigPath("db_snapshots"), chainFile), "ddev-"+app.Name+"-snapshots", subdir, uid, "", i == 0)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIncrementalSnapshotSupport returns an error when the entrypoint of dbImage
+// can't restore a chain of incremental snapshots. Older ddev-dbserver images only
+// take one snapshot and would start on the current data instead of restoring.
+func checkIncrementalSnapshotSupport(dbImage string) error {
+	if err := dockerutil.Pull(dbImage); err != nil {
+		return fmt.Errorf("unable to pull %s: %v", dbImage, err)
+	}
+	supported, err := dockerutil.ImageLabel(dbImage, ddevImages.DdevIncrementalSnapshotsLabel)
+	if err != nil {
+		return fmt.Errorf("unable to inspect %s: %v", dbImage, err)
+	}
+	if supported != "true" {
+		return fmt.Errorf("the database image %s can't restore incremental snapshots, restore a full snapshot instead or use a ddev-dbserver image with the %s label", dbImage, ddevImages.DdevIncrementalSnapshotsLabel)
+	}
+	return nil
+}
+
+// parseBackupCheckpoints extracts the to_lsn value from the contents of a
+// mariabackup/xtrabackup xtrabackup_checkpoints file
+func parseBackupCheckpoints(checkpoints string) string {
+	m := regexp.MustCompile(`(?m)^\s*to_lsn\s*=\s*([0-9]+)\s*$`)
+	if matches := m.FindStringSubmatch(checkpoints); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// snapshotCompressionFromFile returns the compression used by a snapshot file, based on its extension
+func snapshotCompressionFromFile(snapshotFile string) string {
+	switch {
+	case strings.HasSuffix(snapshotFile, ".zst"):
+		return "zstd"
+	case strings.HasSuffix(snapshotFile, ".gz"):
+		return "gzip"
+	}
+	return ""
+}
+
+// getSnapshotGitInfo returns the current git commit and branch of the project,
+// or empty strings if the project isn't a git checkout
+func getSnapshotGitInfo(appRoot string) (commit string, branch string) {
+	out, err := exec.RunHostCommandSeparateStreams("git", "-C", appRoot, "rev-parse", "HEAD")
+	if err != nil {
+		return "", ""
+	}
+	commit = strings.TrimSpace(out)
+	out, err = exec.RunHostCommandSeparateStreams("git", "-C", appRoot, "rev-parse", "--abbrev-ref", "HEAD")
+	if err == nil {
+		branch = strings.TrimSpace(out)
+	}
+	return commit, branch
+}
+
+// getSnapshotDatabases returns the databases in the db container with their table counts
+func (app *DdevApp) getSnapshotDatabases() ([]SnapshotDatabase, error) {
+	stdout, stderr, err := app.Exec(&ExecOpts{
+		Service: "db",
+		Cmd:     app.getSnapshotDatabasesCommand(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list databases: %v, stderr=%s", err, stderr)
+	}
+	return parseSnapshotDatabases(stdout), nil
+}
+
+// getSnapshotDatabasesCommand returns the command that lists the databases and their
+// table counts in the db container, as parsed by parseSnapshotDatabases
+func (app *DdevApp) getSnapshotDatabasesCommand() string {
+	c := fmt.Sprintf(`%s -uroot -proot -N -B -e "SELECT s.schema_name, COUNT(t.table_name) FROM information_schema.schemata s LEFT JOIN information_schema.tables t ON t.table_schema = s.schema_name WHERE s.schema_name NOT IN ('information_schema', 'performance_schema', 'mysql', 'sys') GROUP BY s.schema_name ORDER BY s.schema_name"`, app.GetDBClientCommand())
+	if app.Database.Type == nodeps.Postgres {
+		c = `for db in $(psql -q -d postgres -At -c "SELECT datname FROM pg_database WHERE NOT datistemplate AND datname <> 'postgres' ORDER BY datname"); do echo -e "${db}\t$(psql -q -d "${db}" -At -c "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema NOT IN ('pg_catalog', 'information_schema')")"; done`
+	}
+	return c
+}
+
+// parseSnapshotDatabases parses tab-separated "database<TAB>table count" lines
+func parseSnapshotDatabases(out string) []SnapshotDatabase {
+	var databases []SnapshotDatabase
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 2 {
+			continue
+		}
+		tables, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			continue
+		}
+		databases = append(databases, SnapshotDatabase{Name: strings.TrimSpace(fields[0]), Tables: tables})
+	}
+	return databases
+}
+
+// GetSnapshotRetention returns the project's snapshot_retention rules,
+// falling back to the global snapshot_retention if the project has none
+func (app *DdevApp) GetSnapshotRetention() configTypes.SnapshotRetention {
+	if !app.SnapshotRetention.IsEmpty() {
+		return app.SnapshotRetention
+	}
+	return globalconfig.DdevGlobalConfig.SnapshotRetention
+}
+
+// PruneSnapshots deletes the snapshots that aren't kept by the snapshot_retention rules
+// Returns the pruned snapshots and the number of bytes reclaimed.
+// With dryRun nothing is deleted, but the result is the same.
+func (app *DdevApp) PruneSnapshots(dryRun bool) ([]Snapshot, int64, error) {
+	var pruned []Snapshot
+	var reclaimed int64
+
+	retention := app.GetSnapshotRetention()
+	if retention.IsEmpty() {
+		return pruned, reclaimed, nil
+	}
+	snapshots, err := app.ListSnapshots()
+	if err != nil {
+		return pruned, reclaimed, err
+	}
+	toPrune, err := planSnapshotPrune(snapshots, retention, time.Now())
+	if err != nil {
+		return pruned, reclaimed, err
+	}
+	for _, snapshot := range toPrune {
+		if !dryRun {
+			if err = app.DeleteSnapshot(snapshot.Name); err != nil {
+				return pruned, reclaimed, err
+			}
+		}
+		pruned = append(pruned, snapshot)
+		reclaimed += snapshot.Size
+	}
+	return pruned, reclaimed, nil
+}
+
+// planSnapshotPrune returns the snapshots that aren't kept by the retention rules,
+// newest first, so incremental snapshots are deleted before the snapshots they are based on.
+// Snapshots that a kept incremental snapshot is based on are always kept as well.
+func planSnapshotPrune(snapshots []Snapshot, retention configTypes.SnapshotRetention, now time.Time) ([]Snapshot, error) {
+	maxSize, err := retention.GetMaxSizeBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := slices.Clone(snapshots)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Created.After(sorted[j].Created)
+	})
+	byName := make(map[string]Snapshot)
+	for _, snapshot := range sorted {
+		byName[snapshot.Name] = snapshot
+	}
+
+	// Find the snapshots kept by keep_last and keep_daily,
+	// if neither is set, only max_size applies.
+	candidates := make(map[string]bool)
+	for i, snapshot := range sorted {
+		if (retention.KeepLast == 0 && retention.KeepDaily == 0) || i < retention.KeepLast {
+			candidates[snapshot.Name] = true
+		}
+	}
+	if retention.KeepDaily > 0 {
+		year, month, day := now.Date()
+		oldestDay := time.Date(year, month, day, 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(retention.KeepDaily - 1))
+		days := make(map[string]bool)
+		for _, snapshot := range sorted {
+			created := snapshot.Created.In(now.Location())
+			if created.Before(oldestDay) {
+				continue
+			}
+			if d := created.Format("2006-01-02"); !days[d] {
+				days[d] = true
+				candidates[snapshot.Name] = true
+			}
+		}
+	}
+
+	// Keep the candidates newest first together with the snapshots they are
+	// based on, as long as they fit into max_size. The newest one is always kept.
+	kept := make(map[string]bool)
+	var keptSize int64
+	for _, snapshot := range sorted {
+		if !candidates[snapshot.Name] || kept[snapshot.Name] {
+			continue
+		}
+		var chain []string
+		var chainSize int64
+		for name := snapshot.Name; name != "" && !kept[name] && !slices.Contains(chain, name); name = byName[name].Base {
+			if _, ok := byName[name]; !ok {
+				break
+			}
+			chain = append(chain, name)
+			chainSize += byName[name].Size
+		}
+		if maxSize > 0 && len(kept) > 0 && keptSize+chainSize > maxSize {
+			continue
+		}
+		for _, name := range chain {
+			kept[name] = true
+		}
+		keptSize += chainSize
+	}
+
+	var toPrune []Snapshot
+	for _, snapshot := range sorted {
+		if !kept[snapshot.Name] {
+			toPrune = append(toPrune, snapshot)
+		}
+	}
+	return toPrune, nil
+}
@@ -0,0 +1,354 @@
+package ddevapp
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ddev/ddev/pkg/archive"
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/ddev/ddev/pkg/versionconstants"
+	"github.com/otiai10/copy"
+	"go.yaml.in/yaml/v4"
+)
+
+// SnapshotArchiveManifestName is the name of the manifest inside a snapshot archive
+const SnapshotArchiveManifestName = "ddev-snapshot.yaml"
+
+// SnapshotArchiveManifest describes the contents of an exported snapshot archive
+type SnapshotArchiveManifest struct {
+	Snapshot    string    `yaml:"snapshot"`
+	Project     string    `yaml:"project"`
+	DBVersion   string    `yaml:"db_version"`
+	DdevVersion string    `yaml:"ddev_version"`
+	Exported    time.Time `yaml:"exported"`
+	// SnapshotFiles are the snapshot files in the archive, base snapshot first
+	SnapshotFiles []string `yaml:"snapshot_files"`
+	// UploadDirs are the exported upload_dirs, the files of UploadDirs[i] are in files/<i>/
+	UploadDirs []string `yaml:"upload_dirs,omitempty"`
+	// Checksums has the SHA-256 checksum of every other file in the archive
+	Checksums map[string]string `yaml:"checksums"`
+}
+
+// ExportSnapshot writes the snapshot, the snapshots it's based on, and optionally the
+// contents of the upload_dirs into a single archive at archivePath.
+// The archive is gzipped if archivePath ends with .gz or .tgz
+func (app *DdevApp) ExportSnapshot(snapshotName string, archivePath string, includeFiles bool) error {
+	// Untar reads these by their name, they can't be written
+	if strings.HasSuffix(archivePath, "xz") || strings.HasSuffix(archivePath, "bz2") {
+		return fmt.Errorf("unable to write snapshot archive %s: only .tar, .tar.gz and .tgz archives are supported", archivePath)
+	}
+	chain, err := app.GetSnapshotChain(snapshotName)
+	if err != nil {
+		return err
+	}
+	snapshotDir := app.GetConfigPath("db_snapshots")
+	dbVersion := ""
+	for _, snapshotFile := range chain {
+		if fileutil.IsDirectory(filepath.Join(snapshotDir, snapshotFile)) {
+			return fmt.Errorf("snapshot '%s' is an obsolete directory-based snapshot and can't be exported, please restore it and create a new snapshot", snapshotFile)
+		}
+		dbVersion = getSnapshotFileDBVersion(snapshotFile)
+	}
+
+	manifest := SnapshotArchiveManifest{
+		Snapshot:      snapshotName,
+		Project:       app.Name,
+		DBVersion:     dbVersion,
+		DdevVersion:   versionconstants.DdevVersion,
+		Exported:      time.Now(),
+		SnapshotFiles: chain,
+		Checksums:     map[string]string{},
+	}
+
+	// The archive is written next to archivePath and only replaces it when it's complete
+	f, err := os.CreateTemp(filepath.Dir(archivePath), "."+filepath.Base(archivePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("unable to create snapshot archive %s: %v", archivePath, err)
+	}
+	tmpPath := f.Name()
+	if strings.HasSuffix(archivePath, ".gz") || strings.HasSuffix(archivePath, ".tgz") {
+		gzw := gzip.NewWriter(f)
+		err = app.writeSnapshotArchive(gzw, snapshotDir, &manifest, includeFiles)
+		if closeErr := gzw.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("unable to compress snapshot archive %s: %v", archivePath, closeErr)
+		}
+	} else {
+		err = app.writeSnapshotArchive(f, snapshotDir, &manifest, includeFiles)
+	}
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("unable to write snapshot archive %s: %v", archivePath, closeErr)
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, archivePath)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeSnapshotArchive writes the tar archive of the snapshot files of manifest,
+// of the upload_dirs if includeFiles is true, and of the manifest itself to w
+func (app *DdevApp) writeSnapshotArchive(w io.Writer, snapshotDir string, manifest *SnapshotArchiveManifest, includeFiles bool) error {
+	var err error
+	tw := tar.NewWriter(w)
+	for _, snapshotFile := range manifest.SnapshotFiles {
+		for _, name := range []string{snapshotFile, snapshotFile + snapshotMetadataSuffix} {
+			hostPath := filepath.Join(snapshotDir, name)
+			if !fileutil.FileExists(hostPath) {
+				continue
+			}
+			if err = addFileToSnapshotArchive(tw, hostPath, path.Join("db_snapshots", name), manifest.Checksums); err != nil {
+				return err
+			}
+		}
+	}
+
+	if includeFiles {
+		for _, uploadDir := range app.GetUploadDirs() {
+			hostUploadDir := app.calculateHostUploadDirFullPath(uploadDir)
+			if !fileutil.IsDirectory(hostUploadDir) {
+				continue
+			}
+			archiveDir := path.Join("files", strconv.Itoa(len(manifest.UploadDirs)))
+			manifest.UploadDirs = append(manifest.UploadDirs, uploadDir)
+			err = filepath.WalkDir(hostUploadDir, func(hostPath string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				// Only regular files are exported, symlinks may point anywhere on the host
+				if !d.Type().IsRegular() {
+					return nil
+				}
+				rel, err := filepath.Rel(hostUploadDir, hostPath)
+				if err != nil {
+					return err
+				}
+				return addFileToSnapshotArchive(tw, hostPath, path.Join(archiveDir, filepath.ToSlash(rel)), manifest.Checksums)
+			})
+			if err != nil {
+				return fmt.Errorf("unable to add upload_dirs '%s' to snapshot archive: %v", uploadDir, err)
+			}
+		}
+	}
+
+	// The manifest is written last, when all the checksums are known
+	manifestContent, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("error marshaling snapshot archive manifest: %v", err)
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    SnapshotArchiveManifestName,
+		Mode:    0644,
+		Size:    int64(len(manifestContent)),
+		ModTime: manifest.Exported,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = tw.Write(manifestContent); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// addFileToSnapshotArchive adds the file at hostPath to the archive as archiveName
+// and records its checksum
+func addFileToSnapshotArchive(tw *tar.Writer, hostPath string, archiveName string, checksums map[string]string) error {
+	checksum, err := fileutil.FileSHA256(hostPath)
+	if err != nil {
+		return fmt.Errorf("unable to checksum %s: %v", hostPath, err)
+	}
+	fi, err := os.Stat(hostPath)
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	header.Name = archiveName
+	if err = tw.WriteHeader(header); err != nil {
+		return err
+	}
+	f, err := os.Open(hostPath)
+	if err != nil {
+		return err
+	}
+	defer util.CheckClose(f)
+	if _, err = io.Copy(tw, f); err != nil {
+		return fmt.Errorf("unable to add %s to snapshot archive: %v", hostPath, err)
+	}
+	checksums[archiveName] = checksum
+	return nil
+}
+
+// ReadSnapshotArchive extracts the snapshot archive at archivePath into a temporary
+// directory and verifies its checksums. It returns the manifest, the directory and
+// a cleanup function that the caller has to call.
+func ReadSnapshotArchive(archivePath string) (*SnapshotArchiveManifest, string, func(), error) {
+	extractDir, err := os.MkdirTemp("", "ddev-snapshot-import-")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(extractDir)
+	}
+	fail := func(err error) (*SnapshotArchiveManifest, string, func(), error) {
+		cleanup()
+		return nil, "", nil, err
+	}
+
+	if err = archive.Untar(archivePath, extractDir, ""); err != nil {
+		return fail(fmt.Errorf("unable to extract snapshot archive %s: %v", archivePath, err))
+	}
+	manifestPath := filepath.Join(extractDir, SnapshotArchiveManifestName)
+	if !fileutil.FileExists(manifestPath) {
+		return fail(fmt.Errorf("%s is not a DDEV snapshot archive, it has no %s", archivePath, SnapshotArchiveManifestName))
+	}
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return fail(err)
+	}
+	manifest := &SnapshotArchiveManifest{}
+	if err = yaml.Unmarshal(content, manifest); err != nil {
+		return fail(fmt.Errorf("unable to parse %s in snapshot archive %s: %v", SnapshotArchiveManifestName, archivePath, err))
+	}
+	if len(manifest.SnapshotFiles) == 0 {
+		return fail(fmt.Errorf("snapshot archive %s doesn't contain any snapshot", archivePath))
+	}
+
+	// Every file has to be listed in the manifest and match its checksum
+	var extracted []string
+	err = filepath.WalkDir(extractDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(extractDir, p)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); rel != SnapshotArchiveManifestName {
+			extracted = append(extracted, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+	sort.Strings(extracted)
+	if len(extracted) != len(manifest.Checksums) {
+		return fail(fmt.Errorf("checksum verification of snapshot archive %s failed: it contains %d files but its manifest lists %d", archivePath, len(extracted), len(manifest.Checksums)))
+	}
+	for _, name := range extracted {
+		expected, ok := manifest.Checksums[name]
+		if !ok {
+			return fail(fmt.Errorf("checksum verification of snapshot archive %s failed: %s is not listed in its manifest", archivePath, name))
+		}
+		actual, err := fileutil.FileSHA256(filepath.Join(extractDir, filepath.FromSlash(name)))
+		if err != nil {
+			return fail(err)
+		}
+		if actual != expected {
+			return fail(fmt.Errorf("checksum verification of snapshot archive %s failed: %s has checksum %s, expected %s", archivePath, name, actual, expected))
+		}
+	}
+	for _, snapshotFile := range manifest.SnapshotFiles {
+		if _, ok := manifest.Checksums[path.Join("db_snapshots", snapshotFile)]; !ok || snapshotFile != filepath.Base(snapshotFile) {
+			return fail(fmt.Errorf("snapshot archive %s is missing snapshot file %s", archivePath, snapshotFile))
+		}
+	}
+
+	return manifest, extractDir, cleanup, nil
+}
+
+// ImportSnapshot imports a snapshot archive created by ExportSnapshot into the project's
+// .ddev/db_snapshots and, if importFiles is true, its files into the upload_dirs.
+// Returns the name of the imported snapshot, which can then be restored.
+func (app *DdevApp) ImportSnapshot(archivePath string, importFiles bool) (string, error) {
+	manifest, extractDir, cleanup, err := ReadSnapshotArchive(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	currentDBVersion := app.Database.Type + "_" + app.Database.Version
+	if manifest.DBVersion != currentDBVersion {
+		return "", fmt.Errorf("snapshot '%s' in %s is a DB server '%s' snapshot and is not compatible with the configured DDEV DB server version (%s).  Please import it into a project using the DB version it was created with", manifest.Snapshot, archivePath, manifest.DBVersion, currentDBVersion)
+	}
+
+	snapshotDir := app.GetConfigPath("db_snapshots")
+	if err = os.MkdirAll(snapshotDir, 0755); err != nil {
+		return "", err
+	}
+	// Files of the chain that are already in the project, like the base snapshot
+	// of an incremental one imported before, are kept if they are the same
+	var snapshotFiles []string
+	for name, checksum := range manifest.Checksums {
+		if !strings.HasPrefix(name, "db_snapshots/") {
+			continue
+		}
+		existing := filepath.Join(snapshotDir, path.Base(name))
+		if !fileutil.FileExists(existing) {
+			snapshotFiles = append(snapshotFiles, name)
+			continue
+		}
+		existingChecksum, err := fileutil.FileSHA256(existing)
+		if err != nil {
+			return "", err
+		}
+		if existingChecksum != checksum {
+			return "", fmt.Errorf("snapshot file %s already exists in project %s and is different from the one in %s, please delete it before importing", path.Base(name), app.Name, archivePath)
+		}
+	}
+	for _, name := range snapshotFiles {
+		if err = copy.Copy(filepath.Join(extractDir, filepath.FromSlash(name)), filepath.Join(snapshotDir, path.Base(name))); err != nil {
+			return "", fmt.Errorf("unable to copy %s into %s: %v", name, snapshotDir, err)
+		}
+	}
+
+	if importFiles {
+		for i, uploadDir := range manifest.UploadDirs {
+			src := filepath.Join(extractDir, "files", strconv.Itoa(i))
+			if !fileutil.IsDirectory(src) {
+				continue
+			}
+			target := app.calculateHostUploadDirFullPath(uploadDir)
+			if !strings.HasPrefix(target+string(os.PathSeparator), app.AppRoot+string(os.PathSeparator)) {
+				return "", fmt.Errorf("upload_dirs '%s' in snapshot archive %s is outside the project", uploadDir, archivePath)
+			}
+			if err = copy.Copy(src, target); err != nil {
+				return "", fmt.Errorf("unable to import files into %s: %v", target, err)
+			}
+			util.Success("Imported files into %s", target)
+		}
+	}
+
+	return manifest.Snapshot, nil
+}
+
+// getSnapshotFileDBVersion returns the DB type and version, like mariadb_10.11,
+// from a snapshot filename
+func getSnapshotFileDBVersion(snapshotFile string) string {
+	m := regexp.MustCompile(`((mysql|mariadb|postgres)_[0-9.]+)\.(gz|zst)$`)
+	if matches := m.FindStringSubmatch(snapshotFile); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
@@ -0,0 +1,270 @@
+package ddevapp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	configTypes "github.com/ddev/ddev/pkg/config/types"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseBackupCheckpoints checks that to_lsn is extracted from xtrabackup_checkpoints
+func TestParseBackupCheckpoints(t *testing.T) {
+	checkpoints := `backup_type = full-backuped
+from_lsn = 0
+to_lsn = 1637489
+last_lsn = 1637498
+recover_binlog_info = 0
+`
+	require.Equal(t, "1637489", parseBackupCheckpoints(checkpoints))
+	require.Equal(t, "", parseBackupCheckpoints(""))
+	require.Equal(t, "", parseBackupCheckpoints("cat: /var/tmp/nothing: No such file or directory"))
+}
+
+// TestSnapshotChain checks that incremental snapshots are resolved to their
+// full chain and that bases with dependents can't be deleted
+func TestSnapshotChain(t *testing.T) {
+	app := &DdevApp{Name: "chain", AppRoot: t.TempDir()}
+	snapshotDir := app.GetConfigPath("db_snapshots")
+	require.NoError(t, os.MkdirAll(snapshotDir, 0755))
+
+	writeSnapshot := func(name string, base string) string {
+		file := name + "-mariadb_10.11.zst"
+		require.NoError(t, os.WriteFile(filepath.Join(snapshotDir, file), []byte(name), 0644))
+		require.NoError(t, writeSnapshotMetadata(filepath.Join(snapshotDir, file), &SnapshotMetadata{Name: name, DBVersion: "mariadb_10.11", Base: base, ToLSN: "100"}))
+		return file
+	}
+	full := writeSnapshot("full", "")
+	inc1 := writeSnapshot("inc1", "full")
+	inc2 := writeSnapshot("inc2", "inc1")
+
+	chain, err := app.GetSnapshotChain("inc2")
+	require.NoError(t, err)
+	require.Equal(t, []string{full, inc1, inc2}, chain)
+
+	chain, err = app.GetSnapshotChain("full")
+	require.NoError(t, err)
+	require.Equal(t, []string{full}, chain)
+
+	snapshots, err := app.ListSnapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 3)
+
+	dependents, err := app.GetSnapshotDependents("full")
+	require.NoError(t, err)
+	require.Equal(t, []string{"inc1"}, dependents)
+
+	err = app.DeleteSnapshot("full")
+	require.ErrorContains(t, err, "inc1")
+	require.FileExists(t, filepath.Join(snapshotDir, full))
+
+	require.NoError(t, app.DeleteSnapshot("inc2"))
+	require.NoFileExists(t, filepath.Join(snapshotDir, inc2))
+	require.NoFileExists(t, filepath.Join(snapshotDir, inc2+snapshotMetadataSuffix))
+
+	// A missing base breaks the chain
+	require.NoError(t, os.Remove(filepath.Join(snapshotDir, full)))
+	_, err = app.GetSnapshotChain("inc1")
+	require.ErrorContains(t, err, "missing")
+}
+
+// TestParseSnapshotDatabases checks parsing of the database/table count listing
+func TestParseSnapshotDatabases(t *testing.T) {
+	out := "db\t87\nother\t0\n\nWarning: something unexpected\n"
+	require.Equal(t, []SnapshotDatabase{{Name: "db", Tables: 87}, {Name: "other", Tables: 0}}, parseSnapshotDatabases(out))
+	require.Empty(t, parseSnapshotDatabases(""))
+}
+
+// TestSnapshotMetadataInList checks that ListSnapshots reports the metadata sidecar contents
+func TestSnapshotMetadataInList(t *testing.T) {
+	app := &DdevApp{Name: "meta", AppRoot: t.TempDir()}
+	snapshotDir := app.GetConfigPath("db_snapshots")
+	require.NoError(t, os.MkdirAll(snapshotDir, 0755))
+
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	file := filepath.Join(snapshotDir, "before-migration-mysql_8.0.zst")
+	require.NoError(t, os.WriteFile(file, []byte("data"), 0644))
+	require.NoError(t, writeSnapshotMetadata(file, &SnapshotMetadata{
+		Name:        "before-migration",
+		Created:     created,
+		Description: "before migration",
+		DBVersion:   "mysql_8.0",
+		GitCommit:   "0123456789abcdef",
+		GitBranch:   "main",
+		Databases:   []SnapshotDatabase{{Name: "db", Tables: 12}},
+	}))
+	// An older snapshot without metadata
+	require.NoError(t, os.WriteFile(filepath.Join(snapshotDir, "old-mysql_8.0.gz"), []byte("data"), 0644))
+
+	snapshots, err := app.ListSnapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	for _, snapshot := range snapshots {
+		switch snapshot.Name {
+		case "before-migration":
+			require.True(t, created.Equal(snapshot.Created))
+			require.Equal(t, "before migration", snapshot.Description)
+			require.Equal(t, "main", snapshot.GitBranch)
+			require.Equal(t, "zstd", snapshot.Compression)
+			require.Equal(t, []SnapshotDatabase{{Name: "db", Tables: 12}}, snapshot.Databases)
+		case "old":
+			require.Equal(t, "gzip", snapshot.Compression)
+			require.Empty(t, snapshot.Description)
+		default:
+			t.Fatalf("unexpected snapshot %s", snapshot.Name)
+		}
+	}
+}
+
+// TestPlanSnapshotPrune checks which snapshots the snapshot_retention rules prune
+func TestPlanSnapshotPrune(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	snapshot := func(name string, age time.Duration, size int64, base string) Snapshot {
+		return Snapshot{Name: name, Created: now.Add(-age), Size: size, Base: base}
+	}
+	day := 24 * time.Hour
+	snapshots := []Snapshot{
+		snapshot("s1", 1*time.Hour, 100, ""),
+		snapshot("s2", 2*time.Hour, 100, ""),
+		snapshot("s3", 1*day, 100, ""),
+		snapshot("s4", 1*day+time.Hour, 100, ""),
+		snapshot("s5", 5*day, 100, ""),
+		snapshot("s6", 30*day, 100, ""),
+	}
+	names := func(snapshots []Snapshot) []string {
+		var n []string
+		for _, s := range snapshots {
+			n = append(n, s.Name)
+		}
+		return n
+	}
+
+	testCases := []struct {
+		description string
+		retention   configTypes.SnapshotRetention
+		snapshots   []Snapshot
+		expected    []string
+	}{
+		{"keep_last", configTypes.SnapshotRetention{KeepLast: 2}, snapshots, []string{"s3", "s4", "s5", "s6"}},
+		{"keep_daily", configTypes.SnapshotRetention{KeepDaily: 7}, snapshots, []string{"s2", "s4", "s6"}},
+		{"keep_last and keep_daily", configTypes.SnapshotRetention{KeepLast: 2, KeepDaily: 2}, snapshots, []string{"s4", "s5", "s6"}},
+		{"max_size", configTypes.SnapshotRetention{MaxSize: "350"}, snapshots, []string{"s4", "s5", "s6"}},
+		{"max_size keeps the newest", configTypes.SnapshotRetention{MaxSize: "10"}, snapshots, []string{"s2", "s3", "s4", "s5", "s6"}},
+		{"incremental bases are kept", configTypes.SnapshotRetention{KeepLast: 1}, []Snapshot{
+			snapshot("inc2", 1*time.Hour, 10, "inc1"),
+			snapshot("inc1", 2*time.Hour, 10, "full"),
+			snapshot("full", 3*time.Hour, 100, ""),
+			snapshot("old", 4*time.Hour, 100, ""),
+		}, []string{"old"}},
+		{"incremental snapshots are pruned before their base", configTypes.SnapshotRetention{KeepLast: 1}, []Snapshot{
+			snapshot("new", 1*time.Hour, 100, ""),
+			snapshot("inc1", 2*time.Hour, 10, "full"),
+			snapshot("full", 3*time.Hour, 100, ""),
+		}, []string{"inc1", "full"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			toPrune, err := planSnapshotPrune(tc.snapshots, tc.retention, now)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, names(toPrune))
+		})
+	}
+
+	_, err := planSnapshotPrune(snapshots, configTypes.SnapshotRetention{MaxSize: "lots"}, now)
+	require.Error(t, err)
+}
+
+// TestSnapshotArchive checks that an exported snapshot archive can be imported into
+// another project and that tampered or incompatible archives are refused
+func TestSnapshotArchive(t *testing.T) {
+	source := &DdevApp{Name: "source", AppRoot: t.TempDir(), Type: "php", Database: DatabaseDesc{Type: "mariadb", Version: "10.11"}}
+	snapshotDir := source.GetConfigPath("db_snapshots")
+	require.NoError(t, os.MkdirAll(snapshotDir, 0755))
+	for _, s := range []struct{ name, base string }{{"full", ""}, {"inc1", "full"}} {
+		file := filepath.Join(snapshotDir, s.name+"-mariadb_10.11.zst")
+		require.NoError(t, os.WriteFile(file, []byte("DATA-"+s.name), 0644))
+		require.NoError(t, writeSnapshotMetadata(file, &SnapshotMetadata{Name: s.name, DBVersion: "mariadb_10.11", Base: s.base, ToLSN: "100"}))
+	}
+	source.UploadDirs = []string{"files"}
+	require.NoError(t, os.MkdirAll(filepath.Join(source.AppRoot, "files", "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(source.AppRoot, "files", "sub", "image.txt"), []byte("image"), 0644))
+
+	archivePath := filepath.Join(t.TempDir(), "inc1.tar.gz")
+	require.NoError(t, source.ExportSnapshot("inc1", archivePath, true))
+
+	manifest, _, cleanup, err := ReadSnapshotArchive(archivePath)
+	require.NoError(t, err)
+	cleanup()
+	require.Equal(t, []string{"full-mariadb_10.11.zst", "inc1-mariadb_10.11.zst"}, manifest.SnapshotFiles)
+	require.Equal(t, "mariadb_10.11", manifest.DBVersion)
+	require.Contains(t, manifest.Checksums, "files/0/sub/image.txt")
+
+	target := &DdevApp{Name: "target", AppRoot: t.TempDir(), Type: "php", Database: DatabaseDesc{Type: "mariadb", Version: "10.11"}, UploadDirs: []string{"files"}}
+	name, err := target.ImportSnapshot(archivePath, true)
+	require.NoError(t, err)
+	require.Equal(t, "inc1", name)
+	chain, err := target.GetSnapshotChain("inc1")
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	require.FileExists(t, filepath.Join(target.AppRoot, "files", "sub", "image.txt"))
+
+	// Importing the same snapshot again keeps the files that are already there,
+	// but a different file with the same name is refused
+	_, err = target.ImportSnapshot(archivePath, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(target.GetConfigPath("db_snapshots"), "full-mariadb_10.11.zst"), []byte("OTHER"), 0644))
+	_, err = target.ImportSnapshot(archivePath, false)
+	require.ErrorContains(t, err, "snapshot file full-mariadb_10.11.zst already exists in project target and is different")
+	content, err := os.ReadFile(filepath.Join(target.GetConfigPath("db_snapshots"), "full-mariadb_10.11.zst"))
+	require.NoError(t, err)
+	require.Equal(t, "OTHER", string(content))
+
+	// A different DB server version is refused
+	other := &DdevApp{Name: "other", AppRoot: t.TempDir(), Database: DatabaseDesc{Type: "mysql", Version: "8.0"}}
+	_, err = other.ImportSnapshot(archivePath, false)
+	require.ErrorContains(t, err, "not compatible")
+
+	// A tampered archive is refused
+	plainArchive := filepath.Join(t.TempDir(), "full.tar")
+	require.NoError(t, source.ExportSnapshot("full", plainArchive, false))
+	content, err = os.ReadFile(plainArchive)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "DATA-full")
+	content = bytes.Replace(content, []byte("DATA-full"), []byte("DATA-fool"), 1)
+	require.NoError(t, os.WriteFile(plainArchive, content, 0644))
+	_, _, _, err = ReadSnapshotArchive(plainArchive)
+	require.ErrorContains(t, err, "checksum verification")
+
+	// Archives that couldn't be read back are refused, and an existing archive
+	// is replaced without leaving a temporary file behind
+	exportDir := t.TempDir()
+	require.ErrorContains(t, source.ExportSnapshot("full", filepath.Join(exportDir, "full.tar.xz"), false), "only .tar, .tar.gz and .tgz")
+	require.NoError(t, os.WriteFile(filepath.Join(exportDir, "full.tgz"), []byte("old"), 0644))
+	require.NoError(t, source.ExportSnapshot("full", filepath.Join(exportDir, "full.tgz"), false))
+	_, _, cleanup, err = ReadSnapshotArchive(filepath.Join(exportDir, "full.tgz"))
+	require.NoError(t, err)
+	cleanup()
+	entries, err := os.ReadDir(exportDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+// TestRestoreSnapshotWithMigrationUnsupported checks that migrating snapshots is refused
+// for PostgreSQL before any container is started
+func TestRestoreSnapshotWithMigrationUnsupported(t *testing.T) {
+	app := &DdevApp{Name: "migrate", AppRoot: t.TempDir(), Database: DatabaseDesc{Type: "mariadb", Version: "11.8"}}
+	snapshotDir := app.GetConfigPath("db_snapshots")
+	require.NoError(t, os.MkdirAll(snapshotDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(snapshotDir, "pg-postgres_16.zst"), []byte("data"), 0644))
+
+	require.Equal(t, "postgres_16", getSnapshotFileDBVersion("pg-postgres_16.zst"))
+	require.Equal(t, "mariadb_10.11", getSnapshotFileDBVersion("d9_20220107124831-mariadb_10.11.gz"))
+
+	err := app.RestoreSnapshotWithMigration("pg")
+	require.ErrorContains(t, err, "not with PostgreSQL")
+	err = app.RestoreSnapshotWithMigration("missing")
+	require.ErrorContains(t, err, "no snapshot found")
+}
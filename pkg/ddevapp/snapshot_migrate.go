@@ -0,0 +1,177 @@
+package ddevapp
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ddevImages "github.com/ddev/ddev/pkg/docker"
+	"github.com/ddev/ddev/pkg/dockerutil"
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/nodeps"
+	"github.com/ddev/ddev/pkg/output"
+	"github.com/ddev/ddev/pkg/util"
+	"github.com/moby/moby/api/types/container"
+)
+
+// snapshotMigrateDumpDir is where the logical dumps are written in the temporary dbserver container
+const snapshotMigrateDumpDir = "/var/tmp/snapshot_migrate"
+
+// RestoreSnapshotWithMigration restores a snapshot that may have been taken with a different
+// database version than the project uses. The physical snapshot is restored into a temporary
+// dbserver container running the snapshot's original version, dumped logically there,
+// and imported into the project's current database, like 'ddev utility migrate-database' does.
+// Only the user databases are migrated: the mysql system database, with the users and
+// grants created in the snapshot, is not compatible across versions and is left out.
+// Snapshots taken with the project's current database version are restored normally.
+func (app *DdevApp) RestoreSnapshotWithMigration(snapshotName string) (err error) {
+	currentDBVersion := app.Database.Type + "_" + app.Database.Version
+
+	snapshotFile, err := GetSnapshotFileFromName(snapshotName, app)
+	if err != nil {
+		return fmt.Errorf("no snapshot found for name %s: %v", snapshotName, err)
+	}
+	if fileutil.IsDirectory(app.GetConfigPath(filepath.Join("db_snapshots", snapshotFile))) {
+		return fmt.Errorf("snapshot '%s' is an obsolete directory-based snapshot and can't be migrated", snapshotName)
+	}
+	snapshotDBVersion := getSnapshotFileDBVersion(snapshotFile)
+	if snapshotDBVersion == currentDBVersion {
+		return app.RestoreSnapshot(snapshotName)
+	}
+
+	snapshotDBType, snapshotDBTypeVersion, _ := strings.Cut(snapshotDBVersion, "_")
+	for _, dbType := range []string{snapshotDBType, app.Database.Type} {
+		if dbType != nodeps.MariaDB && dbType != nodeps.MySQL {
+			return fmt.Errorf("snapshot '%s' is a DB server '%s' snapshot and the project uses %s; migrating snapshots works only with MySQL and MariaDB, not with PostgreSQL", snapshotName, snapshotDBVersion, currentDBVersion)
+		}
+	}
+	snapshotChain, err := app.GetSnapshotChain(snapshotName)
+	if err != nil {
+		return err
+	}
+	snapshotImage := ddevImages.GetDBImage(snapshotDBType, snapshotDBTypeVersion)
+	if len(snapshotChain) > 1 {
+		if err = checkIncrementalSnapshotSupport(snapshotImage); err != nil {
+			return fmt.Errorf("unable to migrate incremental snapshot '%s': %v", snapshotName, err)
+		}
+	}
+
+	err = app.ProcessHooks("pre-restore-snapshot")
+	if err != nil {
+		return fmt.Errorf("failed to process pre-restore-snapshot hooks: %v", err)
+	}
+	start := time.Now()
+
+	// snapshotApp only describes the snapshot's database server, for choosing its client tools
+	snapshotApp := &DdevApp{Name: app.Name, Database: DatabaseDesc{Type: snapshotDBType, Version: snapshotDBTypeVersion}}
+	uid, _, _ := dockerutil.GetContainerUser()
+
+	snapshotsMount := app.GetConfigPath("db_snapshots") + ":/mnt/snapshots"
+	if globalconfig.DdevGlobalConfig.NoBindMounts {
+		if err = app.copySnapshotChainIntoVolume(snapshotChain, ""); err != nil {
+			return err
+		}
+		snapshotsMount = "ddev-" + app.Name + "-snapshots:/mnt/snapshots"
+	}
+
+	containerName := "ddev-" + app.Name + "-snapshot-migrate"
+	// Remove any leftover of an earlier failed migration
+	_ = dockerutil.RemoveContainer(containerName)
+	util.Success("Restoring snapshot '%s' into a temporary %s database server...", snapshotName, snapshotDBVersion)
+	containerID, _, err := dockerutil.RunSimpleContainerExtended(containerName, &container.Config{
+		Image:  snapshotImage,
+		Cmd:    append([]string{RestoreSnapshotCommand}, snapshotChain...),
+		User:   uid,
+		Labels: map[string]string{"com.ddev.site-name": app.Name},
+	}, &container.HostConfig{
+		Binds: []string{snapshotsMount},
+	}, false, 0)
+	if err != nil {
+		return fmt.Errorf("failed to start temporary %s database server: %v", snapshotDBVersion, err)
+	}
+	// nolint: errcheck
+	defer dockerutil.RemoveContainer(containerID)
+
+	maxWaitTime := max(SnapshotRestoreDefaultWaitTime, app.GetMaxContainerWaitTime())
+	output.UserOut.Printf("Waiting up to %ds for snapshot restore to complete...\nYou can also follow the restore progress in another terminal window with `docker logs -f %s`", maxWaitTime, containerName)
+	pingCmd := fmt.Sprintf(`%s -uroot -proot -e "SELECT 1" >/dev/null 2>&1`, snapshotApp.GetDBClientCommand())
+	for {
+		if _, _, err = dockerutil.Exec(containerID, pingCmd, uid); err == nil {
+			break
+		}
+		if time.Since(start) > time.Duration(maxWaitTime)*time.Second {
+			return fmt.Errorf("timed out after %ds waiting for snapshot '%s' to be restored in the temporary database server, see `docker logs %s`", maxWaitTime, snapshotName, containerName)
+		}
+		time.Sleep(1 * time.Second)
+		if !output.JSONOutput {
+			fmt.Print(".")
+		}
+	}
+	output.UserOut.Println()
+
+	stdout, stderr, err := dockerutil.Exec(containerID, snapshotApp.getSnapshotDatabasesCommand(), uid)
+	if err != nil {
+		return fmt.Errorf("unable to list databases in snapshot '%s': %v, stderr=%s", snapshotName, err, stderr)
+	}
+	databases := parseSnapshotDatabases(stdout)
+	if len(databases) == 0 {
+		return fmt.Errorf("snapshot '%s' doesn't contain any databases", snapshotName)
+	}
+
+	// Each database is dumped separately because ImportDB imports into a single database
+	var dumpCmds []string
+	for _, db := range databases {
+		dumpFile := path.Join(snapshotMigrateDumpDir, db.Name+".sql")
+		dumpCmds = append(dumpCmds, fmt.Sprintf(`%s -uroot -proot --single-transaction --routines --triggers %s > %s && gzip %s`, snapshotApp.GetDBDumpCommand(), db.Name, dumpFile, dumpFile))
+	}
+	util.Success("Dumping database(s) %s from snapshot '%s'...", strings.Join(databaseNames(databases), ", "), snapshotName)
+	util.Warning("Users and grants are not migrated, only the default 'db' user is available in the restored database(s)")
+	_, stderr, err = dockerutil.Exec(containerID, fmt.Sprintf("mkdir -p %s && %s", snapshotMigrateDumpDir, strings.Join(dumpCmds, " && ")), uid)
+	if err != nil {
+		return fmt.Errorf("failed to dump snapshot '%s': %v, stderr=%s", snapshotName, err, stderr)
+	}
+
+	dumpDir, err := os.MkdirTemp("", "ddev-snapshot-migrate-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(dumpDir); removeErr != nil && err == nil {
+			err = fmt.Errorf("unable to remove temporary directory %s: %v", dumpDir, removeErr)
+		}
+	}()
+	if err = dockerutil.CopyFromContainer(containerName, snapshotMigrateDumpDir, dumpDir); err != nil {
+		return fmt.Errorf("failed to copy database dumps out of the temporary database server: %v", err)
+	}
+	_ = dockerutil.RemoveContainer(containerID)
+
+	if err = app.StartAppIfNotRunning(); err != nil {
+		return fmt.Errorf("failed to start project %s: %v", app.Name, err)
+	}
+	for _, db := range databases {
+		dumpFile := filepath.Join(dumpDir, path.Base(snapshotMigrateDumpDir), db.Name+".sql.gz")
+		if err = app.ImportDB(dumpFile, "", true, false, db.Name); err != nil {
+			return fmt.Errorf("failed to import database %s from snapshot '%s': %v", db.Name, snapshotName, err)
+		}
+	}
+
+	util.Success("Database snapshot %s was migrated from %s to %s and restored in %vs", snapshotName, snapshotDBVersion, currentDBVersion, int(time.Since(start).Seconds()))
+	err = app.ProcessHooks("post-restore-snapshot")
+	if err != nil {
+		return fmt.Errorf("failed to process post-restore-snapshot hooks: %v", err)
+	}
+	return nil
+}
+
+// databaseNames returns the names of the databases
+func databaseNames(databases []SnapshotDatabase) []string {
+	var names []string
+	for _, db := range databases {
+		names = append(names, db.Name)
+	}
+	return names
+}
@@ -10,7 +10,10 @@ import (
 
 	"github.com/ddev/ddev/pkg/archive"
 	"github.com/ddev/ddev/pkg/ddevapp"
+	"github.com/ddev/ddev/pkg/docker"
 	"github.com/ddev/ddev/pkg/dockerutil"
+	"github.com/ddev/ddev/pkg/fileutil"
+	"github.com/ddev/ddev/pkg/globalconfig"
 	"github.com/ddev/ddev/pkg/nodeps"
 	"github.com/ddev/ddev/pkg/testcommon"
 	"github.com/ddev/ddev/pkg/util"
@@ -375,3 +378,116 @@ func TestDdevRestoreSnapshot(t *testing.T) {
 	}
 	runTime()
 }
+
+// TestDdevRestoreIncrementalSnapshot tests restoring an incremental snapshot on top of
+// its base snapshot, with and without bind mounts, or that it's refused when the
+// database image can't restore it.
+func TestDdevRestoreIncrementalSnapshot(t *testing.T) {
+	// Don't run this unless GOTEST_SHORT is unset; it doesn't need to be run everywhere.
+	if os.Getenv("GOTEST_SHORT") != "" {
+		t.Skip("Skip because GOTEST_SHORT is set")
+	}
+
+	assert := assert2.New(t)
+
+	runTime := util.TimeTrackC(t.Name())
+	origDir, _ := os.Getwd()
+	site := TestSites[0]
+
+	d7testerTest1Dump, err := filepath.Abs(filepath.Join("testdata", "TestDdevRestoreSnapshot", "restore_snapshot", "d7tester_test_1.sql.gz"))
+	require.NoError(t, err)
+	d7testerTest2Dump, err := filepath.Abs(filepath.Join("testdata", "TestDdevRestoreSnapshot", "restore_snapshot", "d7tester_test_2.sql.gz"))
+	require.NoError(t, err)
+
+	testcommon.ClearDockerEnv()
+
+	app, err := ddevapp.NewApp(site.Dir, false)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = app.Stop(true, false)
+		assert.NoError(err)
+		err = os.Chdir(origDir)
+		assert.NoError(err)
+		_ = os.RemoveAll(app.GetConfigPath("db_snapshots"))
+		testcommon.ClearDockerEnv()
+	})
+
+	err = os.Chdir(app.AppRoot)
+	require.NoError(t, err)
+	err = app.Start()
+	require.NoError(t, err)
+
+	nodeTitle := func() string {
+		stdout, _, err := app.Exec(&ddevapp.ExecOpts{
+			Service: "db",
+			Cmd:     fmt.Sprintf(`echo "SELECT title FROM node WHERE nid=1;" | %s -N`, app.GetDBClientCommand()),
+		})
+		require.NoError(t, err)
+		return stdout
+	}
+
+	err = app.ImportDB(d7testerTest1Dump, "", false, false, "db")
+	require.NoError(t, err)
+	baseSnapshot, err := app.Snapshot(t.Name() + "_base")
+	require.NoError(t, err)
+
+	err = app.ImportDB(d7testerTest2Dump, "", false, false, "db")
+	require.NoError(t, err)
+	assert.Contains(nodeTitle(), "d7 tester test 2 has 2 nodes")
+
+	supported, err := dockerutil.ImageLabel(app.GetDBImage(), docker.DdevIncrementalSnapshotsLabel)
+	require.NoError(t, err)
+	if supported != "true" {
+		// An incremental snapshot can't be created for an image that can't restore it
+		_, err = app.CreateSnapshot(ddevapp.SnapshotOpts{Name: t.Name() + "_incr", Incremental: true})
+		require.ErrorContains(t, err, "can't restore incremental snapshots")
+
+		// and one from another machine isn't restored on top of the current data
+		baseFile, err := ddevapp.GetSnapshotFileFromName(baseSnapshot, app)
+		require.NoError(t, err)
+		snapshotDir := app.GetConfigPath("db_snapshots")
+		incrementalFile := strings.Replace(baseFile, baseSnapshot, t.Name()+"_incr", 1)
+		require.NoError(t, fileutil.CopyFile(filepath.Join(snapshotDir, baseFile), filepath.Join(snapshotDir, incrementalFile)))
+		metadata := fmt.Sprintf("name: %s\ndb_version: %s_%s\nbase: %s\n", t.Name()+"_incr", app.Database.Type, app.Database.Version, baseSnapshot)
+		require.NoError(t, os.WriteFile(filepath.Join(snapshotDir, incrementalFile+".yaml"), []byte(metadata), 0644))
+		err = app.RestoreSnapshot(t.Name() + "_incr")
+		require.ErrorContains(t, err, "can't restore incremental snapshots")
+		assert.Contains(nodeTitle(), "d7 tester test 2 has 2 nodes")
+		runTime()
+		return
+	}
+
+	incrementalSnapshot, err := app.CreateSnapshot(ddevapp.SnapshotOpts{Name: t.Name() + "_incr", Incremental: true})
+	require.NoError(t, err)
+	chain, err := app.GetSnapshotChain(incrementalSnapshot)
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+
+	err = app.ImportDB(d7testerTest1Dump, "", false, false, "db")
+	require.NoError(t, err)
+	assert.Contains(nodeTitle(), "d7 tester test 1 has 1 node")
+
+	err = app.RestoreSnapshot(incrementalSnapshot)
+	require.NoError(t, err)
+	assert.Contains(nodeTitle(), "d7 tester test 2 has 2 nodes")
+
+	err = app.RestoreSnapshot(baseSnapshot)
+	require.NoError(t, err)
+	assert.Contains(nodeTitle(), "d7 tester test 1 has 1 node")
+
+	// Without bind mounts the whole chain is copied into the snapshots volume
+	origNoBindMounts := globalconfig.DdevGlobalConfig.NoBindMounts
+	globalconfig.DdevGlobalConfig.NoBindMounts = true
+	t.Cleanup(func() {
+		globalconfig.DdevGlobalConfig.NoBindMounts = origNoBindMounts
+	})
+	err = app.Restart()
+	require.NoError(t, err)
+	assert.Contains(nodeTitle(), "d7 tester test 1 has 1 node")
+	err = app.RestoreSnapshot(incrementalSnapshot)
+	require.NoError(t, err)
+	assert.Contains(nodeTitle(), "d7 tester test 2 has 2 nodes")
+
+	runTime()
+}
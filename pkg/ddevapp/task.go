@@ -2,11 +2,18 @@ package ddevapp
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ddev/ddev/pkg/dockerutil"
 	"github.com/ddev/ddev/pkg/nodeps"
 	"github.com/ddev/ddev/pkg/util"
 	"github.com/mattn/go-isatty"
@@ -21,6 +28,13 @@ type Task interface {
 	GetDescription() string
 }
 
+// outputTask is a Task that can write its output somewhere other than the terminal,
+// which tasks running in parallel need
+type outputTask interface {
+	Task
+	executeWithOutput(out io.Writer) error
+}
+
 // ExecTask is the struct that defines "exec" tasks for hooks, commands
 // to be run in containers.
 type ExecTask struct {
@@ -67,6 +81,20 @@ func (c ExecTask) Execute() error {
 	return nil
 }
 
+// executeWithOutput executes an ExecTask without a terminal, writing its output to out
+func (c ExecTask) executeWithOutput(out io.Writer) error {
+	_, _, err := c.app.Exec(&ExecOpts{
+		Service:   c.service,
+		User:      c.user,
+		Cmd:       c.exec,
+		RawCmd:    c.execRaw,
+		NoCapture: true,
+		Stdout:    out,
+		Stderr:    out,
+	})
+	return err
+}
+
 // GetDescription returns a human-readable description of the task
 func (c ExecTask) GetDescription() string {
 	s := c.exec
@@ -90,6 +118,12 @@ func (c ExecHostTask) Execute() error {
 	return runHostCommandWithCapturedOutput(c.app, c.exec)
 }
 
+// executeWithOutput (HostTask) executes a command on the host without stdin,
+// writing its output to out
+func (c ExecHostTask) executeWithOutput(out io.Writer) error {
+	return runHostCommandWithOutput(c.app, c.exec, out)
+}
+
 // Execute (ComposerTask) runs a Composer command in the web container
 // and returns stdout, stderr, err
 func (c ComposerTask) Execute() error {
@@ -98,11 +132,219 @@ func (c ComposerTask) Execute() error {
 	return err
 }
 
+// executeWithOutput (ComposerTask) runs a Composer command in the web container
+// without a terminal, writing its output to out
+func (c ComposerTask) executeWithOutput(out io.Writer) error {
+	_, _, err := c.app.composer(c.execRaw, out)
+
+	return err
+}
+
 // GetDescription returns a human-readable description of the task
 func (c ComposerTask) GetDescription() string {
 	return fmt.Sprintf("Composer command '%v' in web container", c.execRaw)
 }
 
+// defaultWaitForTimeout is how many seconds a "wait-for" task waits by default
+const defaultWaitForTimeout = 120
+
+// waitForHost matches the host of a "wait-for" tcp target, a service name or a hostname
+var waitForHost = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// WaitForTask is the struct that defines "wait-for" tasks for hooks, which
+// wait until a TCP port accepts connections, a URL responds, or a service is healthy.
+// Exactly one of tcp, url and service is set.
+type WaitForTask struct {
+	tcp     string // host:port, polled from the web container so service names resolve
+	url     string // polled from the host, through the router
+	service string // service whose container must become healthy
+	timeout int    // seconds
+	app     *DdevApp
+}
+
+// Execute waits for the target of a WaitForTask, or fails when the timeout expires
+func (c WaitForTask) Execute() error {
+	switch {
+	case c.service != "":
+		labels := map[string]string{
+			"com.ddev.site-name":        c.app.GetName(),
+			"com.docker.compose.oneoff": "False",
+		}
+		if err := dockerutil.ContainersWait(c.timeout, labels, c.service); err != nil {
+			return fmt.Errorf("service %s didn't become healthy within %d seconds: %v", c.service, c.timeout, err)
+		}
+	case c.tcp != "":
+		host, port, _ := net.SplitHostPort(c.tcp)
+		_, _, err := c.app.Exec(&ExecOpts{
+			Service: nodeps.WebContainer,
+			Cmd:     fmt.Sprintf("timeout %d bash -c 'until (echo >/dev/tcp/%s/%s) 2>/dev/null; do sleep 1; done'", c.timeout, host, port),
+		})
+		if err != nil {
+			return fmt.Errorf("%s didn't accept connections within %d seconds: %v", c.tcp, c.timeout, err)
+		}
+	default:
+		return waitForURL(c.url, time.Duration(c.timeout)*time.Second)
+	}
+	return nil
+}
+
+// executeWithOutput executes a WaitForTask, which has no output of its own
+func (c WaitForTask) executeWithOutput(_ io.Writer) error {
+	return c.Execute()
+}
+
+// GetDescription returns a human-readable description of the task
+func (c WaitForTask) GetDescription() string {
+	switch {
+	case c.service != "":
+		return fmt.Sprintf("Wait for service '%s' to be healthy (timeout %ds)", c.service, c.timeout)
+	case c.tcp != "":
+		return fmt.Sprintf("Wait for %s to accept connections from the web container (timeout %ds)", c.tcp, c.timeout)
+	}
+	return fmt.Sprintf("Wait for %s to respond (timeout %ds)", c.url, c.timeout)
+}
+
+// waitForURL polls url until it responds with a status below 400, or fails when timeout expires.
+// Certificates aren't verified, because this only checks that the site is up.
+func waitForURL(url string, timeout time.Duration) error {
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	deadline := time.Now().Add(timeout)
+	last := ""
+	for {
+		resp, err := client.Get(url)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode < 400 {
+				return nil
+			}
+			last = resp.Status
+		} else {
+			last = err.Error()
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s didn't respond within %s, last result: %s", url, timeout, last)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// HTTPTask is the struct that defines "http" tasks for hooks, HTTP requests
+// issued from inside a container, that fail unless they get the expected status code
+type HTTPTask struct {
+	url     string
+	method  string
+	status  int
+	service string // Name of service, defaults to web
+	app     *DdevApp
+}
+
+// Execute issues the request of an HTTPTask with curl. The response body is
+// attached to the error if the status code isn't the expected one.
+func (c HTTPTask) Execute() error {
+	stdout, stderr, err := c.app.Exec(&ExecOpts{
+		Service: c.service,
+		RawCmd:  []string{"curl", "-sS", "-X", c.method, "-w", "\n%{http_code}", c.url},
+	})
+	if err != nil {
+		return errorWithOutput(fmt.Errorf("%s %s failed: %v", c.method, c.url, err), stdout+stderr)
+	}
+	// The status code is written on a line of its own after the body
+	out := strings.TrimRight(stdout, "\n")
+	body, code := "", out
+	if i := strings.LastIndex(out, "\n"); i >= 0 {
+		body, code = out[:i], out[i+1:]
+	}
+	if code != strconv.Itoa(c.status) {
+		return errorWithOutput(fmt.Errorf("%s %s returned status %s instead of %d", c.method, c.url, code, c.status), body)
+	}
+	return nil
+}
+
+// executeWithOutput executes an HTTPTask, which has no output unless it fails
+func (c HTTPTask) executeWithOutput(_ io.Writer) error {
+	return c.Execute()
+}
+
+// GetDescription returns a human-readable description of the task
+func (c HTTPTask) GetDescription() string {
+	return fmt.Sprintf("HTTP %s %s from container/service '%s', expecting status %d", c.method, c.url, c.service, c.status)
+}
+
+// newWaitForTask creates a WaitForTask from the value of its "wait-for" key
+func newWaitForTask(app *DdevApp, value any) (Task, error) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("wait-for must be a map with one of tcp, url or service, and optionally timeout")
+	}
+	t := WaitForTask{app: app, timeout: defaultWaitForTimeout}
+	targets := 0
+	for k, v := range m {
+		switch k {
+		case "tcp", "url", "service":
+			s, ok := v.(string)
+			if !ok || s == "" {
+				return nil, fmt.Errorf("wait-for %s must be a non-empty string, not %v", k, v)
+			}
+			targets++
+			switch k {
+			case "tcp":
+				host, port, err := net.SplitHostPort(s)
+				if _, perr := strconv.Atoi(port); err != nil || perr != nil || !waitForHost.MatchString(host) {
+					return nil, fmt.Errorf("wait-for tcp must be host:port, like solr:8983, not %s", s)
+				}
+				t.tcp = s
+			case "url":
+				if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
+					return nil, fmt.Errorf("wait-for url must be an http:// or https:// URL, not %s", s)
+				}
+				t.url = s
+			default:
+				t.service = s
+			}
+		case "timeout":
+			if t.timeout, ok = v.(int); !ok || t.timeout <= 0 {
+				return nil, fmt.Errorf("wait-for timeout must be a positive number of seconds, not %v", v)
+			}
+		default:
+			return nil, fmt.Errorf("unknown wait-for key '%s', valid keys are tcp, url, service and timeout", k)
+		}
+	}
+	if targets != 1 {
+		return nil, fmt.Errorf("wait-for must have exactly one of tcp, url or service")
+	}
+	return t, nil
+}
+
+// newHTTPTask creates an HTTPTask from its YAML description
+func newHTTPTask(app *DdevApp, ytask YAMLTask) (Task, error) {
+	url, ok := ytask["http"].(string)
+	if !ok || (!strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://")) {
+		return nil, fmt.Errorf("http must be an http:// or https:// URL, not %v", ytask["http"])
+	}
+	t := HTTPTask{app: app, url: url, method: http.MethodGet, status: http.StatusOK, service: nodeps.WebContainer}
+	if v, ok := ytask["method"]; ok {
+		method, ok := v.(string)
+		if !ok || method == "" {
+			return nil, fmt.Errorf("http method must be a string like GET or POST, not %v", v)
+		}
+		t.method = strings.ToUpper(method)
+	}
+	if v, ok := ytask["status"]; ok {
+		if t.status, ok = v.(int); !ok || t.status < 100 || t.status > 599 {
+			return nil, fmt.Errorf("http status must be an HTTP status code, not %v", v)
+		}
+	}
+	if v, ok := ytask["service"]; ok {
+		if t.service, ok = v.(string); !ok || t.service == "" {
+			return nil, fmt.Errorf("http service must be a service name, not %v", v)
+		}
+	}
+	return t, nil
+}
+
 // NewTask is the factory method to create whatever kind of task
 // we need using the yaml description of the task.
 // Returns a task (of various types) or nil
@@ -171,6 +413,20 @@ func NewTask(app *DdevApp, ytask YAMLTask) Task {
 			return t
 		}
 		util.Warning("Invalid exec/exec_raw value, not executing it: %v", value)
+	} else if value, ok = ytask["wait-for"]; ok {
+		t, err := newWaitForTask(app, value)
+		if err != nil {
+			util.Warning("Invalid wait-for value, not executing it: %v", err)
+			return nil
+		}
+		return t
+	} else if _, ok = ytask["http"]; ok {
+		t, err := newHTTPTask(app, ytask)
+		if err != nil {
+			util.Warning("Invalid http value, not executing it: %v", err)
+			return nil
+		}
+		return t
 	}
 	return nil
 }
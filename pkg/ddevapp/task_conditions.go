@@ -0,0 +1,177 @@
+package ddevapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ddev/ddev/pkg/nodeps"
+	"github.com/ddev/ddev/pkg/util"
+)
+
+// TaskCondition is the `when:` of a hook task or of a parallel group of tasks.
+// The task only runs if all the conditions that are set hold.
+type TaskCondition struct {
+	// FileExists is a file or directory that must exist, relative to the project root
+	FileExists string
+	// Env is an environment variable that must be set on the host
+	Env string
+	// ProjectTypes are the project types the task runs for
+	ProjectTypes []string
+	// Test is a shell command run on the host that must succeed
+	Test string
+	// ConfigChanged runs the task only if the project configuration changed
+	// since the task last succeeded
+	ConfigChanged bool
+}
+
+// parseTaskCondition parses the value of a `when:` key
+func parseTaskCondition(value any) (*TaskCondition, error) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("'when' must be a map of conditions, not %v", value)
+	}
+	w := &TaskCondition{}
+	for k, v := range m {
+		var err error
+		switch k {
+		case "file_exists":
+			w.FileExists, err = conditionString(k, v)
+		case "env":
+			w.Env, err = conditionString(k, v)
+		case "test":
+			w.Test, err = conditionString(k, v)
+		case "project_type":
+			if s, ok := v.(string); ok {
+				w.ProjectTypes = []string{s}
+			} else if w.ProjectTypes, err = util.InterfaceSliceToStringSlice(v); err != nil {
+				err = fmt.Errorf("'project_type' must be a project type or a list of project types: %v", err)
+			}
+		case "config_changed":
+			if w.ConfigChanged, ok = v.(bool); !ok {
+				err = fmt.Errorf("'config_changed' must be true or false, not %v", v)
+			}
+		default:
+			err = fmt.Errorf("unknown condition '%s', valid conditions are file_exists, env, project_type, test and config_changed", k)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// parseTaskInputs parses the value of an `inputs:` key, a glob or a list of globs
+func parseTaskInputs(value any) ([]string, error) {
+	var inputs []string
+	var err error
+	if s, ok := value.(string); ok && s != "" {
+		inputs = []string{s}
+	} else if inputs, err = util.InterfaceSliceToStringSlice(value); err != nil || len(inputs) == 0 {
+		return nil, fmt.Errorf("'inputs' must be a file glob or a list of file globs, not %v", value)
+	}
+	for _, input := range inputs {
+		if _, err = filepath.Match(input, ""); err != nil {
+			return nil, fmt.Errorf("invalid input '%s': %v", input, err)
+		}
+	}
+	return inputs, nil
+}
+
+// conditionString returns the value of a condition that takes a non-empty string
+func conditionString(name string, value any) (string, error) {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("'%s' must be a non-empty string, not %v", name, value)
+	}
+	return s, nil
+}
+
+// String describes the conditions, as in "file_exists: package.json, env: CI"
+func (w *TaskCondition) String() string {
+	var s []string
+	if w.FileExists != "" {
+		s = append(s, "file_exists: "+w.FileExists)
+	}
+	if w.Env != "" {
+		s = append(s, "env: "+w.Env)
+	}
+	if len(w.ProjectTypes) > 0 {
+		s = append(s, "project_type: "+strings.Join(w.ProjectTypes, "|"))
+	}
+	if w.Test != "" {
+		s = append(s, "test: "+w.Test)
+	}
+	if w.ConfigChanged {
+		s = append(s, "config_changed")
+	}
+	return strings.Join(s, ", ")
+}
+
+// check reports whether the conditions hold for the task identified by taskID.
+// If they don't, the reason says which one doesn't.
+// The cheap conditions are checked first, so the test command only runs if they hold.
+func (w *TaskCondition) check(app *DdevApp, taskID string) (ok bool, reason string, err error) {
+	if w.FileExists != "" {
+		f := w.FileExists
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(app.GetAppRoot(), f)
+		}
+		if _, err := os.Stat(f); err != nil {
+			return false, fmt.Sprintf("%s doesn't exist", w.FileExists), nil
+		}
+	}
+	if w.Env != "" {
+		if _, ok := os.LookupEnv(w.Env); !ok {
+			return false, fmt.Sprintf("environment variable %s isn't set", w.Env), nil
+		}
+	}
+	if len(w.ProjectTypes) > 0 && !slices.ContainsFunc(w.ProjectTypes, func(t string) bool {
+		// "drupal" is an alias of the latest Drupal, as it is for the project type
+		if t == nodeps.AppTypeDrupal {
+			t = nodeps.AppTypeDrupalLatestStable
+		}
+		return strings.ToLower(t) == app.GetType()
+	}) {
+		return false, fmt.Sprintf("project type is %s, not %s", app.GetType(), strings.Join(w.ProjectTypes, " or ")), nil
+	}
+	if w.ConfigChanged && !app.RerunHooks {
+		hash, err := app.configHash()
+		if err != nil {
+			return false, "", err
+		}
+		s, err := app.readHookState()
+		if err != nil {
+			return false, "", err
+		}
+		if s.ConfigHashes[taskID] == hash {
+			return false, "the project configuration didn't change since it last ran", nil
+		}
+	}
+	if w.Test != "" {
+		if runHostCommandWithOutput(app, w.Test, io.Discard) != nil {
+			return false, fmt.Sprintf("test '%s' failed", w.Test), nil
+		}
+	}
+	return true, "", nil
+}
+
+// recordSuccess remembers what the conditions need to know about a successful run of the task
+func (w *TaskCondition) recordSuccess(app *DdevApp, taskID string) error {
+	if !w.ConfigChanged {
+		return nil
+	}
+	hash, err := app.configHash()
+	if err != nil {
+		return err
+	}
+	return app.updateHookState(func(s *hookState) {
+		if s.ConfigHashes == nil {
+			s.ConfigHashes = map[string]string{}
+		}
+		s.ConfigHashes[taskID] = hash
+	})
+}
@@ -0,0 +1,302 @@
+package ddevapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	asrt "github.com/stretchr/testify/assert"
+	rqr "github.com/stretchr/testify/require"
+)
+
+// TestTaskConditions tests the `when:` conditions of hook tasks
+func TestTaskConditions(t *testing.T) {
+	assert := asrt.New(t)
+	require := rqr.New(t)
+
+	app := &DdevApp{Name: "taskconditions", AppRoot: t.TempDir(), Type: "drupal"}
+	require.NoError(os.MkdirAll(app.AppConfDir(), 0755))
+	require.NoError(os.WriteFile(app.GetConfigPath("config.yaml"), []byte("type: drupal\n"), 0644))
+	require.NoError(os.WriteFile(filepath.Join(app.AppRoot, "package.json"), []byte("{}"), 0644))
+	t.Setenv("DDEV_TASK_CONDITION_SET", "")
+
+	testCases := map[string]struct {
+		when map[string]any
+		run  bool
+	}{
+		"file exists":              {map[string]any{"file_exists": "package.json"}, true},
+		"file doesn't exist":       {map[string]any{"file_exists": "composer.json"}, false},
+		"env set, even if empty":   {map[string]any{"env": "DDEV_TASK_CONDITION_SET"}, true},
+		"env not set":              {map[string]any{"env": "DDEV_TASK_CONDITION_UNSET"}, false},
+		"project type":             {map[string]any{"project_type": "drupal"}, true},
+		"one of the project types": {map[string]any{"project_type": []any{"wordpress", "drupal"}}, true},
+		"other project type":       {map[string]any{"project_type": "wordpress"}, false},
+		"test succeeds":            {map[string]any{"test": "test -f package.json"}, true},
+		"test fails":               {map[string]any{"test": "false"}, false},
+		"all conditions hold":      {map[string]any{"file_exists": "package.json", "project_type": "drupal"}, true},
+		"one condition fails":      {map[string]any{"file_exists": "package.json", "project_type": "wordpress"}, false},
+	}
+	for name, tc := range testCases {
+		w, err := parseTaskCondition(tc.when)
+		require.NoError(err, name)
+		run, reason, err := w.check(app, "post-start: test")
+		require.NoError(err, name)
+		assert.Equal(tc.run, run, name)
+		assert.Equal(tc.run, reason == "", "%s: %s", name, reason)
+	}
+
+	for _, when := range []any{
+		"package.json",
+		map[string]any{"file_exist": "package.json"},
+		map[string]any{"file_exists": ""},
+		map[string]any{"project_type": []any{1}},
+		map[string]any{"config_changed": "yes"},
+	} {
+		_, err := parseTaskCondition(when)
+		assert.Error(err, "%v", when)
+	}
+
+	// config_changed runs until the task succeeds with the current configuration
+	w, err := parseTaskCondition(map[string]any{"config_changed": true})
+	require.NoError(err)
+	run, _, err := w.check(app, "post-start: test")
+	require.NoError(err)
+	assert.True(run)
+	require.NoError(w.recordSuccess(app, "post-start: test"))
+	run, reason, err := w.check(app, "post-start: test")
+	require.NoError(err)
+	assert.False(run)
+	assert.Contains(reason, "didn't change")
+	// Each task is tracked separately
+	run, _, err = w.check(app, "post-start: other test")
+	require.NoError(err)
+	assert.True(run)
+	// An override changes the configuration too
+	require.NoError(os.WriteFile(app.GetConfigPath("config.local.yaml"), []byte("php_version: \"8.4\"\n"), 0644))
+	run, _, err = w.check(app, "post-start: test")
+	require.NoError(err)
+	assert.True(run)
+}
+
+// TestProcessHooksParallel tests that the tasks of a parallel group run at the same time,
+// and that conditions and failures are handled for each of them
+func TestProcessHooksParallel(t *testing.T) {
+	assert := asrt.New(t)
+	require := rqr.New(t)
+
+	app := &DdevApp{Name: "parallelhooks", AppRoot: t.TempDir(), Type: "php", FailOnHookFail: true}
+	require.NoError(os.MkdirAll(app.AppConfDir(), 0755))
+	require.NoError(os.WriteFile(app.GetConfigPath("config.yaml"), []byte("type: php\n"), 0644))
+
+	// Each task waits for the file of the other, so they only both succeed if they run at the same time
+	waitFor := func(mine string, theirs string) string {
+		return "touch " + mine + " && for i in $(seq 100); do [ -f " + theirs + " ] && exit 0; sleep 0.1; done; exit 1"
+	}
+	app.Hooks = map[string][]YAMLTask{
+		"post-start": {
+			{"parallel": []any{
+				map[string]any{"exec-host": waitFor("a", "b"), "name": "a"},
+				map[string]any{"exec-host": waitFor("b", "a")},
+				map[string]any{"exec-host": "touch skipped", "when": map[string]any{"project_type": "drupal"}},
+			}},
+			{"exec-host": "echo run >> config-runs", "when": map[string]any{"config_changed": true}},
+		},
+	}
+	require.NoError(app.ProcessHooks("post-start"))
+	assert.FileExists(filepath.Join(app.AppRoot, "a"))
+	assert.FileExists(filepath.Join(app.AppRoot, "b"))
+	assert.NoFileExists(filepath.Join(app.AppRoot, "skipped"))
+
+	// The config_changed task doesn't run again until the configuration changes
+	require.NoError(app.ProcessHooks("post-start"))
+	require.NoError(os.WriteFile(app.GetConfigPath("config.yaml"), []byte("type: php\nphp_version: \"8.4\"\n"), 0644))
+	require.NoError(app.ProcessHooks("post-start"))
+	runs, err := os.ReadFile(filepath.Join(app.AppRoot, "config-runs"))
+	require.NoError(err)
+	assert.Equal(2, strings.Count(string(runs), "run"))
+
+	// A failing task fails the hook with FailOnHookFail, after the other tasks finished
+	app.Hooks = map[string][]YAMLTask{
+		"post-start": {
+			{"parallel": []any{
+				map[string]any{"exec-host": "echo broken && exit 3"},
+				map[string]any{"exec-host": "sleep 1 && touch finished"},
+			}},
+		},
+	}
+	err = app.ProcessHooks("post-start")
+	require.Error(err)
+	assert.Contains(err.Error(), "broken")
+	assert.FileExists(filepath.Join(app.AppRoot, "finished"))
+
+	app.FailOnHookFail = false
+	require.NoError(app.ProcessHooks("post-start"))
+
+	// Parallel groups can't be nested, and pre-start still only allows exec-host
+	app.Hooks = map[string][]YAMLTask{
+		"pre-start": {
+			{"parallel": []any{map[string]any{"exec": "true"}}},
+		},
+		"post-start": {
+			{"parallel": []any{map[string]any{"parallel": []any{}}}},
+		},
+	}
+	assert.Error(app.ProcessHooks("pre-start"))
+	assert.Error(app.ProcessHooks("post-start"))
+}
+
+// TestProcessHooksInputs tests that tasks with `inputs:` are skipped until their inputs change
+func TestProcessHooksInputs(t *testing.T) {
+	assert := asrt.New(t)
+	require := rqr.New(t)
+
+	app := &DdevApp{Name: "hookinputs", AppRoot: t.TempDir(), Type: "php"}
+	require.NoError(os.MkdirAll(filepath.Join(app.AppRoot, "assets"), 0755))
+	lockFile := filepath.Join(app.AppRoot, "composer.lock")
+	require.NoError(os.WriteFile(lockFile, []byte("v1"), 0644))
+	app.Hooks = map[string][]YAMLTask{
+		"post-start": {
+			{"exec-host": "echo run >> lock-runs", "inputs": []any{"composer.*", "assets"}},
+			{"exec-host": "echo run >> missing-runs", "inputs": "package-lock.json"},
+		},
+	}
+	runs := func(name string) int {
+		b, _ := os.ReadFile(filepath.Join(app.AppRoot, name))
+		return strings.Count(string(b), "run")
+	}
+
+	require.NoError(app.ProcessHooks("post-start"))
+	require.NoError(app.ProcessHooks("post-start"))
+	assert.Equal(1, runs("lock-runs"))
+	assert.Equal(1, runs("missing-runs"))
+
+	// A changed file, a new file in a directory and a file that appears are all changes
+	require.NoError(os.WriteFile(lockFile, []byte("v2"), 0644))
+	require.NoError(app.ProcessHooks("post-start"))
+	assert.Equal(2, runs("lock-runs"))
+	require.NoError(os.WriteFile(filepath.Join(app.AppRoot, "assets", "app.js"), []byte("js"), 0644))
+	require.NoError(app.ProcessHooks("post-start"))
+	assert.Equal(3, runs("lock-runs"))
+	require.NoError(os.WriteFile(filepath.Join(app.AppRoot, "package-lock.json"), []byte("{}"), 0644))
+	require.NoError(app.ProcessHooks("post-start"))
+	assert.Equal(3, runs("lock-runs"))
+	assert.Equal(2, runs("missing-runs"))
+
+	// --rerun-hooks runs them anyway
+	app.RerunHooks = true
+	require.NoError(app.ProcessHooks("post-start"))
+	assert.Equal(4, runs("lock-runs"))
+	assert.Equal(3, runs("missing-runs"))
+
+	// A task that failed runs again
+	app.RerunHooks = false
+	app.Hooks = map[string][]YAMLTask{
+		"post-start": {
+			{"exec-host": "echo run >> fail-runs && false", "inputs": "composer.lock"},
+		},
+	}
+	require.NoError(app.ProcessHooks("post-start"))
+	require.NoError(app.ProcessHooks("post-start"))
+	assert.Equal(2, runs("fail-runs"))
+}
+
+// TestValidateHookYAMLParallel tests the validation of parallel groups and conditions in config.yaml
+func TestValidateHookYAMLParallel(t *testing.T) {
+	assert := asrt.New(t)
+
+	valid := `
+hooks:
+  post-start:
+    - exec: npm ci
+      when:
+        file_exists: package.json
+    - wait-for:
+        tcp: solr:8983
+    - http: http://solr:8983/solr/admin/ping
+      status: 200
+    - parallel:
+        - exec: npm run build
+          name: npm
+        - composer: install
+          inputs: [composer.json, composer.lock]
+      when:
+        config_changed: true
+`
+	assert.NoError(validateHookYAML([]byte(valid)))
+
+	for _, invalid := range []string{
+		"hooks:\n  post-start:\n    - exec: true\n      when:\n        unknown: x\n",
+		"hooks:\n  post-start:\n    - parallel: npm ci\n",
+		"hooks:\n  post-start:\n    - parallel:\n        - unknown: x\n",
+		"hooks:\n  post-start:\n    - parallel:\n        - parallel:\n            - exec: true\n",
+		"hooks:\n  post-start:\n    - exec: true\n      inputs: []\n",
+		"hooks:\n  post-start:\n    - wait: solr:8983\n",
+		"hooks:\n  post-start:\n    - exec: true\n      inputs: \"[\"\n",
+		"hooks:\n  post-start:\n    - parallel:\n        - exec: true\n      inputs: composer.lock\n",
+	} {
+		assert.Error(validateHookYAML([]byte(invalid)), invalid)
+	}
+}
+
+// TestNewTaskWaitForAndHTTP tests the parsing of wait-for and http tasks
+func TestNewTaskWaitForAndHTTP(t *testing.T) {
+	assert := asrt.New(t)
+
+	app := &DdevApp{Name: "waitfor"}
+	testCases := map[string]struct {
+		ytask       YAMLTask
+		description string
+	}{
+		"tcp":           {YAMLTask{"wait-for": map[string]any{"tcp": "solr:8983", "timeout": 30}}, "Wait for solr:8983 to accept connections from the web container (timeout 30s)"},
+		"url":           {YAMLTask{"wait-for": map[string]any{"url": "https://waitfor.ddev.site/health"}}, "Wait for https://waitfor.ddev.site/health to respond (timeout 120s)"},
+		"service":       {YAMLTask{"wait-for": map[string]any{"service": "elasticsearch"}}, "Wait for service 'elasticsearch' to be healthy (timeout 120s)"},
+		"http":          {YAMLTask{"http": "http://solr:8983/solr/admin/ping"}, "HTTP GET http://solr:8983/solr/admin/ping from container/service 'web', expecting status 200"},
+		"http options":  {YAMLTask{"http": "http://localhost/cron", "method": "post", "status": 204, "service": "cron"}, "HTTP POST http://localhost/cron from container/service 'cron', expecting status 204"},
+		"no target":     {YAMLTask{"wait-for": map[string]any{"timeout": 30}}, ""},
+		"two targets":   {YAMLTask{"wait-for": map[string]any{"tcp": "solr:8983", "service": "solr"}}, ""},
+		"bad tcp":       {YAMLTask{"wait-for": map[string]any{"tcp": "solr"}}, ""},
+		"injected tcp":  {YAMLTask{"wait-for": map[string]any{"tcp": "solr';reboot;':8983"}}, ""},
+		"bad url":       {YAMLTask{"wait-for": map[string]any{"url": "solr:8983"}}, ""},
+		"bad timeout":   {YAMLTask{"wait-for": map[string]any{"service": "solr", "timeout": "1m"}}, ""},
+		"unknown key":   {YAMLTask{"wait-for": map[string]any{"port": 8983}}, ""},
+		"not a map":     {YAMLTask{"wait-for": "solr:8983"}, ""},
+		"bad http url":  {YAMLTask{"http": "localhost"}, ""},
+		"bad status":    {YAMLTask{"http": "http://localhost", "status": 1000}, ""},
+		"status string": {YAMLTask{"http": "http://localhost", "status": "200"}, ""},
+	}
+	for name, tc := range testCases {
+		task := NewTask(app, tc.ytask)
+		if tc.description == "" {
+			assert.Nil(task, name)
+			continue
+		}
+		if assert.NotNil(task, name) {
+			assert.Equal(tc.description, task.GetDescription(), name)
+		}
+	}
+}
+
+// TestWaitForURL tests that a wait-for url task waits until the URL responds
+func TestWaitForURL(t *testing.T) {
+	assert := asrt.New(t)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(waitForURL(server.URL, 10*time.Second))
+	assert.Equal(int32(3), requests.Load())
+
+	requests.Store(-100)
+	err := waitForURL(server.URL, time.Second)
+	assert.ErrorContains(err, "503 Service Unavailable")
+}
@@ -142,6 +142,14 @@ const ConfigInstructions = `
 # database container will be unusable. In the global configuration it is also
 # possible to omit ddev-router, but not here.
 
+# snapshot_retention:
+#   keep_last: 5     # keep the 5 most recent snapshots
+#   keep_daily: 7    # keep the most recent snapshot of each of the last 7 days
+#   max_size: 10GB   # cap the total size of kept snapshots
+# Database snapshots to keep when snapshots are pruned, which happens after
+# every 'ddev snapshot' (including the one taken by 'ddev delete') and with
+# 'ddev snapshot prune'. Overrides snapshot_retention in the global config.
+
 # performance_mode: "global"
 # DDEV offers performance optimization strategies to improve the filesystem
 # performance depending on your host system. Should be configured globally.
@@ -254,6 +262,23 @@ const ConfigInstructions = `
 #    http_port: 9998
 #    https_port: 9999
 
+# router_middlewares:
+#   ip_allow_list: ["127.0.0.1", "192.168.0.0/16"]
+#   redirect_regex:
+#     - regex: "^https://www\\.(.*)"
+#       replacement: "https://${1}"
+#       permanent: true
+#   basic_auth:
+#     users: ["admin:secret"]
+#   rate_limit:
+#     average: 100
+#     burst: 50
+#   headers:
+#     response:
+#       X-Robots-Tag: noindex
+# Traefik middlewares applied by ddev-router to all the HTTP and HTTPS routes
+# of the project. basic_auth passwords can be plain or htpasswd hashes.
+
 #web_extra_daemons:
 #- name: "http-1"
 #  command: "/var/www/html/node_modules/.bin/http-server -p 3000"
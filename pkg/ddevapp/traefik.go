@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -25,8 +28,19 @@ type TraefikRouting struct {
 		InternalServicePort string
 	}
 	HTTPS bool
+	// PathPrefixes limit the routes to these URL path prefixes, from HTTP_PATH_PREFIX
+	PathPrefixes []string
+	// StripPathPrefix removes the path prefix before the request reaches the service
+	StripPathPrefix bool
+	// Priority of the routes with path prefixes over the other routes of the same hostnames
+	Priority int
 }
 
+// pathPrefixPriority is above the default Traefik priority, the length of the rule,
+// so that routes with path prefixes win over the routes of the same hostnames without,
+// and longer prefixes win over shorter ones
+const pathPrefixPriority = 10000
+
 // detectAppRouting reviews the configured services and uses their
 // VIRTUAL_HOST and HTTP(S)_EXPOSE environment variables to set up routing
 // for the project
@@ -45,6 +59,14 @@ func detectAppRouting(app *DdevApp) ([]TraefikRouting, []string, error) {
 			continue
 		}
 		hostnames := strings.Split(virtualHost, ",")
+		var pathPrefix, stripPathPrefix string
+		if p, ok := service.Environment["HTTP_PATH_PREFIX"]; ok && p != nil {
+			pathPrefix = *p
+		}
+		if p, ok := service.Environment["HTTP_PATH_PREFIX_STRIP"]; ok && p != nil {
+			stripPathPrefix = *p
+		}
+		pathPrefixes, strip := processHTTPPathPrefix(serviceName, pathPrefix, stripPathPrefix)
 		if httpExposePointer, ok := service.Environment["HTTP_EXPOSE"]; ok && httpExposePointer != nil && *httpExposePointer != "" {
 			httpExpose := *httpExposePointer
 			util.Debug("HTTP_EXPOSE=%v for %s", httpExpose, serviceName)
@@ -52,7 +74,7 @@ func detectAppRouting(app *DdevApp) ([]TraefikRouting, []string, error) {
 			if err != nil {
 				return nil, nil, err
 			}
-			table = append(table, routeEntries...)
+			table = append(table, withPathPrefixes(routeEntries, pathPrefixes, strip)...)
 		}
 
 		if httpsExposePointer, ok := service.Environment["HTTPS_EXPOSE"]; ok && httpsExposePointer != nil && *httpsExposePointer != "" {
@@ -62,7 +84,7 @@ func detectAppRouting(app *DdevApp) ([]TraefikRouting, []string, error) {
 			if err != nil {
 				return nil, nil, err
 			}
-			table = append(table, routeEntries...)
+			table = append(table, withPathPrefixes(routeEntries, pathPrefixes, strip)...)
 		}
 	}
 
@@ -110,6 +132,202 @@ func processHTTPExpose(serviceName string, httpExpose string, isHTTPS bool, exte
 	return routingTable, nil
 }
 
+// processHTTPPathPrefix returns the URL path prefixes from the HTTP_PATH_PREFIX
+// environment variable of a service, a comma-separated list like "/api,/graphql",
+// and whether HTTP_PATH_PREFIX_STRIP asks to remove them before the request reaches the service
+func processHTTPPathPrefix(serviceName string, httpPathPrefix string, stripPathPrefix string) ([]string, bool) {
+	var prefixes []string
+	for prefix := range strings.SplitSeq(httpPathPrefix, ",") {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		if !strings.HasPrefix(prefix, "/") || strings.ContainsAny(prefix, "` \\") {
+			util.Warning("Skipping bad HTTP_PATH_PREFIX %s for service %s, it must start with / and can't contain spaces, backticks or backslashes", prefix, serviceName)
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	util.Debug("HTTP_PATH_PREFIX=%v HTTP_PATH_PREFIX_STRIP=%v for %s", prefixes, stripPathPrefix, serviceName)
+	strip, _ := strconv.ParseBool(stripPathPrefix)
+	return prefixes, len(prefixes) > 0 && strip
+}
+
+// withPathPrefixes limits routing table entries to URL path prefixes
+func withPathPrefixes(routes []TraefikRouting, prefixes []string, strip bool) []TraefikRouting {
+	if len(prefixes) == 0 {
+		return routes
+	}
+	longest := 0
+	for _, p := range prefixes {
+		longest = max(longest, len(p))
+	}
+	for i := range routes {
+		routes[i].PathPrefixes = prefixes
+		routes[i].StripPathPrefix = strip
+		routes[i].Priority = pathPrefixPriority + longest
+	}
+	return routes
+}
+
+// TraefikTCPRouting is a route of a TCP entrypoint of the router to a service of a project,
+// see router_tcp_routing in the global config
+type TraefikTCPRouting struct {
+	AppName string
+	// Hostname is the TLS SNI hostname of the route, like db.<project>.ddev.site
+	Hostname            string
+	ExternalPort        string
+	InternalServiceName string
+	InternalServicePort string
+	// NoTLS routes take all the connections to their router port, because their
+	// protocol can't be routed by TLS SNI, like MySQL and MariaDB which start TLS
+	// after the server greeting
+	NoTLS bool
+}
+
+// tcpExposePortPairRegex matches a port pair of TCP_EXPOSE, like 6379 or 3307:3306
+var tcpExposePortPairRegex = regexp.MustCompile(`^[0-9]+(:[0-9]+)?$`)
+
+// detectAppTCPRouting reviews the configured services and uses their TCP_EXPOSE
+// environment variable, and the database type for the db service, to set up
+// TCP routing for the project when router_tcp_routing is enabled.
+// MySQL and MariaDB are only routed with TCP_EXPOSE, which gives them their own port.
+func detectAppTCPRouting(app *DdevApp) []TraefikTCPRouting {
+	var table []TraefikTCPRouting
+	if !globalconfig.DdevGlobalConfig.RouterTCPRouting || app.ComposeYaml == nil || app.ComposeYaml.Services == nil {
+		return table
+	}
+	serviceNames := make([]string, 0, len(app.ComposeYaml.Services))
+	for serviceName := range app.ComposeYaml.Services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	for _, serviceName := range serviceNames {
+		service := app.ComposeYaml.Services[serviceName]
+		var tcpExpose string
+		if tcpExposePointer, ok := service.Environment["TCP_EXPOSE"]; ok && tcpExposePointer != nil {
+			tcpExpose = *tcpExposePointer
+		}
+		noTLS := false
+		if serviceName == "db" {
+			switch app.Database.Type {
+			case nodeps.Postgres:
+				// Traefik handles the STARTTLS negotiation of PostgreSQL
+				if tcpExpose == "" {
+					tcpExpose = "5432"
+				}
+			default:
+				noTLS = true
+			}
+		}
+		if tcpExpose == "" {
+			continue
+		}
+		util.Debug("TCP_EXPOSE=%v for %s", tcpExpose, serviceName)
+		for portPair := range strings.SplitSeq(tcpExpose, ",") {
+			if !tcpExposePortPairRegex.MatchString(portPair) {
+				util.Warning("Skipping bad TCP_EXPOSE port pair spec %s for service %s", portPair, serviceName)
+				continue
+			}
+			externalPort, internalPort, found := strings.Cut(portPair, ":")
+			if !found {
+				internalPort = externalPort
+			}
+			table = append(table, TraefikTCPRouting{
+				AppName:             app.Name,
+				Hostname:            serviceName + "." + app.GetHostname(),
+				ExternalPort:        externalPort,
+				InternalServiceName: serviceName,
+				InternalServicePort: internalPort,
+				NoTLS:               noTLS,
+			})
+		}
+	}
+	return table
+}
+
+// collectTCPRouting returns the TCP routes of all active projects, skipping
+// the ones whose router port is already used by HTTP routes in httpPorts.
+// A route not using TLS takes its whole router port, so when several routes
+// share the port of one of them, none of them is kept, rather than sending
+// the clients of one project to the database of another.
+func collectTCPRouting(activeApps []*DdevApp, httpPorts []string) []TraefikTCPRouting {
+	apps := slices.Clone(activeApps)
+	slices.SortFunc(apps, func(a, b *DdevApp) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var routes []TraefikTCPRouting
+	portRoutes := map[string][]TraefikTCPRouting{}
+	for _, app := range apps {
+		for _, r := range detectAppTCPRouting(app) {
+			if slices.Contains(httpPorts, r.ExternalPort) {
+				util.Warning("Skipping TCP routing of %s on port %s, which is already used for HTTP", r.Hostname, r.ExternalPort)
+				continue
+			}
+			portRoutes[r.ExternalPort] = append(portRoutes[r.ExternalPort], r)
+			routes = append(routes, r)
+		}
+	}
+
+	var table []TraefikTCPRouting
+	warned := map[string]bool{}
+	for _, r := range routes {
+		shared := portRoutes[r.ExternalPort]
+		if len(shared) > 1 && slices.ContainsFunc(shared, func(o TraefikTCPRouting) bool { return o.NoTLS }) {
+			if !warned[r.ExternalPort] {
+				var hostnames []string
+				for _, o := range shared {
+					hostnames = append(hostnames, o.Hostname)
+				}
+				util.Warning("Skipping TCP routing on port %s, which is used by %s; MySQL and MariaDB can't share a port, set TCP_EXPOSE of their db service to different ports", r.ExternalPort, strings.Join(hostnames, ", "))
+				warned[r.ExternalPort] = true
+			}
+			continue
+		}
+		table = append(table, r)
+	}
+	return table
+}
+
+// determineRouterTCPPorts returns the ports of the TCP entrypoints of the router
+func determineRouterTCPPorts(activeApps []*DdevApp, httpPorts []string) []string {
+	var ports []string
+	for _, r := range collectTCPRouting(activeApps, httpPorts) {
+		if !slices.Contains(ports, r.ExternalPort) {
+			ports = append(ports, r.ExternalPort)
+		}
+	}
+	slices.Sort(ports)
+	return ports
+}
+
+// GetRouterTCPURLs returns the host:port connection strings of the TCP routes
+// of the project through the router, by service, for the routes the router
+// serves with the currently active projects
+func (app *DdevApp) GetRouterTCPURLs() map[string]string {
+	if !globalconfig.DdevGlobalConfig.RouterTCPRouting {
+		return map[string]string{}
+	}
+	activeApps := GetActiveProjects()
+	return app.routerTCPURLs(collectTCPRouting(activeApps, determineRouterPorts(activeApps)))
+}
+
+// routerTCPURLs returns the host:port connection strings of the routes of the project in table, by service
+func (app *DdevApp) routerTCPURLs(table []TraefikTCPRouting) map[string]string {
+	urls := map[string]string{}
+	for _, r := range table {
+		if r.AppName != app.Name {
+			continue
+		}
+		if _, ok := urls[r.InternalServiceName]; !ok {
+			urls[r.InternalServiceName] = r.Hostname + ":" + r.ExternalPort
+		}
+	}
+	return urls
+}
+
 // PushGlobalTraefikConfig pushes the config into ddev-global-cache
 func PushGlobalTraefikConfig(activeApps []*DdevApp) error {
 	globalTraefikDir := filepath.Join(globalconfig.GetGlobalDdevDir(), "traefik")
@@ -200,14 +418,20 @@ func PushGlobalTraefikConfig(activeApps []*DdevApp) error {
 		PrimaryHostname    string
 		TargetCertsPath    string
 		RouterPorts        []string
+		RouterTCPPorts     []string
+		TCPRoutingTable    []TraefikTCPRouting
 		UseLetsEncrypt     bool
 		LetsEncryptEmail   string
 		TraefikMonitorPort string
 		HasCAROOT          bool
 	}
+	routerPorts := determineRouterPorts(activeApps)
+	tcpRoutingTable := collectTCPRouting(activeApps, routerPorts)
 	templateData := traefikData{
 		TargetCertsPath:    inContainerTargetCertsPath,
-		RouterPorts:        determineRouterPorts(activeApps),
+		RouterPorts:        routerPorts,
+		RouterTCPPorts:     determineRouterTCPPorts(activeApps, routerPorts),
+		TCPRoutingTable:    tcpRoutingTable,
 		UseLetsEncrypt:     globalconfig.DdevGlobalConfig.UseLetsEncrypt,
 		LetsEncryptEmail:   globalconfig.DdevGlobalConfig.LetsEncryptEmail,
 		TraefikMonitorPort: globalconfig.DdevGlobalConfig.TraefikMonitorPort,
@@ -269,6 +493,25 @@ func PushGlobalTraefikConfig(activeApps []*DdevApp) error {
 	expectedConfigs := map[string]bool{"default_config.yaml": true, "README.txt": true}
 	expectedCerts := map[string]bool{"README.txt": true}
 
+	// TCP routing of all active projects, see router_tcp_routing in the global config
+	if len(tcpRoutingTable) > 0 {
+		tcpConfigPath := filepath.Join(globalSourceConfigDir, "tcp_routing.yaml")
+		t, err = template.New("traefik_tcp_config_template.yaml").Funcs(getTemplateFuncMap()).ParseFS(bundledAssets, "traefik_tcp_config_template.yaml")
+		if err != nil {
+			return fmt.Errorf("could not create template from traefik_tcp_config_template.yaml: %v", err)
+		}
+		tcpConfig, err := os.Create(tcpConfigPath)
+		if err != nil {
+			return fmt.Errorf("failed to create Traefik tcp_routing.yaml file: %v", err)
+		}
+		err = t.Execute(tcpConfig, templateData)
+		_ = tcpConfig.Close()
+		if err != nil {
+			return fmt.Errorf("could not parse traefik_tcp_config_template.yaml with templatedate='%v':: %v", templateData, err)
+		}
+		expectedConfigs["tcp_routing.yaml"] = true
+	}
+
 	// Add default certs to expected list if not using Let's Encrypt
 	if !globalconfig.DdevGlobalConfig.UseLetsEncrypt && globalconfig.GetCAROOT() != "" {
 		expectedCerts["default_cert.crt"] = true
@@ -481,6 +724,13 @@ func configureTraefikForApp(app *DdevApp) error {
 	if err != nil {
 		return err
 	}
+	// The TCP routes using TLS SNI are served with the project certificate too
+	for _, r := range detectAppTCPRouting(app) {
+		if !r.NoTLS {
+			hostnames = append(hostnames, r.Hostname)
+		}
+	}
+	hostnames = util.SliceToUniqueSlice(&hostnames)
 	projectTraefikDir := app.GetConfigPath("traefik")
 	err = os.MkdirAll(projectTraefikDir, 0755)
 	if err != nil {
@@ -543,23 +793,33 @@ func configureTraefikForApp(app *DdevApp) error {
 		}
 	}
 
+	projectTraefikYamlFile := filepath.Join(projectSourceConfigDir, app.Name+".yaml")
+	middlewareNames, middlewareConfig, err := app.RouterMiddlewares.traefikMiddlewares(app.Name, projectTraefikYamlFile)
+	if err != nil {
+		return fmt.Errorf("failed to configure router_middlewares: %v", err)
+	}
+
 	type traefikData struct {
-		App             *DdevApp
-		Hostnames       []string
-		PrimaryHostname string
-		TargetCertsPath string
-		RoutingTable    []TraefikRouting
-		UseLetsEncrypt  bool
-		HasCAROOT       bool
+		App                     *DdevApp
+		Hostnames               []string
+		PrimaryHostname         string
+		TargetCertsPath         string
+		RoutingTable            []TraefikRouting
+		RouterMiddlewares       []string
+		RouterMiddlewaresConfig string
+		UseLetsEncrypt          bool
+		HasCAROOT               bool
 	}
 	templateData := traefikData{
-		App:             app,
-		Hostnames:       []string{},
-		PrimaryHostname: app.GetHostname(),
-		TargetCertsPath: inContainerTargetCertsPath,
-		RoutingTable:    routingTable,
-		UseLetsEncrypt:  globalconfig.DdevGlobalConfig.UseLetsEncrypt,
-		HasCAROOT:       globalconfig.GetCAROOT() != "",
+		App:                     app,
+		Hostnames:               []string{},
+		PrimaryHostname:         app.GetHostname(),
+		TargetCertsPath:         inContainerTargetCertsPath,
+		RoutingTable:            routingTable,
+		RouterMiddlewares:       middlewareNames,
+		RouterMiddlewaresConfig: middlewareConfig,
+		UseLetsEncrypt:          globalconfig.DdevGlobalConfig.UseLetsEncrypt,
+		HasCAROOT:               globalconfig.GetCAROOT() != "",
 	}
 
 	// Convert externalHostnames wildcards like `*.<anything>` to `[a-zA-Z0-9-]+.wild.ddev.site`
@@ -572,7 +832,6 @@ func configureTraefikForApp(app *DdevApp) error {
 		}
 	}
 
-	projectTraefikYamlFile := filepath.Join(projectSourceConfigDir, app.Name+".yaml")
 	// Check to see if file can be safely overwritten (has signature, is empty, or doesn't exist)
 	err = fileutil.CheckSignatureOrNoFile(projectTraefikYamlFile, nodeps.DdevFileSignature)
 	sigExists = (err == nil)
@@ -0,0 +1,253 @@
+package ddevapp
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+
+	composeTypes "github.com/compose-spec/compose-go/v2/types"
+	"github.com/ddev/ddev/pkg/globalconfig"
+	"github.com/ddev/ddev/pkg/nodeps"
+	asrt "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestProcessHTTPPathPrefix checks the parsing of HTTP_PATH_PREFIX and HTTP_PATH_PREFIX_STRIP
+func TestProcessHTTPPathPrefix(t *testing.T) {
+	assert := asrt.New(t)
+
+	prefixes, strip := processHTTPPathPrefix("node", "", "true")
+	assert.Empty(prefixes)
+	assert.False(strip)
+
+	prefixes, strip = processHTTPPathPrefix("node", "/api, /graphql,,api,/a b", "true")
+	assert.Equal([]string{"/api", "/graphql"}, prefixes)
+	assert.True(strip)
+
+	prefixes, strip = processHTTPPathPrefix("node", "/api", "no")
+	assert.Equal([]string{"/api"}, prefixes)
+	assert.False(strip)
+}
+
+// TestTraefikPathPrefixConfig checks the routers generated for a service with HTTP_PATH_PREFIX
+// get a PathPrefix rule with priority over the web routers, and strip the prefix if asked,
+// while the routers of the web service stay the same
+func TestTraefikPathPrefixConfig(t *testing.T) {
+	assert := asrt.New(t)
+	origUseLetsEncrypt := globalconfig.DdevGlobalConfig.UseLetsEncrypt
+	globalconfig.DdevGlobalConfig.UseLetsEncrypt = false
+	t.Cleanup(func() {
+		globalconfig.DdevGlobalConfig.UseLetsEncrypt = origUseLetsEncrypt
+	})
+
+	env := func(kv ...string) composeTypes.MappingWithEquals {
+		m := composeTypes.MappingWithEquals{}
+		for i := 0; i < len(kv); i += 2 {
+			m[kv[i]] = &kv[i+1]
+		}
+		return m
+	}
+	app := &DdevApp{
+		Name:       "pathprefix",
+		AppRoot:    t.TempDir(),
+		ProjectTLD: "ddev.site",
+		ComposeYaml: &composeTypes.Project{Services: composeTypes.Services{
+			"web": {Environment: env("VIRTUAL_HOST", "pathprefix.ddev.site", "HTTP_EXPOSE", "80:80", "HTTPS_EXPOSE", "443:80")},
+			"node": {Environment: env("VIRTUAL_HOST", "pathprefix.ddev.site", "HTTP_EXPOSE", "80:3000", "HTTPS_EXPOSE", "443:3000",
+				"HTTP_PATH_PREFIX", "/api,/graphql", "HTTP_PATH_PREFIX_STRIP", "true")},
+		}},
+	}
+	require.NoError(t, os.MkdirAll(app.GetConfigPath(""), 0755))
+	require.NoError(t, configureTraefikForApp(app))
+
+	content, err := os.ReadFile(filepath.Join(app.GetConfigPath("traefik/config"), "pathprefix.yaml"))
+	require.NoError(t, err)
+	var config struct {
+		HTTP struct {
+			Routers     map[string]map[string]any `yaml:"routers"`
+			Middlewares map[string]map[string]any `yaml:"middlewares"`
+		} `yaml:"http"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &config), string(content))
+
+	for _, name := range []string{"pathprefix-node-3000-http", "pathprefix-node-3000-https"} {
+		router := config.HTTP.Routers[name]
+		require.NotNil(t, router, name)
+		assert.Equal("(HostRegexp(`^pathprefix\\.ddev\\.site$`)) && (PathPrefix(`/api`) || PathPrefix(`/graphql`))", router["rule"])
+		assert.Equal(pathPrefixPriority+len("/graphql"), router["priority"])
+		assert.Equal([]any{"pathprefix-node-stripPrefix"}, router["middlewares"])
+	}
+	for _, name := range []string{"pathprefix-web-80-http", "pathprefix-web-80-https"} {
+		router := config.HTTP.Routers[name]
+		require.NotNil(t, router, name)
+		assert.Equal("HostRegexp(`^pathprefix\\.ddev\\.site$`)", router["rule"])
+		assert.NotContains(router, "priority")
+		assert.NotContains(router, "middlewares")
+	}
+	assert.Equal(map[string]any{"prefixes": []any{"/api", "/graphql"}}, config.HTTP.Middlewares["pathprefix-node-stripPrefix"]["stripPrefix"])
+}
+
+// TestTraefikTCPRouting checks the TCP routes of the db and TCP_EXPOSE services,
+// that MySQL routes take their whole port, and the generated Traefik config
+func TestTraefikTCPRouting(t *testing.T) {
+	assert := asrt.New(t)
+	origTCPRouting := globalconfig.DdevGlobalConfig.RouterTCPRouting
+	t.Cleanup(func() {
+		globalconfig.DdevGlobalConfig.RouterTCPRouting = origTCPRouting
+	})
+
+	redisExpose := "6379"
+	newApp := func(name, dbType string, dbExpose string) *DdevApp {
+		app := &DdevApp{
+			Name:       name,
+			ProjectTLD: "ddev.site",
+			Database:   DatabaseDesc{Type: dbType},
+			ComposeYaml: &composeTypes.Project{Services: composeTypes.Services{
+				"web":   {},
+				"db":    {},
+				"redis": {Environment: composeTypes.MappingWithEquals{"TCP_EXPOSE": &redisExpose}},
+			}},
+		}
+		if dbExpose != "" {
+			app.ComposeYaml.Services["db"] = composeTypes.ServiceConfig{Environment: composeTypes.MappingWithEquals{"TCP_EXPOSE": &dbExpose}}
+		}
+		return app
+	}
+	// MySQL and MariaDB are only routed on the port of their TCP_EXPOSE
+	apps := []*DdevApp{newApp("zmysql", nodeps.MySQL, "3306"), newApp("pg", nodeps.Postgres, ""), newApp("nomaria", nodeps.MariaDB, "")}
+
+	globalconfig.DdevGlobalConfig.RouterTCPRouting = false
+	assert.Empty(collectTCPRouting(apps, nil))
+
+	globalconfig.DdevGlobalConfig.RouterTCPRouting = true
+	table := collectTCPRouting(apps, []string{"80", "443", "6379"})
+	var routes []string
+	for _, r := range table {
+		routes = append(routes, r.Hostname+":"+r.ExternalPort)
+	}
+	// redis conflicts with an HTTP port
+	assert.Equal([]string{"db.pg.ddev.site:5432", "db.zmysql.ddev.site:3306"}, routes)
+	assert.False(table[0].NoTLS)
+	assert.True(table[1].NoTLS)
+	assert.Equal([]string{"3306", "5432"}, determineRouterTCPPorts(apps, []string{"80", "443", "6379"}))
+	assert.Equal(map[string]string{"db": "db.zmysql.ddev.site:3306"}, apps[0].routerTCPURLs(table))
+	assert.Empty(apps[2].routerTCPURLs(table))
+
+	// A project using the port of a MySQL route doesn't get it, and doesn't take it
+	// from the project that has it either, whatever their names
+	conflicting := append(slices.Clone(apps), newApp("amaria", nodeps.MariaDB, "3306"))
+	conflictTable := collectTCPRouting(conflicting, []string{"80", "443"})
+	assert.Equal(map[string]string{"redis": "redis.zmysql.ddev.site:6379"}, conflicting[0].routerTCPURLs(conflictTable))
+	assert.Equal(map[string]string{"redis": "redis.amaria.ddev.site:6379"}, conflicting[3].routerTCPURLs(conflictTable))
+	assert.Equal(map[string]string{"db": "db.pg.ddev.site:5432", "redis": "redis.pg.ddev.site:6379"}, conflicting[1].routerTCPURLs(conflictTable))
+
+	tmpl, err := template.New("traefik_tcp_config_template.yaml").Funcs(getTemplateFuncMap()).ParseFS(bundledAssets, "traefik_tcp_config_template.yaml")
+	require.NoError(t, err)
+	var b strings.Builder
+	require.NoError(t, tmpl.Execute(&b, struct {
+		TCPRoutingTable []TraefikTCPRouting
+		UseLetsEncrypt  bool
+	}{TCPRoutingTable: table}))
+	var config struct {
+		TCP struct {
+			Routers  map[string]map[string]any `yaml:"routers"`
+			Services map[string]map[string]any `yaml:"services"`
+		} `yaml:"tcp"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(b.String()), &config), b.String())
+
+	mysqlRouter := config.TCP.Routers["zmysql-db-3306-tcp"]
+	require.NotNil(t, mysqlRouter)
+	assert.Equal("HostSNI(`*`)", mysqlRouter["rule"])
+	assert.Equal([]any{"tcp-3306"}, mysqlRouter["entrypoints"])
+	assert.NotContains(mysqlRouter, "tls")
+
+	pgRouter := config.TCP.Routers["pg-db-5432-tcp"]
+	require.NotNil(t, pgRouter)
+	assert.Equal("HostSNI(`db.pg.ddev.site`)", pgRouter["rule"])
+	assert.Equal(map[string]any{}, pgRouter["tls"])
+	assert.Equal("pg-db-5432-tcp", pgRouter["service"])
+	assert.Equal(map[string]any{"servers": []any{map[string]any{"address": "ddev-pg-db:5432"}}}, config.TCP.Services["pg-db-5432-tcp"]["loadBalancer"])
+}
+
+// TestTraefikRouterMiddlewares checks router_middlewares are validated, defined in the
+// project Traefik config and applied to the HTTP and HTTPS routers, before stripPrefix
+func TestTraefikRouterMiddlewares(t *testing.T) {
+	assert := asrt.New(t)
+	origUseLetsEncrypt := globalconfig.DdevGlobalConfig.UseLetsEncrypt
+	globalconfig.DdevGlobalConfig.UseLetsEncrypt = false
+	t.Cleanup(func() {
+		globalconfig.DdevGlobalConfig.UseLetsEncrypt = origUseLetsEncrypt
+	})
+
+	assert.Error((&RouterMiddlewares{IPAllowList: []string{"192.168.0.0/33"}}).Validate())
+	assert.Error((&RouterMiddlewares{RedirectRegex: []RouterRedirectRegex{{Regex: "^(old"}}}).Validate())
+	assert.Error((&RouterMiddlewares{BasicAuth: &RouterBasicAuth{Users: []string{"admin"}}}).Validate())
+	assert.Error((&RouterMiddlewares{RateLimit: &RouterRateLimit{}}).Validate())
+
+	hashed := "$apr1$xyz$abc"
+	middlewares := &RouterMiddlewares{
+		IPAllowList:   []string{"127.0.0.1", "192.168.0.0/16"},
+		RedirectRegex: []RouterRedirectRegex{{Regex: "^https://www\\.(.*)", Replacement: "https://${1}", Permanent: true}},
+		BasicAuth:     &RouterBasicAuth{Users: []string{"admin:secret", "hashed:" + hashed}},
+		Headers:       &RouterHeaders{Response: map[string]string{"X-Robots-Tag": "noindex"}},
+	}
+	require.NoError(t, middlewares.Validate())
+
+	httpExpose, httpsExpose, virtualHost, prefix, strip := "80:3000", "443:3000", "middlewares.ddev.site", "/api", "true"
+	app := &DdevApp{
+		Name:              "middlewares",
+		AppRoot:           t.TempDir(),
+		ProjectTLD:        "ddev.site",
+		RouterMiddlewares: middlewares,
+		ComposeYaml: &composeTypes.Project{Services: composeTypes.Services{
+			"node": {Environment: composeTypes.MappingWithEquals{"VIRTUAL_HOST": &virtualHost, "HTTP_EXPOSE": &httpExpose, "HTTPS_EXPOSE": &httpsExpose,
+				"HTTP_PATH_PREFIX": &prefix, "HTTP_PATH_PREFIX_STRIP": &strip}},
+		}},
+	}
+	require.NoError(t, os.MkdirAll(app.GetConfigPath(""), 0755))
+	require.NoError(t, configureTraefikForApp(app))
+
+	content, err := os.ReadFile(filepath.Join(app.GetConfigPath("traefik/config"), "middlewares.yaml"))
+	require.NoError(t, err)
+	var config struct {
+		HTTP struct {
+			Routers     map[string]map[string]any `yaml:"routers"`
+			Middlewares map[string]map[string]any `yaml:"middlewares"`
+		} `yaml:"http"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &config), string(content))
+
+	for _, name := range []string{"middlewares-node-3000-http", "middlewares-node-3000-https"} {
+		router := config.HTTP.Routers[name]
+		require.NotNil(t, router, name)
+		assert.Equal([]any{"middlewares-ipAllowList", "middlewares-redirectRegex-1", "middlewares-basicAuth", "middlewares-headers", "middlewares-node-stripPrefix"}, router["middlewares"])
+	}
+	assert.Equal(map[string]any{"sourceRange": []any{"127.0.0.1", "192.168.0.0/16"}}, config.HTTP.Middlewares["middlewares-ipAllowList"]["ipAllowList"])
+	assert.Equal(map[string]any{"regex": "^https://www\\.(.*)", "replacement": "https://${1}", "permanent": true}, config.HTTP.Middlewares["middlewares-redirectRegex-1"]["redirectRegex"])
+	assert.Equal(map[string]any{"customResponseHeaders": map[string]any{"X-Robots-Tag": "noindex"}}, config.HTTP.Middlewares["middlewares-headers"]["headers"])
+	assert.Contains(config.HTTP.Middlewares, "middlewares-redirectHttps")
+
+	users := config.HTTP.Middlewares["middlewares-basicAuth"]["basicAuth"].(map[string]any)["users"].([]any)
+	require.Len(t, users, 2)
+	user, password, _ := strings.Cut(users[0].(string), ":")
+	assert.Equal("admin", user)
+	assert.NoError(bcrypt.CompareHashAndPassword([]byte(password), []byte("secret")))
+	assert.Equal("hashed:"+hashed, users[1])
+
+	// The config doesn't change on every start, only when a password changes
+	require.NoError(t, configureTraefikForApp(app))
+	again, err := os.ReadFile(filepath.Join(app.GetConfigPath("traefik/config"), "middlewares.yaml"))
+	require.NoError(t, err)
+	assert.Equal(string(content), string(again))
+	middlewares.BasicAuth.Users[0] = "admin:changed"
+	require.NoError(t, configureTraefikForApp(app))
+	again, err = os.ReadFile(filepath.Join(app.GetConfigPath("traefik/config"), "middlewares.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(string(again), password)
+}
@@ -13,6 +13,11 @@ import (
 // which of its image generations a pinned webimage/dbimage came from.
 const DdevImageTagLabel = "com.ddev.image-tag"
 
+// DdevIncrementalSnapshotsLabel is the image label of the ddev-dbserver images
+// whose entrypoint restores a base snapshot followed by its incremental snapshots.
+// The entrypoint of older images only takes one snapshot.
+const DdevIncrementalSnapshotsLabel = "com.ddev.incremental-snapshots"
+
 // GetWebImage returns the correctly formatted web image:tag reference
 func GetWebImage() string {
 	fullWebImg := versionconstants.WebImg
@@ -349,6 +349,8 @@ func ContainersWait(waittime int, labels map[string]string, filterServices ...st
 	lastStatus := ""
 	startTime := time.Now()
 	lastLogTime := startTime
+	// lastHealth is the health of each container, by ID, for reporting transitions with --events
+	lastHealth := map[string]string{}
 
 	for {
 		select {
@@ -383,6 +385,10 @@ func ContainersWait(waittime int, labels map[string]string, filterServices ...st
 				}
 				totalCount++
 				health, logOutput := GetContainerHealth(&c)
+				if health != lastHealth[c.ID] {
+					lastHealth[c.ID] = health
+					emitContainerHealthEvent(&c, health)
+				}
 
 				switch health {
 				case string(container.Healthy):
@@ -438,6 +444,24 @@ func ContainersWait(waittime int, labels map[string]string, filterServices ...st
 	return fmt.Errorf("inappropriate break out of for loop in ContainerWait() waiting for container labels %v", labels)
 }
 
+// emitContainerHealthEvent emits a --events container_health event for a container
+func emitContainerHealthEvent(c *container.Summary, health string) {
+	if !output.EventsEnabled() {
+		return
+	}
+	name := ""
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+	output.EmitEvent(output.Event{
+		Type:    output.EventContainerHealth,
+		Project: c.Labels["com.ddev.site-name"],
+		Service: c.Labels["com.docker.compose.service"],
+		Name:    name,
+		Status:  health,
+	})
+}
+
 // getSuggestedCommandForContainerLog returns a command that can be used to find out what is wrong with a container
 func getSuggestedCommandForContainerLog(c *container.Summary, timeout int) (string, string) {
 	var suggestedCommands []string
@@ -71,6 +71,9 @@ func NewComposeServiceWithStreams(stdout, stderr io.Writer) (context.Context, ap
 		// top-level resource instead.
 		ep = newQuietEventProcessor(output.UserErr.Out)
 	}
+	if output.EventsEnabled() {
+		ep = newImagePullEventProcessor(ep)
+	}
 	opts := []compose.Option{
 		compose.WithOutputStream(stdout),
 		compose.WithErrorStream(stderr),
@@ -116,6 +119,78 @@ func (q *quietEventProcessor) On(events ...api.Resource) {
 
 func (q *quietEventProcessor) Done(_ string, _ bool) {}
 
+// imagePullEventProcessor passes compose events on to another api.EventProcessor
+// and emits the progress of image pulls as --events image_pull events
+type imagePullEventProcessor struct {
+	api.EventProcessor
+	mu sync.Mutex
+	// layers has the progress of the layers of each image being pulled, by compose resource ID
+	layers map[string]map[string]int
+	// reported is the last progress reported for each image being pulled
+	reported map[string]int
+	emit     func(output.Event)
+}
+
+func newImagePullEventProcessor(ep api.EventProcessor) *imagePullEventProcessor {
+	return &imagePullEventProcessor{EventProcessor: ep, layers: map[string]map[string]int{}, reported: map[string]int{}, emit: output.EmitEvent}
+}
+
+func (p *imagePullEventProcessor) On(events ...api.Resource) {
+	p.EventProcessor.On(events...)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range events {
+		// Compose reports image pulls as "Image <name>" resources, with the layers as sub-resources
+		if e.ParentID != "" {
+			layers, ok := p.layers[e.ParentID]
+			if !ok {
+				continue
+			}
+			layers[e.ID] = max(layers[e.ID], e.Percent)
+			if e.Status == api.Done {
+				layers[e.ID] = 100
+			}
+			total := 0
+			for _, percent := range layers {
+				total += percent
+			}
+			percent := total / len(layers)
+			if percent >= p.reported[e.ParentID]+5 && percent < 100 {
+				p.reported[e.ParentID] = percent
+				p.emit(output.Event{Type: output.EventImagePull, Image: strings.TrimPrefix(e.ParentID, "Image "), Status: "pulling", Percent: &percent})
+			}
+			continue
+		}
+		image, isImage := strings.CutPrefix(e.ID, "Image ")
+		if !isImage {
+			continue
+		}
+		if e.Status == api.Working && e.Text == api.StatusPulling {
+			if _, ok := p.layers[e.ID]; !ok {
+				p.layers[e.ID] = map[string]int{}
+				percent := 0
+				p.emit(output.Event{Type: output.EventImagePull, Image: image, Status: "pulling", Percent: &percent})
+			}
+			continue
+		}
+		// Other "Image" resources, like built images, aren't pulls
+		if _, ok := p.layers[e.ID]; !ok {
+			continue
+		}
+		switch e.Status {
+		case api.Done:
+			percent := 100
+			p.emit(output.Event{Type: output.EventImagePull, Image: image, Status: "pulled", Percent: &percent})
+		case api.Error, api.Warning:
+			p.emit(output.Event{Type: output.EventImagePull, Image: image, Status: "failed", Error: strings.TrimSpace(e.Text + " " + e.Details)})
+		default:
+			continue
+		}
+		delete(p.layers, e.ID)
+		delete(p.reported, e.ID)
+	}
+}
+
 // ExitCodeToError converts the (exitCode, err) return of api.Compose.Exec /
 // RunOneOffContainer into a single error usable with errors.As(&cli.StatusError{}).
 //
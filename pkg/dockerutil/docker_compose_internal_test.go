@@ -2,10 +2,12 @@ package dockerutil
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ddev/ddev/pkg/output"
 	"github.com/docker/compose/v5/cmd/display"
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/sirupsen/logrus"
@@ -174,3 +176,50 @@ func TestSetupLogrusSuppressionIdempotent(t *testing.T) {
 	require.Same(t, first, second,
 		"second install must be a no-op; otherwise we'd double-wrap and lose the original formatter")
 }
+
+// TestImagePullEventProcessor verifies that image pulls are reported as
+// image_pull events with increasing progress, and that other events are
+// passed on to the wrapped processor.
+func TestImagePullEventProcessor(t *testing.T) {
+	var buf bytes.Buffer
+	var events []output.Event
+	ep := newImagePullEventProcessor(newQuietEventProcessor(&buf))
+	ep.emit = func(e output.Event) {
+		events = append(events, e)
+	}
+
+	image := "Image ddev/ddev-webserver:latest"
+	ep.On(
+		api.Resource{ID: image, Status: api.Working, Text: api.StatusPulling},
+		api.Resource{ID: "layer1", ParentID: image, Status: api.Working, Percent: 40},
+		api.Resource{ID: "layer2", ParentID: image, Status: api.Working, Percent: 2},
+		// Less than 5% more isn't reported
+		api.Resource{ID: "layer2", ParentID: image, Status: api.Working, Percent: 4},
+		api.Resource{ID: "layer2", ParentID: image, Status: api.Done},
+		api.Resource{ID: image, Status: api.Done, Text: api.StatusPulled},
+		// A built image isn't a pull
+		api.Resource{ID: "Image ddev/ddev-webserver-built", Status: api.Done, Text: "Built"},
+		api.Resource{ID: "Image ddev/ddev-dbserver:latest", Status: api.Working, Text: api.StatusPulling},
+		api.Resource{ID: "Image ddev/ddev-dbserver:latest", Status: api.Error, Text: "Error", Details: "pull access denied"},
+	)
+
+	var got []string
+	for _, e := range events {
+		require.Equal(t, output.EventImagePull, e.Type)
+		s := e.Image + " " + e.Status
+		if e.Percent != nil {
+			s += fmt.Sprintf(" %d", *e.Percent)
+		}
+		got = append(got, s)
+	}
+	require.Equal(t, []string{
+		"ddev/ddev-webserver:latest pulling 0",
+		"ddev/ddev-webserver:latest pulling 40",
+		"ddev/ddev-webserver:latest pulling 70",
+		"ddev/ddev-webserver:latest pulled 100",
+		"ddev/ddev-dbserver:latest pulling 0",
+		"ddev/ddev-dbserver:latest failed",
+	}, got)
+	require.Contains(t, events[len(events)-1].Error, "pull access denied")
+	require.Contains(t, buf.String(), "Image ddev/ddev-webserver:latest Pulled")
+}
@@ -2,6 +2,7 @@ package fileutil
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
@@ -54,3 +55,18 @@ func FileHash(filePath string, optionalExtraString string) (string, error) {
 
 	return fmt.Sprintf("%x", sum), nil
 }
+
+// FileSHA256 returns the hex-encoded SHA-256 checksum of the contents of filePath
+func FileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer util.CheckClose(file)
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
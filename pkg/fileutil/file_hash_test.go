@@ -67,6 +67,20 @@ func TestFileHash(t *testing.T) {
 	}
 }
 
+// TestFileSHA256 checks FileSHA256 against a known checksum
+func TestFileSHA256(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "TestFileSHA256")
+	err := os.WriteFile(testFile, []byte("hello world\n"), 0644)
+	require.NoError(t, err)
+
+	result, err := fileutil.FileSHA256(testFile)
+	require.NoError(t, err)
+	require.Equal(t, "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447", result)
+
+	_, err = fileutil.FileSHA256(filepath.Join(t.TempDir(), "nonexistent"))
+	require.Error(t, err)
+}
+
 // externalComputeSha1Sum uses external tool (sha1sum for example) to compute shasum
 // Used only in tests
 func externalComputeSha1Sum(filePath string) (string, error) {
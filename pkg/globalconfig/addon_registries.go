@@ -0,0 +1,79 @@
+package globalconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// PublicAddonRegistryName is the name of the public add-on registry at addons.ddev.com
+const PublicAddonRegistryName = "ddev"
+
+// AddonRegistry is an add-on registry from addon_registries.
+// Its location is the URL of a JSON index in the format of addons.ddev.com,
+// a file:// URL or path of such an index, or of a directory with an
+// addons.json index (like the ones 'ddev add-on mirror' writes), or of
+// a directory of add-ons in <name> or <owner>/<name> subdirectories.
+type AddonRegistry struct {
+	Name     string `yaml:"name"`
+	Location string `yaml:"location,omitempty"`
+	// Priority orders the registries, the highest first. The public registry has priority 0.
+	Priority int  `yaml:"priority,omitempty"`
+	Disabled bool `yaml:"disabled,omitempty"`
+}
+
+var validAddonRegistryName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// ValidateAddonRegistries checks the addon_registries of the global config
+func ValidateAddonRegistries(registries []AddonRegistry) error {
+	seen := map[string]bool{}
+	for _, r := range registries {
+		if !validAddonRegistryName.MatchString(r.Name) {
+			return fmt.Errorf("addon_registries: invalid name '%s', it must contain only letters, digits, '-' and '_'", r.Name)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("addon_registries: the name '%s' is used more than once", r.Name)
+		}
+		seen[r.Name] = true
+		if r.Location == "" && r.Name != PublicAddonRegistryName && !r.Disabled {
+			return fmt.Errorf("addon_registries: the '%s' registry has no location", r.Name)
+		}
+	}
+	return nil
+}
+
+// GetAddonRegistries returns the enabled add-on registries, the highest
+// priority first. The public registry is included unless it is disabled, its
+// location is remote_config.addon_data_url unless another one is configured.
+func GetAddonRegistries() []AddonRegistry {
+	public := AddonRegistry{Name: PublicAddonRegistryName}
+	var registries []AddonRegistry
+	for _, r := range DdevGlobalConfig.AddonRegistries {
+		if r.Name == PublicAddonRegistryName {
+			public = r
+			continue
+		}
+		if !r.Disabled {
+			registries = append(registries, r)
+		}
+	}
+	if !public.Disabled {
+		if public.Location == "" {
+			public.Location = DdevGlobalConfig.RemoteConfig.AddonDataURL
+		}
+		if public.Location == "" {
+			public.Location = DefaultAddonDataURL
+		}
+		registries = append(registries, public)
+	}
+	sort.SliceStable(registries, func(i, j int) bool {
+		return registries[i].Priority > registries[j].Priority
+	})
+	return registries
+}
+
+// HasCustomAddonRegistries reports whether add-on registries other than the public one are used
+func HasCustomAddonRegistries() bool {
+	registries := GetAddonRegistries()
+	return len(registries) != 1 || registries[0].Name != PublicAddonRegistryName
+}
@@ -39,45 +39,48 @@ type ProjectInfo struct {
 
 // GlobalConfig is the struct defining ddev's global config
 type GlobalConfig struct {
-	DeveloperMode                    bool                        `yaml:"developer_mode,omitempty"`
-	DockerBuildxVersion              string                      `yaml:"docker_buildx_version,omitempty"`
-	FailOnHookFailGlobal             bool                        `yaml:"fail_on_hook_fail"`
-	InstrumentationOptIn             bool                        `yaml:"instrumentation_opt_in"`
-	InstrumentationQueueSize         int                         `yaml:"instrumentation_queue_size,omitempty"`
-	InstrumentationReportingInterval time.Duration               `yaml:"instrumentation_reporting_interval,omitempty"`
-	InstrumentationUser              string                      `yaml:"instrumentation_user,omitempty"`
-	InternetDetectionTimeout         int64                       `yaml:"internet_detection_timeout_ms"`
-	LastStartedVersion               string                      `yaml:"last_started_version"`
-	LetsEncryptEmail                 string                      `yaml:"letsencrypt_email"`
-	Messages                         MessagesConfig              `yaml:"messages,omitempty"`
-	MkcertCARoot                     string                      `yaml:"mkcert_caroot"`
-	NoBindMounts                     bool                        `yaml:"no_bind_mounts"`
-	NoTUI                            bool                        `yaml:"no_tui,omitempty"`
-	OmitContainersGlobal             []string                    `yaml:"omit_containers,flow"`
-	OmitProjectNameByDefault         bool                        `yaml:"omit_project_name_by_default,omitempty"`
-	OmitSnapshotOnDelete             bool                        `yaml:"omit_snapshot_on_delete,omitempty"`
-	PerformanceMode                  configTypes.PerformanceMode `yaml:"performance_mode"`
-	ProjectTldGlobal                 string                      `yaml:"project_tld"`
-	RemoteConfig                     RemoteConfig                `yaml:"remote_config,omitempty"`
-	Router                           string                      `yaml:"router,omitempty"`
-	RouterBindAllInterfaces          bool                        `yaml:"router_bind_all_interfaces"`
-	RouterHTTPPort                   string                      `yaml:"router_http_port"`
-	RouterHTTPSPort                  string                      `yaml:"router_https_port"`
-	RouterMailpitHTTPPort            string                      `yaml:"mailpit_http_port,omitempty"`
-	RouterMailpitHTTPSPort           string                      `yaml:"mailpit_https_port,omitempty"`
-	RouterXHGuiHTTPPort              string                      `yaml:"xhgui_http_port,omitempty"`
-	RouterXHGuiHTTPSPort             string                      `yaml:"xhgui_https_port,omitempty"`
-	ShareDefaultProvider             string                      `yaml:"share_default_provider,omitempty"`
-	SimpleFormatting                 bool                        `yaml:"simple_formatting"`
-	TableStyle                       string                      `yaml:"table_style"`
-	TraefikMonitorPort               string                      `yaml:"traefik_monitor_port,omitempty"`
-	UseHardenedImages                bool                        `yaml:"use_hardened_images"`
-	UseLetsEncrypt                   bool                        `yaml:"use_letsencrypt"`
-	WSL2NoWindowsHostsMgt            bool                        `yaml:"wsl2_no_windows_hosts_mgt"`
-	WebEnvironment                   []string                    `yaml:"web_environment"`
-	XdebugIDELocation                string                      `yaml:"xdebug_ide_location"`
-	XHProfMode                       configTypes.XHProfMode      `yaml:"xhprof_mode,omitempty"`
-	ProjectList                      map[string]*ProjectInfo     `yaml:"project_info,omitempty"`
+	AddonRegistries                  []AddonRegistry               `yaml:"addon_registries,omitempty"`
+	DeveloperMode                    bool                          `yaml:"developer_mode,omitempty"`
+	DockerBuildxVersion              string                        `yaml:"docker_buildx_version,omitempty"`
+	FailOnHookFailGlobal             bool                          `yaml:"fail_on_hook_fail"`
+	InstrumentationOptIn             bool                          `yaml:"instrumentation_opt_in"`
+	InstrumentationQueueSize         int                           `yaml:"instrumentation_queue_size,omitempty"`
+	InstrumentationReportingInterval time.Duration                 `yaml:"instrumentation_reporting_interval,omitempty"`
+	InstrumentationUser              string                        `yaml:"instrumentation_user,omitempty"`
+	InternetDetectionTimeout         int64                         `yaml:"internet_detection_timeout_ms"`
+	LastStartedVersion               string                        `yaml:"last_started_version"`
+	LetsEncryptEmail                 string                        `yaml:"letsencrypt_email"`
+	Messages                         MessagesConfig                `yaml:"messages,omitempty"`
+	MkcertCARoot                     string                        `yaml:"mkcert_caroot"`
+	NoBindMounts                     bool                          `yaml:"no_bind_mounts"`
+	NoTUI                            bool                          `yaml:"no_tui,omitempty"`
+	OmitContainersGlobal             []string                      `yaml:"omit_containers,flow"`
+	OmitProjectNameByDefault         bool                          `yaml:"omit_project_name_by_default,omitempty"`
+	OmitSnapshotOnDelete             bool                          `yaml:"omit_snapshot_on_delete,omitempty"`
+	PerformanceMode                  configTypes.PerformanceMode   `yaml:"performance_mode"`
+	ProjectTldGlobal                 string                        `yaml:"project_tld"`
+	RemoteConfig                     RemoteConfig                  `yaml:"remote_config,omitempty"`
+	Router                           string                        `yaml:"router,omitempty"`
+	RouterBindAllInterfaces          bool                          `yaml:"router_bind_all_interfaces"`
+	RouterHTTPPort                   string                        `yaml:"router_http_port"`
+	RouterHTTPSPort                  string                        `yaml:"router_https_port"`
+	RouterTCPRouting                 bool                          `yaml:"router_tcp_routing,omitempty"`
+	RouterMailpitHTTPPort            string                        `yaml:"mailpit_http_port,omitempty"`
+	RouterMailpitHTTPSPort           string                        `yaml:"mailpit_https_port,omitempty"`
+	RouterXHGuiHTTPPort              string                        `yaml:"xhgui_http_port,omitempty"`
+	RouterXHGuiHTTPSPort             string                        `yaml:"xhgui_https_port,omitempty"`
+	ShareDefaultProvider             string                        `yaml:"share_default_provider,omitempty"`
+	SimpleFormatting                 bool                          `yaml:"simple_formatting"`
+	SnapshotRetention                configTypes.SnapshotRetention `yaml:"snapshot_retention,omitempty"`
+	TableStyle                       string                        `yaml:"table_style"`
+	TraefikMonitorPort               string                        `yaml:"traefik_monitor_port,omitempty"`
+	UseHardenedImages                bool                          `yaml:"use_hardened_images"`
+	UseLetsEncrypt                   bool                          `yaml:"use_letsencrypt"`
+	WSL2NoWindowsHostsMgt            bool                          `yaml:"wsl2_no_windows_hosts_mgt"`
+	WebEnvironment                   []string                      `yaml:"web_environment"`
+	XdebugIDELocation                string                        `yaml:"xdebug_ide_location"`
+	XHProfMode                       configTypes.XHProfMode        `yaml:"xhprof_mode,omitempty"`
+	ProjectList                      map[string]*ProjectInfo       `yaml:"project_info,omitempty"`
 }
 
 // New returns a default GlobalConfig
@@ -220,6 +223,14 @@ func ValidateGlobalConfig() error {
 		return fmt.Errorf(`xdebug_ide_location must be IP address or one of %v`, ValidXdebugIDELocations)
 	}
 
+	if err := DdevGlobalConfig.SnapshotRetention.Validate(); err != nil {
+		return err
+	}
+
+	if err := ValidateAddonRegistries(DdevGlobalConfig.AddonRegistries); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -399,6 +410,14 @@ func WriteGlobalConfig(config GlobalConfig) error {
 # If true, 'ddev delete' will omit the database snapshot by default.
 # This can still be overridden per-command with --omit-snapshot=false.
 
+# snapshot_retention:
+#   keep_last: 5
+#   keep_daily: 7
+#   max_size: 10GB
+# Database snapshots to keep when snapshots are pruned, which happens after
+# every snapshot and with 'ddev snapshot prune'. Unset rules are not applied.
+# Can be overridden in project config.
+
 # performance_mode: "<default for the OS>"
 # DDEV offers performance optimization strategies to improve the filesystem
 # performance depending on your host system. Can be overridden with the project
@@ -451,6 +470,22 @@ func WriteGlobalConfig(config GlobalConfig) error {
 # xhprof_mode: [prepend|xhgui]
 # Default is "xhgui"
 
+# addon_registries:
+#   - name: company
+#     location: https://git.example.com/ddev/addons.json
+#     priority: 10
+#   - name: offline
+#     location: /srv/ddev-addon-mirror
+#     priority: 20
+#   - name: ddev
+#     disabled: true
+# Add-on registries used by 'ddev add-on search', 'list' and 'get', in addition
+# to the public one at addons.ddev.com, named "ddev" with priority 0.
+# A location is the URL of a JSON index in the format of addons.ddev.com, a
+# file:// URL or path of such an index, a directory of add-ons, or a directory
+# written by 'ddev add-on mirror'. Add-ons found in several registries are
+# taken from the one with the highest priority.
+
 # developer_mode: true # (defaults to false) is not used at this time
 
 # router_bind_all_interfaces: false  # (defaults to false)
@@ -459,6 +494,11 @@ func WriteGlobalConfig(config GlobalConfig) error {
 #    access those ports. Note that this exposes the Mailpit ports as well, which
 #    can be a major security issue, so choose wisely.
 
+# router_tcp_routing: false  # (defaults to false)
+#    If true, ddev-router also routes TCP connections to the db container
+#    and to services defining TCP_EXPOSE, on stable hostnames like
+#    db.<project>.ddev.site:5432, using TLS SNI.
+
 # use_hardened_images: false
 # With hardened images, a container that is exposed to the internet is
 # a harder target, although not as hard as a fully-secured host.
@@ -0,0 +1,184 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// EventsFormatJSONL is the only supported --events format, one JSON event per line
+const EventsFormatJSONL = "jsonl"
+
+// Event types emitted with --events
+const (
+	// EventPhaseStarted and EventPhaseFinished bracket a phase of a command, like "build"
+	EventPhaseStarted  = "phase_started"
+	EventPhaseFinished = "phase_finished"
+	// EventImagePull reports the progress of an image pull
+	EventImagePull = "image_pull"
+	// EventContainerHealth reports a change of the health of a container
+	EventContainerHealth = "container_health"
+	// EventHookTask reports the result of a hook task
+	EventHookTask = "hook_task"
+	// EventURLs reports the URLs of a started project
+	EventURLs = "urls"
+	// EventError reports the error a command failed with
+	EventError = "error"
+)
+
+// Event is a single machine-readable event. Only the fields relevant to its Type are set.
+type Event struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Project  string    `json:"project,omitempty"`
+	Phase    string    `json:"phase,omitempty"`
+	Hook     string    `json:"hook,omitempty"`
+	Task     string    `json:"task,omitempty"`
+	Image    string    `json:"image,omitempty"`
+	Service  string    `json:"service,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Percent  *int      `json:"percent,omitempty"`
+	Duration *float64  `json:"duration_seconds,omitempty"`
+	URLs     []string  `json:"urls,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+var (
+	// EventsFormat is the value of the --events flag.
+	// Like JSONOutput, it's parsed before Cobra flag initialization, because
+	// stdout has to be reserved for the events before anything is written to it.
+	EventsFormat = func() string {
+		if testing.Testing() {
+			return ""
+		}
+		return parseEventsFlag(os.Args[1:])
+	}()
+	// eventsOut is where events are written, the original stdout.
+	// Everything else written to stdout goes to stderr instead.
+	eventsOut = func() io.Writer {
+		if EventsFormat == "" {
+			return nil
+		}
+		out := os.Stdout
+		os.Stdout = os.Stderr
+		return out
+	}()
+	eventsMu sync.Mutex
+)
+
+// EventsEnabled reports whether events are emitted
+func EventsEnabled() bool {
+	return eventsOut != nil
+}
+
+// ValidateEventsFormat returns an error if --events has an unsupported value
+func ValidateEventsFormat(format string) error {
+	if format != "" && format != EventsFormatJSONL {
+		return fmt.Errorf("unsupported --events format '%s', the only supported format is '%s'", format, EventsFormatJSONL)
+	}
+	return nil
+}
+
+// EmitEvent writes an event if events are enabled
+func EmitEvent(e Event) {
+	if eventsOut == nil {
+		return
+	}
+	emitEventTo(eventsOut, e)
+}
+
+// emitEventTo writes an event as a line of JSON
+func emitEventTo(out io.Writer, e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	_, _ = out.Write(append(b, '\n'))
+}
+
+// EventDuration converts a duration to the seconds reported in events
+func EventDuration(d time.Duration) *float64 {
+	s := d.Round(time.Millisecond).Seconds()
+	return &s
+}
+
+// PhaseTracker emits the phase events of a command whose phases run one after the other
+type PhaseTracker struct {
+	project string
+	phase   string
+	start   time.Time
+}
+
+// NewPhaseTracker returns a PhaseTracker for the phases of a project's command
+func NewPhaseTracker(project string) *PhaseTracker {
+	return &PhaseTracker{project: project}
+}
+
+// Start finishes the current phase successfully and starts the named one
+func (t *PhaseTracker) Start(phase string) {
+	t.Finish(nil)
+	t.phase = phase
+	t.start = time.Now()
+	EmitEvent(Event{Type: EventPhaseStarted, Project: t.project, Phase: phase})
+}
+
+// Finish finishes the current phase, if any, with the error it failed with
+func (t *PhaseTracker) Finish(err error) {
+	if t.phase == "" {
+		return
+	}
+	e := Event{Type: EventPhaseFinished, Project: t.project, Phase: t.phase, Status: "success", Duration: EventDuration(time.Since(t.start))}
+	if err != nil {
+		e.Status = "failed"
+		e.Error = err.Error()
+	}
+	EmitEvent(e)
+	t.phase = ""
+}
+
+// eventsCommands are the commands, and their aliases, that have the --events flag
+var eventsCommands = []string{"start", "add", "stop", "rm", "remove", "restart", "pull"}
+
+// parseEventsFlag returns the value of --events if the command has the flag,
+// so that other commands, like `ddev exec`, can pass --events on
+func parseEventsFlag(args []string) string {
+	i := slices.IndexFunc(args, func(arg string) bool {
+		return !strings.HasPrefix(arg, "-")
+	})
+	if i < 0 || !slices.Contains(eventsCommands, args[i]) {
+		return ""
+	}
+	return parseStringFlag(args, "events")
+}
+
+// parseStringFlag scans the arguments backward for the last value of a string flag,
+// in the --long=value or --long value forms.
+// Returns "" if the flag is absent.
+func parseStringFlag(args []string, long string) string {
+	// Arguments after "--" are passed on to another command
+	if i := slices.Index(args, "--"); i >= 0 {
+		args = args[:i]
+	}
+	prefix := "--" + long + "="
+	for i, arg := range slices.Backward(args) {
+		if strings.HasPrefix(arg, prefix) {
+			return arg[len(prefix):]
+		}
+		if arg == "--"+long && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseEventsFlag checks that --events is only picked up for the commands that have it
+func TestParseEventsFlag(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"start", "--events=jsonl"}, want: "jsonl"},
+		{args: []string{"-j", "start", "--events", "jsonl", "myproject"}, want: "jsonl"},
+		{args: []string{"pull", "upsun", "--events=jsonl", "-y"}, want: "jsonl"},
+		{args: []string{"rm", "--events=other", "--events=jsonl"}, want: "jsonl"},
+		{args: []string{"start"}, want: ""},
+		{args: []string{"start", "--events"}, want: ""},
+		{args: []string{"exec", "mytool", "--events=jsonl"}, want: ""},
+		{args: []string{"restart", "--", "--events=jsonl"}, want: ""},
+		{args: []string{}, want: ""},
+	}
+	for _, tc := range tests {
+		require.Equal(t, tc.want, parseEventsFlag(tc.args), "%v", tc.args)
+	}
+}
+
+// TestEmitEvent checks the JSON lines written for events
+func TestEmitEvent(t *testing.T) {
+	var out bytes.Buffer
+	origEventsOut := eventsOut
+	eventsOut = &out
+	t.Cleanup(func() { eventsOut = origEventsOut })
+
+	phases := NewPhaseTracker("myproject")
+	phases.Start("build")
+	phases.Start("start_containers")
+	phases.Finish(errors.New("boom"))
+	// Finishing again doesn't emit anything
+	phases.Finish(nil)
+	percent := 50
+	EmitEvent(Event{Type: EventImagePull, Image: "ddev/ddev-webserver", Status: "pulling", Percent: &percent})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	var events []map[string]any
+	for _, line := range lines {
+		var e map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		require.NotEmpty(t, e["time"])
+		events = append(events, e)
+	}
+	require.Equal(t, EventPhaseStarted, events[0]["type"])
+	require.Equal(t, "build", events[0]["phase"])
+	require.Equal(t, "myproject", events[0]["project"])
+	require.NotContains(t, events[0], "duration_seconds")
+	require.Equal(t, EventPhaseFinished, events[1]["type"])
+	require.Equal(t, "success", events[1]["status"])
+	require.Contains(t, events[1], "duration_seconds")
+	require.Equal(t, EventPhaseStarted, events[2]["type"])
+	require.Equal(t, "start_containers", events[3]["phase"])
+	require.Equal(t, "failed", events[3]["status"])
+	require.Equal(t, "boom", events[3]["error"])
+	require.Equal(t, float64(50), events[4]["percent"])
+
+	require.NoError(t, ValidateEventsFormat(""))
+	require.NoError(t, ValidateEventsFormat(EventsFormatJSONL))
+	require.Error(t, ValidateEventsFormat("json"))
+}
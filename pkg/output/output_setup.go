@@ -20,6 +20,10 @@ var (
 	UserOut = func() *log.Logger {
 		l := log.New()
 		l.SetOutput(os.Stdout)
+		// With --events, stdout is reserved for the events
+		if EventsEnabled() {
+			l.SetOutput(os.Stderr)
+		}
 		logLevel := log.InfoLevel
 		if nodeps.IsEnvTrue("DDEV_DEBUG") || nodeps.IsEnvTrue("DDEV_VERBOSE") {
 			logLevel = log.DebugLevel
@@ -66,6 +66,7 @@ func outputOnce(format string, a []any, fn func(string, ...any)) {
 
 // Failed will print a red error message and exit with failure.
 func Failed(format string, a ...any) {
+	emitFailedEvent(format, a)
 	format = ColorizeText(format, "red")
 	if a != nil {
 		// output.UserOut.Fatalf(format, a...)
@@ -77,6 +78,18 @@ func Failed(format string, a ...any) {
 	}
 }
 
+// emitFailedEvent emits the --events error event of Failed
+func emitFailedEvent(format string, a []any) {
+	if !output.EventsEnabled() {
+		return
+	}
+	msg := format
+	if a != nil {
+		msg = fmt.Sprintf(format, a...)
+	}
+	output.EmitEvent(output.Event{Type: output.EventError, Error: msg})
+}
+
 // Error will print a red error message but will not exit.
 func Error(format string, a ...any) {
 	format = ColorizeText(format, "red")